@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/trofkm/lakery"
+)
+
+// Smoke test of the lakery_tiny build profile, run by CI with TinyGo:
+//
+//	tinygo run -tags lakery_tiny ./_example/tiny
+
+type Signup struct {
+	Email string   `lakery:"required,email"`
+	Name  string   `lakery:"required,min=3,max=32"`
+	Age   int      `lakery:"gte=18"`
+	Tags  []string `lakery:"each={oneof=go rust zig}"`
+}
+
+func main() {
+	v := lakery.NewValidator(lakery.WithCollectAll())
+
+	valid := Signup{Email: "john@example.com", Name: "john", Age: 30, Tags: []string{"go"}}
+	if err := v.Validate(valid); err != nil {
+		fmt.Println("valid signup failed:", err)
+		os.Exit(1)
+	}
+
+	invalid := Signup{Email: "john", Name: "jo", Age: 12, Tags: []string{"java"}}
+	errs, ok := v.Validate(invalid).(lakery.ValidationErrors)
+	if !ok || len(errs) != 4 {
+		fmt.Println("invalid signup should fail 4 rules, got:", errs)
+		os.Exit(1)
+	}
+	fmt.Println(errs)
+}
@@ -0,0 +1,65 @@
+package lakery
+
+import (
+	"fmt"
+)
+
+// RegisterAlias makes a single tag word expand into a rule set, so common rule sets are declared once:
+//
+//	v.RegisterAlias("password", "required,min=8,max=64,minentropy=3")
+//
+//	type Signup struct {
+//		Password string `lakery:"password"`
+//	}
+//
+// Aliases expand wherever a rule without param is accepted, including each={...}, but not inside
+// alternatives. Rules of an alias may use aliases registered before it.
+// It panics if the rules are malformed or the name is empty or a special tag, since that is a programming error.
+func (v *Validator) RegisterAlias(name, rules string) {
+	if name == "" || isSpecialTag(name) {
+		panic(fmt.Sprintf("lakery: RegisterAlias: invalid alias name %q", name))
+	}
+	parsed, err := parseRules(rules, RuleSourceTag)
+	if err != nil {
+		panic(fmt.Sprintf("lakery: RegisterAlias(%q): %v", name, err))
+	}
+	parsed, err = v.expandRules(parsed)
+	if err != nil {
+		panic(fmt.Sprintf("lakery: RegisterAlias(%q): %v", name, err))
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.mustNotBeFrozen("RegisterAlias", name)
+	v.aliases[name] = parsed
+	v.invalidate()
+}
+
+// expandRules resolves profile rules and then expands aliases, so a profile may select an alias.
+func (v *Validator) expandRules(rules []rule) ([]rule, error) {
+	rules, err := v.resolveProfiles(rules)
+	if err != nil {
+		return nil, err
+	}
+	return v.expandAliases(rules), nil
+}
+
+// expandAliases replaces rules naming an alias with the rules of the alias, keeping their source.
+func (v *Validator) expandAliases(rules []rule) []rule {
+	defer v.runlock(v.rlock())
+	if len(v.aliases) == 0 {
+		return rules
+	}
+	out := make([]rule, 0, len(rules))
+	for _, r := range rules {
+		alias, ok := v.aliases[r.key]
+		if !ok || r.param != "" || r.alts != nil {
+			out = append(out, r)
+			continue
+		}
+		for _, ar := range alias {
+			ar.source = r.source
+			out = append(out, ar)
+		}
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package lakery
+
+import (
+	"reflect"
+	"slices"
+)
+
+// allow=min max required lists the only validators the fields of a struct may use
+const allowTag = "allow"
+
+// isStructRulesField reports whether a field declares rules of the whole struct rather than of a
+// value. Such rules are set on a blank field:
+//
+//	type Credentials struct {
+//		_        struct{} `lakery:"allow=required min max"`
+//		Password string   `lakery:"required,min=12"`
+//	}
+func isStructRulesField(sf reflect.StructField) bool {
+	return sf.Name == "_"
+}
+
+// parseStructRules parses the rules of a blank field and returns the validators listed by allow,
+// nil when the struct doesn't restrict its validators. Blank fields may repeat allow, their
+// lists are joined.
+func (v *Validator) parseStructRules(typ reflect.Type) ([]string, error) {
+	var allow []string
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if !isStructRulesField(sf) {
+			continue
+		}
+		rules, err := v.parseTag(TagRules(sf.Tag, v.tagName))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rules {
+			if r.key != allowTag || r.param == "" {
+				return nil, configErrorf("%s is not a struct rule, expected allow=validators", r)
+			}
+			allow = append(allow, (&Value{param: r.param}).Params()...)
+		}
+	}
+	return allow, nil
+}
+
+// allowed reports whether the validator of key may be used with the validators listed by allow.
+// Special tags like each and dive are always allowed, the rules nested in them are checked.
+func allowed(allow []string, key string) bool {
+	return allow == nil || isSpecialTag(key) || slices.Contains(allow, key)
+}
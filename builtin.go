@@ -2,8 +2,15 @@ package lakery
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+	"unicode"
 )
 
 const (
@@ -11,45 +18,164 @@ const (
 	minTag = "min"
 	// max value for numbers, size for arrays and strings
 	maxTag = "max"
+	// exact size for arrays and strings
+	lenTag = "len"
+	// size for arrays and strings or value for numbers within lo:hi inclusive, e.g. between=2:10
+	betweenTag = "between"
 	// special tag for specifying validation rules for values in arrays
 	eachTag = "each"
+	// special tags for specifying validation rules for keys and values of maps
+	keysTag   = "keys"
+	valuesTag = "values"
+	// special tag skipping the remaining rules when the value is zero
+	omitEmptyTag = "omitempty"
 	// special tag for diving into struct type inside structure
 	diveTag = "dive"
 	// special tag for required fields
 	requiredTag = "required"
+	// minimal Shannon entropy of a string in bits per character
+	minEntropyTag = "minentropy"
+	// value must equal the param, e.g. eq=admin
+	eqTag = "eq"
+	// value must differ from the param, e.g. ne=0
+	neTag = "ne"
+	// number greater than the param, or time after it, e.g. gt=0
+	gtTag = "gt"
+	// number greater than or equal to the param, or time not before it
+	gteTag = "gte"
+	// number less than the param, or time before it, e.g. lt=100
+	ltTag = "lt"
+	// number less than or equal to the param, or time not after it
+	lteTag = "lte"
+	// value must be in the list, e.g. oneof=red green blue
+	oneOfTag = "oneof"
+	// value must not be in the list or in the registered set referenced as @name
+	notInTag = "notin"
+	// value must not be contained in the registered set
+	notForbiddenTag = "notforbidden"
+	// currency amount with at most N decimal places
+	moneyTag = "money"
+	// number between 0 and 100 inclusive
+	percentTag = "percent"
+	// number between 0 and 1 inclusive
+	ratioTag = "ratio"
 )
 
 // registerBuiltins registers built-in validators into the provided validator instance.
-// Built-ins: min, max, required. Special tags: each, dive are handled in tag processing flow.
+// Built-ins: min, max, len, between, required, eq, ne, gt, gte, lt, lte, minentropy, oneof, notin,
+// notforbidden, money, percent, ratio, inrange, ip, ipv4, ipv6, cidr, mac, incidr, incidrfield, regexp,
+// email, uuid, url, uri, alpha, alphanum, alphaunicode, alphanumunicode, contains, excludes, containsany,
+// excludesall, startswith, endswith, lowercase, uppercase, numeric, number, urlhost, urlnocreds,
+// safepath, sqlident, goident, eqfield, nefield, gtfield, gtefield, ltfield, ltefield, required_if,
+// required_unless, required_with, required_without, required_without_all, excluded_with, excluded_if,
+// nfc, nfkc and the default, trim, lower, truncate, tonfc, tonfkc sanitizers.
+// Normalization tags are not available in the lakery_tiny build profile.
+// Built-ins checking the content of strings let empty strings (and nil pointers) pass, so optional fields
+// only need the tag and required fields combine it with required.
+// Special tags: each, keys, values, dive, omitempty are handled in tag processing flow.
 func (v *Validator) registerBuiltins() {
 	v.RegisterTag(minTag, builtinMin)
 	v.RegisterTag(maxTag, builtinMax)
+	v.RegisterTag(lenTag, builtinLen)
+	v.RegisterTag(betweenTag, builtinBetween)
 	v.RegisterTag(requiredTag, builtinRequired)
+	v.RegisterTag(minEntropyTag, builtinMinEntropy)
+	v.RegisterTag(eqTag, builtinEq)
+	v.RegisterTag(neTag, builtinNe)
+	v.RegisterTag(gtTag, builtinCompare(gtTag, ">", "be after", func(cmp int) bool { return cmp > 0 }))
+	v.RegisterTag(gteTag, builtinCompare(gteTag, ">=", "not be before", func(cmp int) bool { return cmp >= 0 }))
+	v.RegisterTag(ltTag, builtinCompare(ltTag, "<", "be before", func(cmp int) bool { return cmp < 0 }))
+	v.RegisterTag(lteTag, builtinCompare(lteTag, "<=", "not be after", func(cmp int) bool { return cmp <= 0 }))
+	v.RegisterTag(oneOfTag, builtinOneOf)
+	v.RegisterTag(notInTag, builtinNotIn)
+	v.RegisterTag(notForbiddenTag, builtinNotForbidden)
+	v.RegisterTag(moneyTag, builtinMoney)
+	v.RegisterTag(percentTag, builtinBounded(percentTag, 0, 100))
+	v.RegisterTag(ratioTag, builtinBounded(ratioTag, 0, 1))
+	v.RegisterTag(inRangeTag, builtinInRange)
+	v.RegisterTag(ipTag, builtinIP(ipTag, "an IP address", netip.Addr.IsValid))
+	v.RegisterTag(ipv4Tag, builtinIP(ipv4Tag, "an IPv4 address", netip.Addr.Is4))
+	v.RegisterTag(ipv6Tag, builtinIP(ipv6Tag, "an IPv6 address", netip.Addr.Is6))
+	v.RegisterTag(cidrTag, builtinCIDR)
+	v.RegisterTag(macTag, builtinMAC)
+	v.RegisterTag(inCIDRTag, builtinInCIDR)
+	v.RegisterTag(inCIDRFieldTag, builtinInCIDRField)
+	v.RegisterTag(emailTag, builtinEmail)
+	v.RegisterTag(regexpTag, builtinRegexp)
+	v.RegisterTag(uuidTag, builtinUUID)
+	v.RegisterTag(urlTag, builtinURL)
+	v.RegisterTag(uriTag, builtinURI)
+	v.RegisterTag(alphaTag, builtinCharClass(alphaTag, "ASCII letters", isASCIILetter))
+	v.RegisterTag(alphaNumTag, builtinCharClass(alphaNumTag, "ASCII letters and digits", isASCIILetterOrDigit))
+	v.RegisterTag(alphaUnicodeTag, builtinCharClass(alphaUnicodeTag, "letters", unicode.IsLetter))
+	v.RegisterTag(alphaNumUnicodeTag, builtinCharClass(alphaNumUnicodeTag, "letters and digits", isLetterOrNumber))
+	v.RegisterTag(containsTag, builtinSubstring(containsTag, "contain", true, strings.Contains))
+	v.RegisterTag(excludesTag, builtinSubstring(excludesTag, "not contain", false, strings.Contains))
+	v.RegisterTag(containsAnyTag, builtinSubstring(containsAnyTag, "contain any of", true, strings.ContainsAny))
+	v.RegisterTag(excludesAllTag, builtinSubstring(excludesAllTag, "not contain any of", false, strings.ContainsAny))
+	v.RegisterTag(startsWithTag, builtinSubstring(startsWithTag, "start with", true, strings.HasPrefix))
+	v.RegisterTag(endsWithTag, builtinSubstring(endsWithTag, "end with", true, strings.HasSuffix))
+	v.RegisterTag(lowercaseTag, builtinCase(lowercaseTag, "lower case", strings.ToLower))
+	v.RegisterTag(uppercaseTag, builtinCase(uppercaseTag, "upper case", strings.ToUpper))
+	v.RegisterTag(numericTag, builtinNumeric)
+	v.RegisterTag(numberTag, builtinCharClass(numberTag, "digits", isASCIIDigit))
+	v.RegisterTag(urlHostTag, builtinURLHost)
+	v.RegisterTag(urlNoCredsTag, builtinURLNoCreds)
+	v.RegisterTag(safePathTag, builtinSafePath)
+	v.RegisterTag(sqlIdentTag, builtinSQLIdent)
+	v.RegisterTag(goIdentTag, builtinGoIdent)
+	v.RegisterTag(eqFieldTag, builtinEqField)
+	v.RegisterTag(neFieldTag, builtinNeField)
+	v.RegisterTag(gtFieldTag, builtinCompareField(gtFieldTag, "greater than", func(cmp int) bool { return cmp > 0 }))
+	v.RegisterTag(gteFieldTag, builtinCompareField(gteFieldTag, "greater than or equal to", func(cmp int) bool { return cmp >= 0 }))
+	v.RegisterTag(ltFieldTag, builtinCompareField(ltFieldTag, "less than", func(cmp int) bool { return cmp < 0 }))
+	v.RegisterTag(lteFieldTag, builtinCompareField(lteFieldTag, "less than or equal to", func(cmp int) bool { return cmp <= 0 }))
+	v.RegisterTag(requiredIfTag, builtinRequiredIf)
+	v.RegisterTag(requiredUnlessTag, builtinRequiredUnless)
+	v.RegisterTag(requiredWithTag, builtinRequiredWith)
+	v.RegisterTag(requiredWithoutTag, builtinRequiredWithout)
+	v.RegisterTag(requiredWithoutAllTag, builtinRequiredWithoutAll)
+	v.RegisterTag(excludedWithTag, builtinExcludedWith)
+	v.RegisterTag(excludedIfTag, builtinExcludedIf)
+	v.RegisterTag(trimTag, builtinTrim)
+	v.RegisterTag(lowerTag, builtinLower)
+	v.RegisterTag(truncateTag, builtinTruncate)
+	v.RegisterTag(defaultTag, builtinDefault)
+	v.registerNormBuiltins()
 }
 
 // builtinMin validates that a value is not less than the provided minimum.
 // - strings, arrays, slices: len(value) >= min
 // - integers (signed/unsigned) and floats: value >= min
+// - time.Time: not before the RFC 3339 or date-only param, see checkTimeBound
 func builtinMin(val *Value) error {
+	if isTime(val) {
+		return checkTimeBound(minTag, val, "not be before", func(cmp int) bool { return cmp >= 0 })
+	}
 	minStr := val.Param()
 	minInt, err := strconv.Atoi(minStr)
 	if err != nil {
-		return fmt.Errorf("min expects integer param: %w", err)
+		return configErrorf("min expects integer param: %w", err)
 	}
 	min := minInt
 
-	rv := val.val
+	rv := val.Deref().val
+	if !rv.IsValid() {
+		// nil pointer or interface fails len-based checks; consider nil < min unless min <= 0
+		if min > 0 {
+			return fmt.Errorf("should have length at least %d", min)
+		}
+		return nil
+	}
 	k := rv.Kind()
-	if k == reflect.Pointer {
-		if rv.IsNil() {
-			// nil pointer fails len-based checks; consider nil < min unless min <= 0
-			if min > 0 {
-				return fmt.Errorf("should have length at least %d", min)
-			}
-			return nil
+	if r, registered := val.number(rv); registered {
+		if r == nil {
+			return fmt.Errorf("should be a finite number")
 		}
-		rv = rv.Elem()
-		k = rv.Kind()
+		if r.Cmp(big.NewRat(int64(min), 1)) < 0 {
+			return fmt.Errorf("should be >= %d", min)
+		}
+		return nil
 	}
 
 	switch k {
@@ -74,30 +200,39 @@ func builtinMin(val *Value) error {
 		}
 		return nil
 	default:
-		return fmt.Errorf("min is not applicable to type %s", rv.Type())
+		return configErrorf("min is not applicable to type %s", rv.Type())
 	}
 }
 
 // builtinMax validates that a value is not greater than the provided maximum.
 // - strings, arrays, slices: len(value) <= max
 // - integers (signed/unsigned) and floats: value <= max
+// - time.Time: not after the RFC 3339 or date-only param, see checkTimeBound
 func builtinMax(val *Value) error {
+	if isTime(val) {
+		return checkTimeBound(maxTag, val, "not be after", func(cmp int) bool { return cmp <= 0 })
+	}
 	maxStr := val.Param()
 	maxInt, err := strconv.Atoi(maxStr)
 	if err != nil {
-		return fmt.Errorf("max expects integer param: %w", err)
+		return configErrorf("max expects integer param: %w", err)
 	}
 	max := maxInt
 
-	rv := val.val
+	rv := val.Deref().val
+	if !rv.IsValid() {
+		// nil pointer has length 0; only passes if max >= 0
+		return nil
+	}
 	k := rv.Kind()
-	if k == reflect.Pointer {
-		if rv.IsNil() {
-			// nil pointer has length 0; only passes if max >= 0
-			return nil
+	if r, registered := val.number(rv); registered {
+		if r == nil {
+			return fmt.Errorf("should be a finite number")
+		}
+		if r.Cmp(big.NewRat(int64(max), 1)) > 0 {
+			return fmt.Errorf("should be <= %d", max)
 		}
-		rv = rv.Elem()
-		k = rv.Kind()
+		return nil
 	}
 
 	switch k {
@@ -122,22 +257,527 @@ func builtinMax(val *Value) error {
 		}
 		return nil
 	default:
-		return fmt.Errorf("max is not applicable to type %s", rv.Type())
+		return configErrorf("max is not applicable to type %s", rv.Type())
 	}
 }
 
+// builtinLen validates that a string (in bytes, like min and max), slice, array or map has exactly
+// N elements. Nil pointers have length 0.
+func builtinLen(val *Value) error {
+	n, err := strconv.Atoi(val.Param())
+	if err != nil || n < 0 {
+		return configErrorf("len expects a non-negative integer param")
+	}
+	rv := val.Deref().val
+	length := 0
+	if rv.IsValid() {
+		switch rv.Kind() {
+		case reflect.String, reflect.Array, reflect.Slice, reflect.Map:
+			length = rv.Len()
+		default:
+			return configErrorf("len is not applicable to type %s", rv.Type())
+		}
+	}
+	if length != n {
+		return fmt.Errorf("should have length %d", n)
+	}
+	return nil
+}
+
+// builtinBetween validates a length or value within the lo:hi param, inclusive, with a single message:
+// - strings (in bytes), arrays, slices, maps: lo <= len(value) <= hi; nil pointers have length 0
+// - integers, floats and registered number types: lo <= value <= hi compared like compareNumber; nil pointers pass
+func builtinBetween(val *Value) error {
+	loStr, hiStr, lo, hi, err := rangeParam(betweenTag, val)
+	if err != nil {
+		return err
+	}
+	rv := val.Deref().val
+	if !rv.IsValid() {
+		if !val.val.IsValid() || !hasLength(val.val.Type()) {
+			return nil
+		}
+		rv = reflect.ValueOf("")
+	}
+	if _, registered := val.number(rv); !registered && hasLength(rv.Type()) {
+		if !lo.IsInt() || !hi.IsInt() {
+			return configErrorf("between expects integer bounds for the length of %s", rv.Type())
+		}
+		if n := big.NewRat(int64(rv.Len()), 1); n.Cmp(lo) < 0 || n.Cmp(hi) > 0 {
+			return fmt.Errorf("should have length between %s and %s", loStr, hiStr)
+		}
+		return nil
+	}
+	in, err := val.numberInRange(betweenTag, rv, lo, hi)
+	if err != nil {
+		return err
+	}
+	if !in {
+		return fmt.Errorf("should be between %s and %s", loStr, hiStr)
+	}
+	return nil
+}
+
+// rangeParam parses the lo:hi param of between and inrange into exact bounds, rejecting lo > hi.
+func rangeParam(tag string, val *Value) (loStr, hiStr string, lo, hi *big.Rat, err error) {
+	loStr, hiStr, ok := val.ParamPair()
+	lo, loOK := new(big.Rat).SetString(loStr)
+	hi, hiOK := new(big.Rat).SetString(hiStr)
+	if !ok || !loOK || !hiOK || lo.Cmp(hi) > 0 {
+		return "", "", nil, nil, configErrorf("%s expects a lo:hi param with lo <= hi", tag)
+	}
+	return loStr, hiStr, lo, hi, nil
+}
+
+// numberInRange reports whether the number rv (already dereferenced) lies within [lo, hi], compared
+// like compareNumber does.
+func (val *Value) numberInRange(tag string, rv reflect.Value, lo, hi *big.Rat) (bool, error) {
+	aboveLo, isNumber, err := val.compareNumber(rv, lo)
+	if !isNumber {
+		return false, configErrorf("%s is not applicable to type %s", tag, rv.Type())
+	}
+	if err != nil {
+		return false, err
+	}
+	belowHi, _, _ := val.compareNumber(rv, hi)
+	return aboveLo >= 0 && belowHi <= 0, nil
+}
+
+// hasLength reports whether values of typ, behind pointers, are measured by their length.
+func hasLength(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.String, reflect.Array, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
 // builtinRequired validates that a value is not the zero value (non-empty string, non-zero number,
-// non-nil pointer/slice/map/function/interface, and structs with any non-zero field).
+// non-nil pointer/slice/map/function/interface, and structs with any non-zero field). Types with an
+// IsZero() bool method, like time.Time or option types with private state, report it themselves.
 func builtinRequired(val *Value) error {
-	rv := val.val
-	if rv.Kind() == reflect.Pointer {
-		if rv.IsNil() {
-			return fmt.Errorf("is required")
+	rv := val.Deref().val
+	if !rv.IsValid() || isZero(rv) {
+		return fmt.Errorf("is required")
+	}
+	return nil
+}
+
+// zeroer is implemented by types reporting their own emptiness.
+type zeroer interface {
+	IsZero() bool
+}
+
+var zeroerType = reflect.TypeFor[zeroer]()
+
+// isZero reports whether rv is empty, calling IsZero when the type (or a pointer to it) has the method.
+func isZero(rv reflect.Value) bool {
+	switch {
+	case !rv.CanInterface():
+	case rv.Type().Implements(zeroerType):
+		return rv.Interface().(zeroer).IsZero()
+	case reflect.PointerTo(rv.Type()).Implements(zeroerType):
+		if !rv.CanAddr() {
+			p := reflect.New(rv.Type())
+			p.Elem().Set(rv)
+			rv = p.Elem()
 		}
-		rv = rv.Elem()
+		return rv.Addr().Interface().(zeroer).IsZero()
 	}
-	if rv.IsZero() {
-		return fmt.Errorf("is required")
+	return rv.IsZero()
+}
+
+// isTime reports whether a value is a time.Time, also behind pointers (nil ones included) and interfaces.
+func isTime(val *Value) bool {
+	if rv := val.Deref().val; rv.IsValid() {
+		return rv.Type() == timeType
+	}
+	if !val.val.IsValid() {
+		return false
+	}
+	typ := val.val.Type()
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ == timeType
+}
+
+// checkTimeBound validates a time.Time against the bound of min, max, gt, gte, lt or lte, given in RFC 3339
+// (min=2020-01-01T00:00:00Z) or as a date (min=2020-01-01). Dates are compared with the date of
+// the value in its own location, so max=2020-12-31 accepts the whole day. Nil pointers pass;
+// combine with required to reject them.
+func checkTimeBound(tag string, val *Value, relation string, ok func(cmp int) bool) error {
+	param := val.Param()
+	dateOnly := true
+	bound, err := time.Parse(time.DateOnly, param)
+	if err != nil {
+		dateOnly = false
+		if bound, err = time.Parse(time.RFC3339, param); err != nil {
+			return configErrorf("%s expects an RFC 3339 time or a date (2006-01-02) param for time.Time, got %q", tag, param)
+		}
+	}
+	rv := val.Deref().val
+	if !rv.IsValid() {
+		return nil
+	}
+	t := rv.Interface().(time.Time)
+	if dateOnly {
+		y, m, d := t.Date()
+		t = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	if !ok(t.Compare(bound)) {
+		return fmt.Errorf("should %s %s", relation, param)
+	}
+	return nil
+}
+
+// builtinCompare returns a validator comparing a number with the param like compareNumber, unlike
+// min and max it never checks the length of strings and collections. ok reports whether the
+// comparison result (-1, 0 or +1) satisfies the rule. time.Time values are compared like
+// checkTimeBound does, with timeRelation in the message. Nil pointers pass; combine with required
+// to reject them.
+func builtinCompare(tag, relation, timeRelation string, ok func(cmp int) bool) TagValidationFunc {
+	return func(val *Value) error {
+		if isTime(val) {
+			return checkTimeBound(tag, val, timeRelation, ok)
+		}
+		param := val.Param()
+		bound, valid := new(big.Rat).SetString(param)
+		if !valid {
+			return configErrorf("%s expects a number param, got %q", tag, param)
+		}
+		rv := val.Deref().val
+		if !rv.IsValid() {
+			return nil
+		}
+		result, isNumber, err := val.compareNumber(rv, bound)
+		if !isNumber {
+			return configErrorf("%s is not applicable to type %s", tag, rv.Type())
+		}
+		if err != nil {
+			return err
+		}
+		if !ok(result) {
+			return fmt.Errorf("should be %s %s", relation, param)
+		}
+		return nil
+	}
+}
+
+// builtinMinEntropy validates that a string has at least the provided Shannon entropy,
+// measured in bits per character. Intended for secrets, tokens and API keys. Empty strings pass,
+// use required to reject them.
+func builtinMinEntropy(val *Value) error {
+	minStr := val.Param()
+	minEntropy, err := strconv.ParseFloat(minStr, 64)
+	if err != nil {
+		return configErrorf("minentropy expects float param: %w", err)
+	}
+	s, err := stringValue(minEntropyTag, val)
+	if err != nil {
+		return err
+	}
+	if s != "" && shannonEntropy(s) < minEntropy {
+		return fmt.Errorf("should have entropy at least %s bits per character", minStr)
 	}
 	return nil
 }
+
+// builtinNotIn validates that a string is not one of the forbidden values.
+// The param is either a list (notin=root admin or notin={root,admin}) or a reference
+// to a set registered with RegisterSet (notin=@common_passwords).
+func builtinNotIn(val *Value) error {
+	s, err := stringValue(notInTag, val)
+	if err != nil {
+		return err
+	}
+	if name, ok := strings.CutPrefix(val.Param(), "@"); ok {
+		return checkNotInSet(notInTag, val, name, s)
+	}
+	for _, forbidden := range val.Params() {
+		if s == forbidden {
+			return fmt.Errorf("is not allowed")
+		}
+	}
+	return nil
+}
+
+// builtinEq validates that a string, number or boolean equals the param.
+func builtinEq(val *Value) error {
+	equal, err := equalParam(eqTag, val)
+	if err != nil {
+		return err
+	}
+	if !equal {
+		return fmt.Errorf("should be equal to %s", val.Param())
+	}
+	return nil
+}
+
+// builtinNe validates that a string, number or boolean differs from the param.
+func builtinNe(val *Value) error {
+	equal, err := equalParam(neTag, val)
+	if err != nil {
+		return err
+	}
+	if equal {
+		return fmt.Errorf("should not be equal to %s", val.Param())
+	}
+	return nil
+}
+
+// equalParam compares the value with the param parsed for its type: strings exactly, booleans
+// like strconv.ParseBool and numbers like compareNumber, so eq=1.5 never holds for an int and eq=0.1
+// holds for a float 0.1.
+// Nil pointers pass both eq and ne, use required to reject them.
+func equalParam(tag string, val *Value) (bool, error) {
+	param := val.Param()
+	rv := val.Deref().val
+	if !rv.IsValid() {
+		return tag == eqTag, nil
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String() == param, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(param)
+		if err != nil {
+			return false, configErrorf("%s expects a boolean param: %w", tag, err)
+		}
+		return rv.Bool() == b, nil
+	}
+	if _, isNumber, _ := val.compareNumber(rv, new(big.Rat)); !isNumber {
+		return false, configErrorf("%s is not applicable to type %s", tag, rv.Type())
+	}
+	want, ok := new(big.Rat).SetString(param)
+	if !ok {
+		return false, configErrorf("%s expects a number param", tag)
+	}
+	result, _, err := val.compareNumber(rv, want)
+	return err == nil && result == 0, nil
+}
+
+// builtinOneOf validates that a string or number is one of the values of the param, separated by
+// spaces (oneof=red green blue) or, wrapped into braces, by commas (oneof={red,green,blue}).
+// Strings are compared exactly, numbers by value like compareNumber; nil pointers pass, use required
+// to reject them.
+func builtinOneOf(val *Value) error {
+	allowed := val.Params()
+	if len(allowed) == 0 {
+		return configErrorf("oneof expects a list of values")
+	}
+	rv := val.Deref().val
+	if !rv.IsValid() {
+		return nil
+	}
+	if rv.Kind() == reflect.String {
+		if slices.Contains(allowed, rv.String()) {
+			return nil
+		}
+		return fmt.Errorf("should be one of %s", strings.Join(allowed, ", "))
+	}
+	if _, isNumber, _ := val.compareNumber(rv, new(big.Rat)); !isNumber {
+		return configErrorf("oneof is not applicable to type %s", rv.Type())
+	}
+	for _, s := range allowed {
+		want, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return configErrorf("oneof expects numbers for %s, got %q", rv.Type(), s)
+		}
+		if result, _, err := val.compareNumber(rv, want); err == nil && result == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("should be one of %s", strings.Join(allowed, ", "))
+}
+
+// builtinNotForbidden validates that a string is not contained in the set registered
+// with RegisterSet or RegisterSetValidator under the name given as param.
+func builtinNotForbidden(val *Value) error {
+	s, err := stringValue(notForbiddenTag, val)
+	if err != nil {
+		return err
+	}
+	return checkNotInSet(notForbiddenTag, val, val.Param(), s)
+}
+
+func checkNotInSet(tag string, val *Value, name, s string) error {
+	locked := val.validator.rlock()
+	contains, ok := val.validator.sets[name]
+	val.validator.runlock(locked)
+	if !ok {
+		return configErrorf("%s references unknown set %q", tag, name)
+	}
+	if contains(s) {
+		return fmt.Errorf("is not allowed")
+	}
+	return nil
+}
+
+// builtinMoney validates a currency amount: at most N decimal places and non-negative.
+// money=2 rejects negative amounts, money=2:signed allows them.
+// - floats are checked on their shortest decimal representation, so epsilon noise like 0.30000000000000004 fails
+// - integers always have zero decimal places
+// - strings and decimal types implementing fmt.Stringer are parsed as plain decimal numbers
+// - types registered with RegisterNumberType are checked on their exact value
+func builtinMoney(val *Value) error {
+	placesStr, option, _ := val.ParamPair()
+	places, err := strconv.Atoi(placesStr)
+	if err != nil {
+		return configErrorf("money expects integer param: %w", err)
+	}
+	signed := false
+	switch option {
+	case "":
+	case "signed":
+		signed = true
+	default:
+		return configErrorf("money has unknown option %q", option)
+	}
+
+	rv := val.Deref().val
+	if !rv.IsValid() {
+		return nil
+	}
+
+	if r, registered := val.number(rv); registered {
+		if r == nil {
+			return fmt.Errorf("should be a decimal amount")
+		}
+		if r.Sign() < 0 && !signed {
+			return fmt.Errorf("should not be negative")
+		}
+		if decimals, ok := ratDecimals(r); !ok || decimals > places {
+			return fmt.Errorf("should have at most %d decimal places", places)
+		}
+		return nil
+	}
+
+	var amount string
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		amount = strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		amount = strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32:
+		amount = strconv.FormatFloat(rv.Float(), 'f', -1, 32)
+	case reflect.Float64:
+		amount = strconv.FormatFloat(rv.Float(), 'f', -1, 64)
+	case reflect.String:
+		amount = rv.String()
+	default:
+		stringer, ok := stringerOf(rv)
+		if !ok {
+			return configErrorf("money is not applicable to type %s", rv.Type())
+		}
+		amount = stringer.String()
+	}
+	if amount == "" {
+		return nil
+	}
+
+	negative, decimals, ok := parseDecimal(amount)
+	if !ok {
+		return fmt.Errorf("should be a decimal amount")
+	}
+	if negative && !signed {
+		return fmt.Errorf("should not be negative")
+	}
+	if decimals > places {
+		return fmt.Errorf("should have at most %d decimal places", places)
+	}
+	return nil
+}
+
+// builtinBounded returns a validator checking that a number lies within [lo, hi], compared like compareNumber.
+func builtinBounded(tag string, lo, hi int64) TagValidationFunc {
+	loRat, hiRat := big.NewRat(lo, 1), big.NewRat(hi, 1)
+	return func(val *Value) error {
+		rv := val.Deref().val
+		if !rv.IsValid() {
+			return nil
+		}
+		in, err := val.numberInRange(tag, rv, loRat, hiRat)
+		if err != nil {
+			return err
+		}
+		if !in {
+			return fmt.Errorf("should be between %d and %d", lo, hi)
+		}
+		return nil
+	}
+}
+
+// parseDecimal parses a plain decimal number like -12.50 and reports whether it is
+// negative (non-zero with a minus sign) and how many digits follow the decimal point.
+func parseDecimal(s string) (negative bool, decimals int, ok bool) {
+	minus := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		minus = s[0] == '-'
+		s = s[1:]
+	}
+	intPart, fracPart, hasPoint := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return false, 0, false
+	}
+	nonZero := false
+	for _, part := range []string{intPart, fracPart} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return false, 0, false
+			}
+			nonZero = nonZero || r != '0'
+		}
+	}
+	if hasPoint && fracPart == "" {
+		return false, 0, false
+	}
+	return minus && nonZero, len(fracPart), true
+}
+
+// stringerOf returns rv as fmt.Stringer if its type (or a pointer to it) implements the interface.
+func stringerOf(rv reflect.Value) (fmt.Stringer, bool) {
+	if !rv.CanInterface() {
+		return nil, false
+	}
+	if s, ok := rv.Interface().(fmt.Stringer); ok {
+		return s, true
+	}
+	if rv.CanAddr() {
+		if s, ok := rv.Addr().Interface().(fmt.Stringer); ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// stringValue returns the string behind val, dereferencing pointers and interfaces. Nil ones are treated as empty strings.
+func stringValue(tag string, val *Value) (string, error) {
+	rv := val.Deref().val
+	if !rv.IsValid() {
+		return "", nil
+	}
+	if rv.Kind() != reflect.String {
+		return "", configErrorf("%s is not applicable to type %s", tag, rv.Type())
+	}
+	return rv.String(), nil
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	var entropy float64
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
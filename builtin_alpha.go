@@ -0,0 +1,68 @@
+package lakery
+
+import (
+	"fmt"
+	"unicode"
+)
+
+const (
+	// string of ASCII letters only
+	alphaTag = "alpha"
+	// string of ASCII letters and digits only
+	alphaNumTag = "alphanum"
+	// string of Unicode letters only
+	alphaUnicodeTag = "alphaunicode"
+	// string of Unicode letters and digits only
+	alphaNumUnicodeTag = "alphanumunicode"
+	// string without upper case letters, see the lower sanitizer to convert it instead
+	lowercaseTag = "lowercase"
+	// string without lower case letters
+	uppercaseTag = "uppercase"
+)
+
+// builtinCharClass returns a validator accepting strings whose every rune satisfies ok, for fields like
+// usernames and slugs. what names the accepted characters in the message, e.g. "letters and digits".
+func builtinCharClass(tag, what string, ok func(r rune) bool) TagValidationFunc {
+	return func(val *Value) error {
+		s, err := stringValue(tag, val)
+		if err != nil || s == "" {
+			return err
+		}
+		for _, r := range s {
+			if !ok(r) {
+				return fmt.Errorf("should contain only %s", what)
+			}
+		}
+		return nil
+	}
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isASCIILetterOrDigit(r rune) bool {
+	return isASCIILetter(r) || isASCIIDigit(r)
+}
+
+// isLetterOrNumber accepts the Unicode letter and number categories; invalid UTF-8 decodes to
+// utf8.RuneError, which is neither.
+func isLetterOrNumber(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// builtinCase returns a validator accepting strings which convert leaves unchanged, so lowercase
+// accepts digits and punctuation (user_42) and checks letters of every script (ÄPFEL is not lower case).
+// Invalid UTF-8 is converted to U+FFFD and fails.
+func builtinCase(tag, what string, convert func(string) string) TagValidationFunc {
+	return func(val *Value) error {
+		s, err := stringValue(tag, val)
+		if err != nil || s == "" {
+			return err
+		}
+		if convert(s) != s {
+			return fmt.Errorf("should be %s", what)
+		}
+		return nil
+	}
+}
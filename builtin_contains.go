@@ -0,0 +1,36 @@
+package lakery
+
+import "fmt"
+
+const (
+	// string must contain the substring, e.g. contains=@
+	containsTag = "contains"
+	// string must not contain the substring, e.g. excludes=' '
+	excludesTag = "excludes"
+	// string must contain at least one of the characters, e.g. containsany=!@#$
+	containsAnyTag = "containsany"
+	// string must not contain any of the characters, e.g. excludesall=<>
+	excludesAllTag = "excludesall"
+	// string must start with the prefix, e.g. startswith=https://
+	startsWithTag = "startswith"
+	// string must end with the suffix, e.g. endswith=.json
+	endsWithTag = "endswith"
+)
+
+// builtinSubstring returns a validator checking a string against the param with match, like
+// strings.Contains, strings.ContainsAny or strings.HasPrefix. want is the expected result and what describes the
+// check in the message. Params with spaces, commas or pipes are quoted (excludes=' '), see
+// UnquoteParam.
+func builtinSubstring(tag, what string, want bool, match func(s, param string) bool) TagValidationFunc {
+	return func(val *Value) error {
+		param := val.Param()
+		s, err := stringValue(tag, val)
+		if err != nil || s == "" {
+			return err
+		}
+		if match(s, param) != want {
+			return fmt.Errorf("should %s %q", what, param)
+		}
+		return nil
+	}
+}
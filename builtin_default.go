@@ -0,0 +1,89 @@
+package lakery
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sets zero values to the param, e.g. default=10
+const defaultTag = "default"
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// builtinDefault sets a zero value to the param, parsed for the type of the field, so the rules
+// following it check the default: strings, booleans, numbers and time.Duration ("30s"); nil pointers
+// are set to a new value. Values with commas are wrapped into braces (default={a,b}).
+// Like sanitizers it needs the struct passed to Validate by pointer when the value is zero.
+func builtinDefault(val *Value) error {
+	param := strings.TrimSpace(val.Param())
+	if strings.HasPrefix(param, "{") && strings.HasSuffix(param, "}") {
+		param = param[1 : len(param)-1]
+	}
+	rv := val.val
+	// non-nil pointers keep their target, only the value they point to is set
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || !rv.IsZero() {
+		return nil
+	}
+	// parsed before checking settability, so malformed params are reported for any value
+	parsed, err := parseDefault(rv.Type(), param)
+	if err != nil {
+		return err
+	}
+	if !rv.CanSet() {
+		return configErrorf("default cannot modify the value: pass a pointer to the struct to Validate")
+	}
+	rv.Set(parsed)
+	return nil
+}
+
+// parseDefault parses the param of default into a value of typ, allocating pointers.
+func parseDefault(typ reflect.Type, param string) (reflect.Value, error) {
+	out := reflect.New(typ).Elem()
+	var err error
+	switch kind := typ.Kind(); {
+	case kind == reflect.Pointer:
+		var elem reflect.Value
+		if elem, err = parseDefault(typ.Elem(), param); err == nil {
+			out.Set(reflect.New(typ.Elem()))
+			out.Elem().Set(elem)
+		}
+	case typ == durationType:
+		var d time.Duration
+		if d, err = time.ParseDuration(param); err == nil {
+			out.SetInt(int64(d))
+		}
+	case kind == reflect.String:
+		out.SetString(param)
+	case kind == reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(param); err == nil {
+			out.SetBool(b)
+		}
+	case kind >= reflect.Int && kind <= reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(param, 10, typ.Bits()); err == nil {
+			out.SetInt(n)
+		}
+	case kind >= reflect.Uint && kind <= reflect.Uintptr:
+		var n uint64
+		if n, err = strconv.ParseUint(param, 10, typ.Bits()); err == nil {
+			out.SetUint(n)
+		}
+	case kind == reflect.Float32 || kind == reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(param, typ.Bits()); err == nil {
+			out.SetFloat(f)
+		}
+	default:
+		return out, configErrorf("default is not applicable to type %s", typ)
+	}
+	if err != nil {
+		return out, configErrorf("default expects a %s param: %w", typ, err)
+	}
+	return out, nil
+}
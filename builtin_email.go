@@ -0,0 +1,47 @@
+package lakery
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// string must be a plain email address like john@example.com
+const emailTag = "email"
+
+// builtinEmail validates that a string is a plain email address. On top of the RFC 5322 syntax
+// checked by mail.ParseAddress it rejects what APIs don't accept as an address field: display
+// names and angle brackets (John <john@example.com>), quoted local parts ("john doe"@example.com),
+// domains without a dot (john@localhost) or given as IP literals, and addresses longer than 254
+// bytes or with local parts longer than 64.
+func builtinEmail(val *Value) error {
+	s, err := stringValue(emailTag, val)
+	if err != nil || s == "" {
+		return err
+	}
+	if !isEmail(s) {
+		return fmt.Errorf("should be an email address")
+	}
+	return nil
+}
+
+func isEmail(s string) bool {
+	if len(s) > 254 {
+		return false
+	}
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Name != "" || addr.Address != s {
+		return false
+	}
+	at := strings.LastIndexByte(s, '@')
+	local, domain := s[:at], s[at+1:]
+	if len(local) > 64 || strings.HasPrefix(domain, "[") {
+		return false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+	}
+	return strings.Contains(domain, ".")
+}
@@ -0,0 +1,275 @@
+package lakery
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	// value must equal another field, e.g. eqfield=Password
+	eqFieldTag = "eqfield"
+	// value must differ from another field, e.g. nefield=OldPassword
+	neFieldTag = "nefield"
+	// value must be greater than another field, e.g. gtfield=Start
+	gtFieldTag = "gtfield"
+	// value must be greater than or equal to another field
+	gteFieldTag = "gtefield"
+	// value must be less than another field, e.g. ltfield=End
+	ltFieldTag = "ltfield"
+	// value must be less than or equal to another field
+	lteFieldTag = "ltefield"
+	// value is required when other fields hold given values, e.g. required_if=Kind card
+	requiredIfTag = "required_if"
+	// value is required unless other fields hold given values, e.g. required_unless=Kind cash
+	requiredUnlessTag = "required_unless"
+	// value is required when any of other fields is set, e.g. required_with=Street
+	requiredWithTag = "required_with"
+	// value is required when any of other fields is not set, e.g. required_without=Phone
+	requiredWithoutTag = "required_without"
+	// value is required when none of other fields is set, e.g. required_without_all=Email Phone
+	requiredWithoutAllTag = "required_without_all"
+	// value must be empty when any of other fields is set, e.g. excluded_with=Token
+	excludedWithTag = "excluded_with"
+	// value must be empty when other fields hold given values, e.g. excluded_if=Kind cash
+	excludedIfTag = "excluded_if"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// builtinEqField validates that the value equals the field named by the param.
+func builtinEqField(val *Value) error {
+	equal, err := equalField(val)
+	if err != nil {
+		return err
+	}
+	if !equal {
+		return fmt.Errorf("should be equal to %s", val.Param())
+	}
+	return nil
+}
+
+// builtinNeField validates that the value differs from the field named by the param.
+func builtinNeField(val *Value) error {
+	equal, err := equalField(val)
+	if err != nil {
+		return err
+	}
+	if equal {
+		return fmt.Errorf("should not be equal to %s", val.Param())
+	}
+	return nil
+}
+
+// builtinCompareField returns a validator comparing the value with the field named by the param.
+// ok reports whether the comparison result (-1, 0 or +1) satisfies the rule.
+// Nil pointers on either side are skipped, use required to reject them.
+func builtinCompareField(tag, relation string, ok func(cmp int) bool) TagValidationFunc {
+	return func(val *Value) error {
+		name := val.Param()
+		other, err := val.field(name)
+		if err != nil {
+			return err
+		}
+		a, aok := derefValue(val.val)
+		b, bok := derefValue(other)
+		if !aok || !bok {
+			return nil
+		}
+		if a.Type() != b.Type() {
+			return configErrorf("field %q has type %s, expected %s", name, b.Type(), a.Type())
+		}
+		cmp, err := compareValues(val, tag, a, b)
+		if err != nil {
+			return err
+		}
+		if !ok(cmp) {
+			return fmt.Errorf("should be %s %s", relation, name)
+		}
+		return nil
+	}
+}
+
+// builtinRequiredIf requires the value when every "Field value" pair of the param matches.
+func builtinRequiredIf(val *Value) error {
+	match, err := fieldsMatch(requiredIfTag, val)
+	if err != nil || !match {
+		return err
+	}
+	if builtinRequired(val) != nil {
+		return fmt.Errorf("is required when %s", describePairs(val.Params()))
+	}
+	return nil
+}
+
+// builtinRequiredUnless requires the value unless every "Field value" pair of the param matches.
+func builtinRequiredUnless(val *Value) error {
+	match, err := fieldsMatch(requiredUnlessTag, val)
+	if err != nil || match {
+		return err
+	}
+	if builtinRequired(val) != nil {
+		return fmt.Errorf("is required unless %s", describePairs(val.Params()))
+	}
+	return nil
+}
+
+// builtinRequiredWith requires the value when any field named by the param is set.
+func builtinRequiredWith(val *Value) error {
+	set, _, err := fieldsSet(requiredWithTag, val)
+	if err != nil || len(set) == 0 {
+		return err
+	}
+	if builtinRequired(val) != nil {
+		return fmt.Errorf("is required when %s is set", set[0])
+	}
+	return nil
+}
+
+// builtinRequiredWithout requires the value when any field named by the param is not set,
+// e.g. Email `lakery:"required_without=Phone"` and Phone `lakery:"required_without=Email"`
+// require at least one of them.
+func builtinRequiredWithout(val *Value) error {
+	_, unset, err := fieldsSet(requiredWithoutTag, val)
+	if err != nil || len(unset) == 0 {
+		return err
+	}
+	if builtinRequired(val) != nil {
+		return fmt.Errorf("is required when %s is not set", unset[0])
+	}
+	return nil
+}
+
+// builtinRequiredWithoutAll requires the value when none of the fields named by the param is set.
+func builtinRequiredWithoutAll(val *Value) error {
+	set, unset, err := fieldsSet(requiredWithoutAllTag, val)
+	if err != nil || len(set) > 0 {
+		return err
+	}
+	if builtinRequired(val) != nil {
+		return fmt.Errorf("is required when none of %s is set", strings.Join(unset, ", "))
+	}
+	return nil
+}
+
+// builtinExcludedWith requires the value to be empty when any field named by the param is set,
+// e.g. Password `lakery:"excluded_with=Token"` rejects requests with both.
+func builtinExcludedWith(val *Value) error {
+	set, _, err := fieldsSet(excludedWithTag, val)
+	if err != nil || len(set) == 0 {
+		return err
+	}
+	if builtinRequired(val) == nil {
+		return fmt.Errorf("should not be set when %s is set", set[0])
+	}
+	return nil
+}
+
+// builtinExcludedIf requires the value to be empty when every "Field value" pair of the param matches.
+func builtinExcludedIf(val *Value) error {
+	match, err := fieldsMatch(excludedIfTag, val)
+	if err != nil || !match {
+		return err
+	}
+	if builtinRequired(val) == nil {
+		return fmt.Errorf("should not be set when %s", describePairs(val.Params()))
+	}
+	return nil
+}
+
+// fieldsSet splits the sibling fields named by the param into the ones which are set and the
+// ones which are not, like required decides: nil pointers and zero values are not set.
+func fieldsSet(tag string, val *Value) (set, unset []string, err error) {
+	names := val.Params()
+	if len(names) == 0 {
+		return nil, nil, configErrorf("%s expects field names", tag)
+	}
+	for _, name := range names {
+		f, err := val.field(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if f, ok := derefValue(f); ok && !isZero(f) {
+			set = append(set, name)
+		} else {
+			unset = append(unset, name)
+		}
+	}
+	return set, unset, nil
+}
+
+// fieldsMatch reports whether every "Field value" pair of the param matches the sibling fields.
+// Field values are compared by their string form; a nil pointer matches no value.
+func fieldsMatch(tag string, val *Value) (bool, error) {
+	parts := val.Params()
+	if len(parts) == 0 || len(parts)%2 != 0 {
+		return false, configErrorf("%s expects \"Field value\" pairs", tag)
+	}
+	match := true
+	for i := 0; i < len(parts); i += 2 {
+		f, err := val.field(parts[i])
+		if err != nil {
+			return false, err
+		}
+		f, ok := derefValue(f)
+		if !ok || fmt.Sprint(f) != parts[i+1] {
+			match = false
+		}
+	}
+	return match, nil
+}
+
+// describePairs renders "A x B y" as "A is x and B is y" for error messages.
+func describePairs(parts []string) string {
+	conds := make([]string, 0, len(parts)/2)
+	for i := 0; i+1 < len(parts); i += 2 {
+		conds = append(conds, parts[i]+" is "+parts[i+1])
+	}
+	return strings.Join(conds, " and ")
+}
+
+// equalField compares the value with the field named by the param.
+// A nil pointer equals only another nil pointer.
+func equalField(val *Value) (bool, error) {
+	name := val.Param()
+	other, err := val.field(name)
+	if err != nil {
+		return false, err
+	}
+	a, aok := derefValue(val.val)
+	b, bok := derefValue(other)
+	if !aok || !bok {
+		return aok == bok, nil
+	}
+	if a.Type() != b.Type() {
+		return false, configErrorf("field %q has type %s, expected %s", name, b.Type(), a.Type())
+	}
+	if a.Type() == timeType {
+		return a.Interface().(time.Time).Equal(b.Interface().(time.Time)), nil
+	}
+	if !a.Comparable() {
+		return reflect.DeepEqual(a.Interface(), b.Interface()), nil
+	}
+	return a.Equal(b), nil
+}
+
+// compareValues orders two values of the same type: numbers, strings and time.Time are supported.
+// Numbers are compared exactly, so large integers that share a float64 approximation still differ.
+func compareValues(val *Value, tag string, a, b reflect.Value) (int, error) {
+	if a.Type() == timeType {
+		return a.Interface().(time.Time).Compare(b.Interface().(time.Time)), nil
+	}
+	if a.Kind() == reflect.String {
+		return strings.Compare(a.String(), b.String()), nil
+	}
+	x, ok := val.exactNumber(a)
+	if !ok {
+		return 0, configErrorf("%s is not applicable to type %s", tag, a.Type())
+	}
+	y, _ := val.exactNumber(b)
+	if x == nil || y == nil {
+		return 0, fmt.Errorf("should be a finite number")
+	}
+	return x.Cmp(y), nil
+}
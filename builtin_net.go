@@ -0,0 +1,164 @@
+package lakery
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"reflect"
+)
+
+const (
+	// number within an inclusive lo:hi range, e.g. inrange=1024:65535 for ports
+	inRangeTag = "inrange"
+	// IP address contained in one of the space-separated networks, e.g. incidr=10.0.0.0/8
+	inCIDRTag = "incidr"
+	// IP address contained in the network stored in another field, e.g. incidrfield=AllowedCIDR
+	inCIDRFieldTag = "incidrfield"
+	// IPv4 or IPv6 address
+	ipTag = "ip"
+	// IPv4 address in dotted decimal form
+	ipv4Tag = "ipv4"
+	// IPv6 address, IPv4-mapped ones like ::ffff:10.0.0.1 included
+	ipv6Tag = "ipv6"
+	// network in CIDR notation, e.g. 10.0.0.0/8 or 2001:db8::/32
+	cidrTag = "cidr"
+	// hardware address like 00:1a:2b:3c:4d:5e
+	macTag = "mac"
+)
+
+// builtinInRange validates that a number lies within the inclusive range given as lo:hi. Like between
+// the value is compared like compareNumber, but inrange applies to numbers only.
+func builtinInRange(val *Value) error {
+	loStr, hiStr, lo, hi, err := rangeParam(inRangeTag, val)
+	if err != nil {
+		return err
+	}
+	rv := val.Deref().val
+	if !rv.IsValid() {
+		return nil
+	}
+	in, err := val.numberInRange(inRangeTag, rv, lo, hi)
+	if err != nil {
+		return err
+	}
+	if !in {
+		return fmt.Errorf("should be in range %s:%s", loStr, hiStr)
+	}
+	return nil
+}
+
+// builtinInCIDR validates that an IP address string belongs to at least one of the
+// space-separated networks in the param.
+func builtinInCIDR(val *Value) error {
+	prefixes, err := parsePrefixes(inCIDRTag, val.Params())
+	if err != nil {
+		return err
+	}
+	return checkInPrefixes(inCIDRTag, val, prefixes)
+}
+
+// builtinInCIDRField validates that an IP address string belongs to the network held by
+// the sibling field named in the param. The field may be a string or a netip.Prefix.
+func builtinInCIDRField(val *Value) error {
+	name := val.Param()
+	f, err := val.field(name)
+	if err != nil {
+		return err
+	}
+	f, ok := derefValue(f)
+	if !ok {
+		return fmt.Errorf("network field %q is not set", name)
+	}
+	var cidr string
+	if f.Kind() == reflect.String {
+		cidr = f.String()
+	} else if stringer, ok := stringerOf(f); ok {
+		cidr = stringer.String()
+	} else {
+		return configErrorf("network field %q has unsupported type %s", name, f.Type())
+	}
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return fmt.Errorf("network field %q holds invalid CIDR %q", name, cidr)
+	}
+	return checkInPrefixes(inCIDRFieldTag, val, []netip.Prefix{prefix})
+}
+
+func parsePrefixes(tag string, cidrs []string) ([]netip.Prefix, error) {
+	if len(cidrs) == 0 {
+		return nil, configErrorf("%s expects at least one CIDR param", tag)
+	}
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, configErrorf("%s expects CIDR param: %w", tag, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+func checkInPrefixes(tag string, val *Value, prefixes []netip.Prefix) error {
+	s, err := stringValue(tag, val)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return fmt.Errorf("should be a valid IP address")
+	}
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr.Unmap()) {
+			return nil
+		}
+	}
+	if len(prefixes) == 1 {
+		return fmt.Errorf("should be in network %s", prefixes[0])
+	}
+	return fmt.Errorf("should be in one of networks %v", prefixes)
+}
+
+// builtinIP returns a validator accepting address strings parsed by netip.ParseAddr for which
+// family reports true; what names the family in the message. Addresses with an IPv6 zone
+// (fe80::1%eth0) are rejected, they are only meaningful on one host.
+func builtinIP(tag, what string, family func(netip.Addr) bool) TagValidationFunc {
+	return func(val *Value) error {
+		s, err := stringValue(tag, val)
+		if err != nil || s == "" {
+			return err
+		}
+		addr, err := netip.ParseAddr(s)
+		if err != nil || addr.Zone() != "" || !family(addr) {
+			return fmt.Errorf("should be %s", what)
+		}
+		return nil
+	}
+}
+
+// builtinCIDR validates that a string is a network in CIDR notation as parsed by netip.ParsePrefix.
+// Host bits may be set (192.168.1.10/24 names an interface address), use incidr to restrict networks.
+func builtinCIDR(val *Value) error {
+	s, err := stringValue(cidrTag, val)
+	if err != nil || s == "" {
+		return err
+	}
+	if _, err := netip.ParsePrefix(s); err != nil {
+		return fmt.Errorf("should be a network in CIDR notation")
+	}
+	return nil
+}
+
+// builtinMAC validates that a string is a hardware address in one of the forms net.ParseMAC accepts:
+// EUI-48, EUI-64 or 20-octet IP over InfiniBand addresses, with colons (00:1a:2b:3c:4d:5e), hyphens
+// (00-1A-2B-3C-4D-5E) or dots between groups of four digits (001a.2b3c.4d5e).
+func builtinMAC(val *Value) error {
+	s, err := stringValue(macTag, val)
+	if err != nil || s == "" {
+		return err
+	}
+	if _, err := net.ParseMAC(s); err != nil {
+		return fmt.Errorf("should be a MAC address")
+	}
+	return nil
+}
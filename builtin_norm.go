@@ -0,0 +1,75 @@
+//go:build !lakery_tiny
+
+package lakery
+
+import (
+	"fmt"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// string must be in Unicode Normalization Form C
+	nfcTag = "nfc"
+	// string must be in Unicode Normalization Form KC
+	nfkcTag = "nfkc"
+	// sanitizer: normalizes string to NFC in place
+	toNFCTag = "tonfc"
+	// sanitizer: normalizes string to NFKC in place
+	toNFKCTag = "tonfkc"
+)
+
+// registerNormBuiltins registers the Unicode normalization tags backed by golang.org/x/text.
+// They are left out of the lakery_tiny build profile.
+func (v *Validator) registerNormBuiltins() {
+	v.RegisterTag(nfcTag, builtinNormalForm(nfcTag, norm.NFC))
+	v.RegisterTag(nfkcTag, builtinNormalForm(nfkcTag, norm.NFKC))
+	v.RegisterTag(toNFCTag, builtinToNormalForm(toNFCTag, norm.NFC))
+	v.RegisterTag(toNFKCTag, builtinToNormalForm(toNFKCTag, norm.NFKC))
+}
+
+// normSpecs describes the normalization tags for BuiltinSpecs.
+var normSpecs = []BuiltinSpec{
+	{Name: nfcTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "already in Unicode normal form C"},
+	}},
+	{Name: nfkcTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "already in Unicode normal form KC"},
+	}},
+	{Name: toNFCTag, Sanitizer: true, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "normalizes to Unicode normal form C"},
+	}},
+	{Name: toNFKCTag, Sanitizer: true, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "normalizes to Unicode normal form KC"},
+	}},
+}
+
+// builtinNormalForm returns a validator asserting that a string is already in the given normal form.
+// Comparing normalized usernames prevents lookalike and duplicate accounts ("é" vs "é").
+func builtinNormalForm(tag string, form norm.Form) TagValidationFunc {
+	return func(val *Value) error {
+		s, err := stringValue(tag, val)
+		if err != nil {
+			return err
+		}
+		if !form.IsNormalString(s) {
+			return fmt.Errorf("should be in Unicode normal form %s", tag)
+		}
+		return nil
+	}
+}
+
+// builtinToNormalForm returns a sanitizer normalizing a string to the given form in place.
+// The struct has to be passed to Validate by pointer, otherwise the field cannot be modified.
+func builtinToNormalForm(tag string, form norm.Form) TagValidationFunc {
+	return func(val *Value) error {
+		s, err := stringValue(tag, val)
+		if err != nil {
+			return err
+		}
+		if form.IsNormalString(s) {
+			return nil
+		}
+		return val.setString(tag, form.String(s))
+	}
+}
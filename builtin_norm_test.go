@@ -0,0 +1,68 @@
+//go:build !lakery_tiny
+
+package lakery_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/trofkm/lakery"
+)
+
+var _ = Describe("Normalization builtins", func() {
+	Context("nfc and nfkc", func() {
+		// "é" as a single code point and as "e" followed by a combining acute accent
+		const composed = "Jos\u00e9"
+		const decomposed = "Jose\u0301"
+
+		type S struct {
+			Username string `lakery:"nfc"`
+		}
+
+		It("passes for strings in normal form", func() {
+			v := lakery.NewValidator()
+			Expect(v.Validate(S{Username: composed})).To(Succeed())
+		})
+
+		It("fails for strings not in normal form", func() {
+			v := lakery.NewValidator()
+			Expect(v.Validate(S{Username: decomposed})).To(MatchError(ContainSubstring("normal form nfc")))
+		})
+
+		It("fails nfkc for compatibility characters", func() {
+			type T struct {
+				Username string `lakery:"nfkc"`
+			}
+			v := lakery.NewValidator()
+			Expect(v.Validate(T{Username: "\uff21dmin"})).To(HaveOccurred())
+		})
+
+		It("normalizes with tonfc when validating a pointer", func() {
+			type T struct {
+				Username string `lakery:"tonfc,nfc"`
+			}
+			v := lakery.NewValidator()
+			t := T{Username: decomposed}
+			Expect(v.Validate(&t)).To(Succeed())
+			Expect(t.Username).To(Equal(composed))
+		})
+
+		It("normalizes with tonfkc when validating a pointer", func() {
+			type T struct {
+				Username string `lakery:"tonfkc"`
+			}
+			v := lakery.NewValidator()
+			t := T{Username: "\uff21dmin"}
+			Expect(v.Validate(&t)).To(Succeed())
+			Expect(t.Username).To(Equal("Admin"))
+		})
+
+		It("fails to normalize when validating a value", func() {
+			type T struct {
+				Username string `lakery:"tonfc"`
+			}
+			v := lakery.NewValidator()
+			Expect(v.Validate(T{Username: decomposed})).To(MatchError(ContainSubstring("pass a pointer")))
+		})
+	})
+})
@@ -0,0 +1,10 @@
+//go:build lakery_tiny
+
+package lakery
+
+// registerNormBuiltins is a no-op in the lakery_tiny build profile, which drops the
+// golang.org/x/text dependency so the validator fits TinyGo and WASM targets.
+func (v *Validator) registerNormBuiltins() {}
+
+// normSpecs is empty as the normalization tags are not registered.
+var normSpecs []BuiltinSpec
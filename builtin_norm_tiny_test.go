@@ -0,0 +1,49 @@
+//go:build lakery_tiny
+
+package lakery_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/trofkm/lakery"
+)
+
+var _ = Describe("Tiny build profile", func() {
+	It("leaves out normalization builtins", func() {
+		v := lakery.NewValidator()
+		Expect(v.ListValidators()).NotTo(ContainElements("nfc", "nfkc", "tonfc", "tonfkc"))
+		Expect(v.ListValidators()).To(ContainElements("min", "max", "required"))
+		for _, spec := range lakery.BuiltinSpecs() {
+			Expect(spec.Name).NotTo(BeElementOf("nfc", "nfkc", "tonfc", "tonfkc"))
+		}
+	})
+
+	It("leaves out protobuf message lookups", func() {
+		rules := lakery.ProtoRules{"acme.v1.Message": {"Name": "required"}}
+		v := lakery.NewValidator(lakery.WithProtobuf(rules))
+		Expect(v.Validate(tinyMessage{})).To(Succeed())
+	})
+
+	It("traces validators without their names", func() {
+		var buf bytes.Buffer
+		v := lakery.NewValidator(lakery.WithTrace(&buf))
+		Expect(v.Var("ab", "min=3")).To(HaveOccurred())
+		Expect(buf.String()).To(ContainSubstring("value: min=3 (unknown) -> fail"))
+	})
+})
+
+// tinyMessage mimics a generated message, whose sidecar rules the tiny profile cannot look up.
+type tinyMessage struct {
+	Name string
+}
+
+func (tinyMessage) ProtoReflect() tinyDescriptor { return tinyDescriptor{} }
+
+type tinyDescriptor struct{}
+
+func (tinyDescriptor) Descriptor() tinyDescriptor { return tinyDescriptor{} }
+
+func (tinyDescriptor) FullName() string { return "acme.v1.Message" }
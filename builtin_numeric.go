@@ -0,0 +1,50 @@
+package lakery
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// string must be a decimal number like -12.5
+	numericTag = "numeric"
+	// string of ASCII digits only
+	numberTag = "number"
+)
+
+// builtinNumeric validates that a string is a plain decimal number: an optional sign, digits and an
+// optional fraction (42, -12.5, +0.25). Exponents, hex, underscores, Inf and NaN accepted by
+// strconv.ParseFloat are rejected, the value is meant to be stored or parsed by other systems.
+func builtinNumeric(val *Value) error {
+	s, err := stringValue(numericTag, val)
+	if err != nil || s == "" {
+		return err
+	}
+	if !isDecimal(s) {
+		return fmt.Errorf("should be a number")
+	}
+	return nil
+}
+
+// isDecimal reports whether s matches [-+]?[0-9]+(\.[0-9]+)?.
+func isDecimal(s string) bool {
+	if s[0] == '-' || s[0] == '+' {
+		s = s[1:]
+	}
+	intPart, frac, dot := strings.Cut(s, ".")
+	return isDigits(intPart) && (!dot || isDigits(frac))
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if !isASCIIDigit(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isASCIIDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
@@ -0,0 +1,45 @@
+package lakery
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// string must match the pattern, e.g. regexp='^[a-z]{2,8}$'
+const regexpTag = "regexp"
+
+// builtinRegexp validates that a string matches the pattern of the param, in the RE2 syntax of
+// package regexp. The pattern is not anchored, use ^ and $ to match the whole string; patterns
+// with commas, pipes or braces are quoted, see UnquoteParam.
+func builtinRegexp(val *Value) error {
+	pattern := val.Param()
+	re, err := val.validator.regexp(pattern)
+	if err != nil {
+		return configErrorf("regexp has an invalid pattern: %w", err)
+	}
+	s, err := stringValue(regexpTag, val)
+	if err != nil || s == "" {
+		return err
+	}
+	if !re.MatchString(s) {
+		return fmt.Errorf("should match %s", pattern)
+	}
+	return nil
+}
+
+// regexp returns the compiled pattern, compiling it on first use. Patterns are cached per validator
+// for its lifetime, they come from rules and are few.
+func (v *Validator) regexp(pattern string) (*regexp.Regexp, error) {
+	if v == nil {
+		return regexp.Compile(pattern)
+	}
+	if re, ok := v.regexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	v.regexps.Store(pattern, re)
+	return re, nil
+}
@@ -0,0 +1,108 @@
+package lakery
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+const (
+	// file path without traversal segments or NUL bytes; safepath=relative and safepath=absolute restrict the form
+	safePathTag = "safepath"
+	// valid unquoted SQL identifier (table, column names)
+	sqlIdentTag = "sqlident"
+	// valid Go identifier
+	goIdentTag = "goident"
+)
+
+// sqlReservedWords are keywords reserved by the SQL standard and major databases which
+// cannot be used as unquoted identifiers.
+var sqlReservedWords = map[string]struct{}{
+	"all": {}, "alter": {}, "and": {}, "any": {}, "as": {}, "asc": {}, "between": {}, "by": {},
+	"case": {}, "cast": {}, "check": {}, "column": {}, "constraint": {}, "create": {}, "cross": {},
+	"default": {}, "delete": {}, "desc": {}, "distinct": {}, "drop": {}, "else": {}, "end": {},
+	"except": {}, "exists": {}, "false": {}, "fetch": {}, "for": {}, "foreign": {}, "from": {},
+	"full": {}, "grant": {}, "group": {}, "having": {}, "in": {}, "inner": {}, "insert": {},
+	"intersect": {}, "into": {}, "is": {}, "join": {}, "left": {}, "like": {}, "limit": {},
+	"not": {}, "null": {}, "offset": {}, "on": {}, "or": {}, "order": {}, "outer": {},
+	"primary": {}, "references": {}, "revoke": {}, "right": {}, "select": {}, "set": {},
+	"table": {}, "then": {}, "to": {}, "true": {}, "union": {}, "unique": {}, "update": {},
+	"user": {}, "using": {}, "values": {}, "when": {}, "where": {}, "with": {},
+}
+
+// builtinSafePath validates file paths received from clients:
+// - no NUL bytes
+// - no ".." segments (both / and \ are treated as separators)
+// - optional param "relative" or "absolute" restricts the path form
+func builtinSafePath(val *Value) error {
+	mode := val.param
+	if mode != "" && mode != "relative" && mode != "absolute" {
+		return configErrorf("safepath expects relative or absolute param, got %q", mode)
+	}
+	s, err := stringValue(safePathTag, val)
+	if err != nil || s == "" {
+		return err
+	}
+	if strings.ContainsRune(s, 0) {
+		return fmt.Errorf("should not contain NUL bytes")
+	}
+	for _, segment := range strings.FieldsFunc(s, isPathSeparator) {
+		if segment == ".." {
+			return fmt.Errorf("should not contain '..' segments")
+		}
+	}
+	abs := isAbsPath(s)
+	if mode == "relative" && abs {
+		return fmt.Errorf("should be a relative path")
+	}
+	if mode == "absolute" && !abs {
+		return fmt.Errorf("should be an absolute path")
+	}
+	return nil
+}
+
+// builtinSQLIdent validates that a string can be used as an unquoted SQL identifier:
+// an ASCII letter or underscore followed by letters, digits or underscores, and not a reserved word.
+func builtinSQLIdent(val *Value) error {
+	s, err := stringValue(sqlIdentTag, val)
+	if err != nil || s == "" {
+		return err
+	}
+	for i, r := range s {
+		letter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !letter && (i == 0 || r < '0' || r > '9') {
+			return fmt.Errorf("should be a valid SQL identifier")
+		}
+	}
+	if _, reserved := sqlReservedWords[strings.ToLower(s)]; reserved {
+		return fmt.Errorf("should not be a reserved SQL keyword")
+	}
+	return nil
+}
+
+// builtinGoIdent validates that a string is a valid Go identifier which is not a keyword.
+func builtinGoIdent(val *Value) error {
+	s, err := stringValue(goIdentTag, val)
+	if err != nil || s == "" {
+		return err
+	}
+	if !token.IsIdentifier(s) {
+		return fmt.Errorf("should be a valid Go identifier")
+	}
+	return nil
+}
+
+func isPathSeparator(r rune) bool {
+	return r == '/' || r == '\\'
+}
+
+// isAbsPath reports whether s is absolute on any common platform: /etc, \\server\share or C:\dir.
+func isAbsPath(s string) bool {
+	if isPathSeparator(rune(s[0])) {
+		return true
+	}
+	if len(s) >= 2 && s[1] == ':' && (s[0]|0x20) >= 'a' && (s[0]|0x20) <= 'z' {
+		return true
+	}
+	return false
+}
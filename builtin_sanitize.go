@@ -0,0 +1,59 @@
+package lakery
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// sanitizer: removes leading and trailing white space in place
+	trimTag = "trim"
+	// sanitizer: converts string to lower case in place
+	lowerTag = "lower"
+	// sanitizer: shortens string to at most N bytes in place
+	truncateTag = "truncate"
+)
+
+// builtinTrim removes leading and trailing white space, as defined by Unicode, in place.
+// Like all sanitizers it needs the struct passed to Validate by pointer when the value changes.
+func builtinTrim(val *Value) error {
+	s, err := stringValue(trimTag, val)
+	if err != nil {
+		return err
+	}
+	if trimmed := strings.TrimSpace(s); trimmed != s {
+		return val.setString(trimTag, trimmed)
+	}
+	return nil
+}
+
+// builtinLower converts a string to lower case in place, e.g. to compare emails or usernames.
+func builtinLower(val *Value) error {
+	s, err := stringValue(lowerTag, val)
+	if err != nil {
+		return err
+	}
+	if lower := strings.ToLower(s); lower != s {
+		return val.setString(lowerTag, lower)
+	}
+	return nil
+}
+
+// builtinTruncate shortens a string to at most N bytes in place, the length checked by max=N.
+// It cuts at a rune boundary, so multi-byte characters are never split and the result may be
+// a few bytes shorter than N.
+func builtinTruncate(val *Value) error {
+	n, err := strconv.Atoi(val.Param())
+	if err != nil || n < 0 {
+		return configErrorf("truncate expects a non-negative integer param")
+	}
+	s, err := stringValue(truncateTag, val)
+	if err != nil || len(s) <= n {
+		return err
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return val.setString(truncateTag, s[:n])
+}
@@ -0,0 +1,67 @@
+package lakery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// string must be a UUID in canonical form, e.g. uuid=4
+const uuidTag = "uuid"
+
+// builtinUUID validates that a string is a UUID in the canonical 8-4-4-4-12 hex form,
+// lower case as RFC 9562 prescribes for output. The optional param lists options:
+// - a version from 1 to 8 (uuid=4): the version digit must match and the variant be RFC 9562
+// - anycase (uuid=anycase, uuid=4 anycase): upper case hex digits are accepted too
+func builtinUUID(val *Value) error {
+	version, anyCase := 0, false
+	if val.param != "" {
+		for _, opt := range val.Params() {
+			n, err := strconv.Atoi(opt)
+			switch {
+			case opt == "anycase":
+				anyCase = true
+			case err == nil && n >= 1 && n <= 8:
+				version = n
+			default:
+				return configErrorf("uuid expects a version from 1 to 8 or anycase param, got %q", opt)
+			}
+		}
+	}
+	s, err := stringValue(uuidTag, val)
+	if err != nil || s == "" {
+		return err
+	}
+	if anyCase {
+		s = strings.ToLower(s)
+	}
+	if !isUUID(s) {
+		return fmt.Errorf("should be a UUID")
+	}
+	// the version is the first digit of the third group, the variant the first of the fourth
+	if version != 0 && (int(s[14]-'0') != version || !strings.ContainsRune("89ab", rune(s[19]))) {
+		return fmt.Errorf("should be a version %d UUID", version)
+	}
+	return nil
+}
+
+// isUUID reports whether s has the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx with lower case hex digits.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package lakery
+
+import (
+	"reflect"
+)
+
+// compiledStruct holds the merged rules of every field of a struct type.
+// It is built once per type and reused by later Validate calls.
+type compiledStruct struct {
+	// generation of the validator the rules were compiled at
+	gen uint64
+	// merged rules and rule parsing errors, indexed by field
+	rules [][]rule
+	errs  []error
+	// fields excluded with lakery:"-"
+	skip []bool
+	// fields with a dive rule, whose nested struct is not traversed again
+	dive []bool
+	// validators listed by the allow struct rule, nil without one, and the error of parsing it
+	allow     []string
+	structErr error
+}
+
+// invalidate drops compiled rules so registrations made after the first Validate call
+// take effect. It is called by every method changing validators or rule sources.
+func (v *Validator) invalidate() {
+	v.gen.Add(1)
+}
+
+// compiled returns the compiled rules of a struct type, compiling them on first use
+// or when the validator changed since they were compiled.
+func (v *Validator) compiled(typ reflect.Type) *compiledStruct {
+	gen := v.gen.Load()
+	if c, ok := v.cache.Load(typ); ok && c.(*compiledStruct).gen == gen {
+		return c.(*compiledStruct)
+	}
+	c := &compiledStruct{
+		gen:   gen,
+		rules: make([][]rule, typ.NumField()),
+		errs:  make([]error, typ.NumField()),
+		skip:  make([]bool, typ.NumField()),
+		dive:  make([]bool, typ.NumField()),
+	}
+	c.allow, c.structErr = v.parseStructRules(typ)
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if c.skip[i] = v.skipped(sf); c.skip[i] {
+			continue
+		}
+		c.rules[i], c.errs[i] = v.fieldRules(typ, sf)
+		c.rules[i] = v.compileRules(c.rules[i])
+		c.dive[i] = hasRule(c.rules[i], diveTag)
+	}
+	v.cache.Store(typ, c)
+	return c
+}
+
+// compileRules resolves the validators of rules and parses the rules nested in collection rules,
+// recursively, so validating a value neither parses tags nor looks up validators. The result is
+// only valid for the current generation of the validator.
+func (v *Validator) compileRules(rules []rule) []rule {
+	for i := range rules {
+		r := &rules[i]
+		r.compiled = true
+		r.fn = v.validator(r.key)
+		for j := range r.alts {
+			r.alts[j].compiled = true
+			r.alts[j].fn = v.validator(r.alts[j].key)
+		}
+		switch r.key {
+		case eachTag, keysTag, valuesTag:
+			r.inner, r.innerErr = v.innerRules(*r)
+			if r.innerErr == nil {
+				r.inner = v.compileRules(r.inner)
+			}
+		}
+	}
+	return rules
+}
@@ -0,0 +1,47 @@
+package lakery
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone returns a copy of the validator with its own registrations, so a request or tenant can add
+// or override a few tags without affecting the shared instance:
+//
+//	tenantV := shared.Clone()
+//	tenantV.RegisterTag("sku", tenantSKU)
+//
+// Tags, sets, aliases, type and struct rules, number and custom types, installed plugins, field
+// hooks and the options are copied. The clone is not frozen, even when v is, and starts with an
+// empty rule cache.
+func (v *Validator) Clone() *Validator {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return &Validator{
+		validators:         maps.Clone(v.validators),
+		tagName:            v.tagName,
+		sets:               maps.Clone(v.sets),
+		trace:              v.trace,
+		collectAll:         v.collectAll,
+		typeRules:          maps.Clone(v.typeRules),
+		numberTypes:        maps.Clone(v.numberTypes),
+		customTypes:        maps.Clone(v.customTypes),
+		aliases:            maps.Clone(v.aliases),
+		structRules:        maps.Clone(v.structRules),
+		precedence:         slices.Clone(v.precedence),
+		merge:              v.merge,
+		protobuf:           v.protobuf,
+		protoRules:         v.protoRules,
+		mutation:           v.mutation,
+		panicOnInvalidRule: v.panicOnInvalidRule,
+		strictTags:         v.strictTags,
+		maxDepth:           v.maxDepth,
+		parallelEach:       v.parallelEach,
+		unexported:         v.unexported,
+		profile:            v.profile,
+		tagParser:          v.tagParser,
+		plugins:            slices.Clone(v.plugins),
+		fieldStart:         slices.Clone(v.fieldStart),
+		fieldResult:        slices.Clone(v.fieldResult),
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/trofkm/lakery"
+)
+
+// genFile is a generated source file.
+type genFile struct {
+	name string
+	src  []byte
+}
+
+// generate parses the non-test Go files in dir and generates a file with a Validate method for each of types.
+func generate(dir string, types []string, tag string) ([]genFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	pkgName := ""
+	structs := make(map[string]*ast.StructType)
+	declared := make(map[string]bool)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		pkgName = file.Name.Name
+		ast.Inspect(file, func(n ast.Node) bool {
+			if spec, ok := n.(*ast.TypeSpec); ok {
+				declared[spec.Name.Name] = true
+				if st, ok := spec.Type.(*ast.StructType); ok {
+					structs[spec.Name.Name] = st
+				}
+			}
+			return true
+		})
+	}
+
+	var files []genFile
+	seen := make(map[string]bool)
+	for _, name := range types {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		st, ok := structs[name]
+		if !ok {
+			if declared[name] {
+				return nil, fmt.Errorf("%s is not a struct type", name)
+			}
+			return nil, fmt.Errorf("type %s not found in %s", name, dir)
+		}
+		src, err := genType(pkgName, name, st, tag)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, genFile{name: strings.ToLower(name) + "_lakery.go", src: src})
+	}
+	return files, nil
+}
+
+// fieldKind is the kind of a field type lakery-gen can generate checks for.
+type fieldKind int
+
+const (
+	kindUnsupported fieldKind = iota
+	kindString
+	kindBool
+	kindInt
+	kindUint
+	kindFloat
+	kindSlice
+	kindMap
+)
+
+var builtinKinds = map[string]fieldKind{
+	"string": kindString,
+	"bool":   kindBool,
+	"int":    kindInt, "int8": kindInt, "int16": kindInt, "int32": kindInt, "int64": kindInt, "rune": kindInt,
+	"uint": kindUint, "uint8": kindUint, "uint16": kindUint, "uint32": kindUint, "uint64": kindUint, "uintptr": kindUint, "byte": kindUint,
+	"float32": kindFloat, "float64": kindFloat,
+}
+
+// kindOf returns the kind of a field type and whether it is a pointer to a builtin type.
+func kindOf(expr ast.Expr) (kind fieldKind, pointer bool) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return builtinKinds[t.Name], false
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return kindSlice, false
+		}
+	case *ast.MapType:
+		return kindMap, false
+	case *ast.StarExpr:
+		if ident, ok := t.X.(*ast.Ident); ok && builtinKinds[ident.Name] != kindUnsupported {
+			return builtinKinds[ident.Name], true
+		}
+	}
+	return kindUnsupported, false
+}
+
+// zeroCheck returns a condition which is true when the field expression is the zero value.
+func zeroCheck(expr string, kind fieldKind, pointer bool) string {
+	if pointer {
+		return expr + " == nil || " + zeroCheck("*"+expr, kind, false)
+	}
+	switch kind {
+	case kindString:
+		return expr + ` == ""`
+	case kindBool:
+		return "!" + expr
+	case kindSlice, kindMap:
+		return expr + " == nil"
+	default:
+		return expr + " == 0"
+	}
+}
+
+// nonZeroCheck returns a condition which is true when the field expression is not the zero value.
+func nonZeroCheck(expr string, kind fieldKind, pointer bool) string {
+	switch {
+	case pointer, kind == kindSlice, kind == kindMap:
+		return expr + " != nil"
+	case kind == kindString:
+		return expr + ` != ""`
+	case kind == kindBool:
+		return expr
+	default:
+		return expr + " != 0"
+	}
+}
+
+// genType generates the source of the Validate method of a struct type.
+func genType(pkgName, typeName string, st *ast.StructType, tag string) ([]byte, error) {
+	var body bytes.Buffer
+	for _, field := range st.Fields.List {
+		rules, err := tagRules(field, tag)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", typeName, err)
+		}
+		if len(rules) == 1 && rules[0].Key == "-" {
+			// excluded from validation
+			continue
+		}
+		if len(field.Names) == 0 {
+			if len(rules) > 0 {
+				return nil, fmt.Errorf("%s: embedded fields are not supported by lakery-gen", typeName)
+			}
+			continue
+		}
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				// rules of unexported fields are not evaluated by Validate either
+				continue
+			}
+			if isInlineStruct(ident, field.Type) {
+				return nil, fmt.Errorf("%s.%s: inline struct fields are not supported by lakery-gen", typeName, ident.Name)
+			}
+			if err := genField(&body, ident.Name, field.Type, rules); err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", typeName, ident.Name, err)
+			}
+		}
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by lakery-gen; DO NOT EDIT.\n\npackage %s\n\n", pkgName)
+	if body.Len() > 0 {
+		src.WriteString("import (\n\"errors\"\n\"reflect\"\n\n\"github.com/trofkm/lakery\"\n)\n\n")
+	}
+	fmt.Fprintf(&src, "// Validate checks the %s rules of %s and returns the first failure as *lakery.FieldError.\n", tag, typeName)
+	fmt.Fprintf(&src, "func (x *%s) Validate() error {\n", typeName)
+	if body.Len() > 0 {
+		src.WriteString("fail := func(field string, value any, tag, param, msg string) error {\n")
+		src.WriteString("sf, _ := reflect.TypeOf(x).Elem().FieldByName(field)\n")
+		src.WriteString("return lakery.NewFieldError(sf, field, reflect.ValueOf(value).Elem(), tag, param, errors.New(msg))\n")
+		src.WriteString("}\n")
+		body.WriteTo(&src)
+	}
+	src.WriteString("return nil\n}\n")
+	return format.Source(src.Bytes())
+}
+
+// genField writes the checks of the rules of a single field.
+func genField(w *bytes.Buffer, name string, typ ast.Expr, rules []lakery.Rule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	kind, pointer := kindOf(typ)
+	if kind == kindUnsupported {
+		return fmt.Errorf("field type %s is not supported by lakery-gen", typeString(typ))
+	}
+	expr := "x." + name
+	fail := func(tag, param, msg string) string {
+		return fmt.Sprintf("return fail(%q, &%s, %q, %q, %q)\n", name, expr, tag, param, msg)
+	}
+	open := 0
+	for i, r := range rules {
+		if r.Alternatives != nil {
+			return fmt.Errorf("rule %s is not supported by lakery-gen", r)
+		}
+		switch r.Key {
+		case "required":
+			fmt.Fprintf(w, "if %s {\n%s}\n", zeroCheck(expr, kind, pointer), fail(r.Key, "", "is required"))
+		case "omitempty":
+			if i == len(rules)-1 {
+				continue
+			}
+			// the remaining rules only run for non-zero values
+			fmt.Fprintf(w, "if %s {\n", nonZeroCheck(expr, kind, pointer))
+			open++
+		case "min", "max":
+			n, err := strconv.Atoi(r.Param)
+			if err != nil || pointer || kind == kindBool || (kind == kindUint && n < 0) {
+				return fmt.Errorf("rule %s is not supported by lakery-gen for %s", r, typeString(typ))
+			}
+			op, length, number := "<", "should have length at least %d", "should be >= %d"
+			if r.Key == "max" {
+				op, length, number = ">", "should have length at most %d", "should be <= %d"
+			}
+			switch kind {
+			case kindString, kindSlice, kindMap:
+				fmt.Fprintf(w, "if len(%s) %s %d {\n%s}\n", expr, op, n, fail(r.Key, r.Param, fmt.Sprintf(length, n)))
+			default:
+				conv := map[fieldKind]string{kindInt: "int64", kindUint: "uint64", kindFloat: "float64"}[kind]
+				fmt.Fprintf(w, "if %s(%s) %s %d {\n%s}\n", conv, expr, op, n, fail(r.Key, r.Param, fmt.Sprintf(number, n)))
+			}
+		default:
+			return fmt.Errorf("rule %s is not supported by lakery-gen", r)
+		}
+	}
+	w.WriteString(strings.Repeat("}\n", open))
+	return nil
+}
+
+// tagRules parses the rules stored under the tag key of a field.
+func tagRules(field *ast.Field, key string) ([]lakery.Rule, error) {
+	if field.Tag == nil {
+		return nil, nil
+	}
+	raw, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return nil, err
+	}
+	return lakery.ParseRules(lakery.TagRules(reflect.StructTag(raw), key))
+}
+
+// isInlineStruct reports whether an exported field is declared as an anonymous struct (or a pointer to one),
+// which Validate traverses.
+func isInlineStruct(name *ast.Ident, typ ast.Expr) bool {
+	if !name.IsExported() {
+		return false
+	}
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	_, ok := typ.(*ast.StructType)
+	return ok
+}
+
+// typeString prints a type expression for error messages.
+func typeString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), expr); err != nil {
+		return fmt.Sprintf("%T", expr)
+	}
+	return buf.String()
+}
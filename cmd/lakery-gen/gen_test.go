@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("lakery-gen", func() {
+	It("generates a file per listed type", func() {
+		files, err := generate("testdata/api", []string{"User", " Order", "User"}, defaultTag)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(2))
+		Expect(files[0].name).To(Equal("user_lakery.go"))
+		Expect(files[1].name).To(Equal("order_lakery.go"))
+		golden, err := os.ReadFile("testdata/order_lakery.go.golden")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(files[1].src)).To(Equal(string(golden)))
+	})
+
+	It("generates checks matching the builtins", func() {
+		files, err := generate("testdata/api", []string{"User"}, defaultTag)
+		Expect(err).NotTo(HaveOccurred())
+		src := string(files[0].src)
+		Expect(src).To(ContainSubstring(`if x.Email == nil || *x.Email == "" {`))
+		Expect(src).To(ContainSubstring("if x.Age != 0 {\n\t\tif uint64(x.Age) < 18 {"))
+		Expect(src).To(ContainSubstring(`return fail("Tags", &x.Tags, "max", "5", "should have length at most 5")`))
+		Expect(src).NotTo(ContainSubstring("x.Nick"))
+		Expect(src).NotTo(ContainSubstring("x.Internal"))
+		Expect(src).NotTo(ContainSubstring("x.Secret"))
+		Expect(src).NotTo(ContainSubstring("x.token"))
+	})
+
+	It("refuses types with unsupported rules", func() {
+		_, err := generate("testdata/api", []string{"Account"}, defaultTag)
+		Expect(err).To(MatchError("Account.Email: rule email is not supported by lakery-gen"))
+	})
+
+	It("refuses unknown and non-struct types", func() {
+		_, err := generate("testdata/api", []string{"Missing"}, defaultTag)
+		Expect(err).To(MatchError("type Missing not found in testdata/api"))
+		_, err = generate("testdata/api", []string{"Status"}, defaultTag)
+		Expect(err).To(MatchError("Status is not a struct type"))
+	})
+
+	It("writes the files next to the sources", func() {
+		dir := GinkgoT().TempDir()
+		src, err := os.ReadFile("testdata/api/user.go")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(dir, "user.go"), src, 0o644)).To(Succeed())
+		var out bytes.Buffer
+		Expect(run([]string{"-type=Order", dir}, &out)).To(Succeed())
+		path := filepath.Join(dir, "order_lakery.go")
+		Expect(out.String()).To(Equal(path + "\n"))
+		Expect(path).To(BeAnExistingFile())
+		Expect(run(nil, &out)).To(MatchError("-type is required"))
+	})
+})
@@ -0,0 +1,25 @@
+package api
+
+type User struct {
+	Name     string            `json:"name" lakery:"required,min=2,max=32"`
+	Age      uint8             `lakery:"omitempty,min=18,max=150"`
+	Score    float64           `lakery:"max=100"`
+	Nick     *string           `lakery:"omitempty"`
+	Email    *string           `lakery:"required"`
+	Tags     []string          `lakery:"required,max=5"`
+	Labels   map[string]string `lakery:"omitempty,min=1"`
+	Internal string
+	Secret   string `lakery:"-"`
+	token    string `lakery:"required,uuid"`
+}
+
+type Order struct {
+	ID    int64 `lakery:"required"`
+	Notes string
+}
+
+type Account struct {
+	Email string `lakery:"required,email"`
+}
+
+type Status int
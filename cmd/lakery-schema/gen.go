@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// generator turns named schemas into Go type declarations.
+type generator struct {
+	defs *schemas
+}
+
+// generate returns the source of a Go file declaring a type per named schema of defs.
+func generate(pkg, source string, defs *schemas) ([]byte, error) {
+	g := &generator{defs: defs}
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by lakery-schema from %s; DO NOT EDIT.\n\npackage %s\n", source, pkg)
+	for _, name := range defs.names {
+		s := defs.byName[name]
+		decl, err := g.typeDecl(name, s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		src.WriteString("\n")
+		writeComment(&src, s.Description)
+		src.WriteString(decl)
+	}
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid source: %w", err)
+	}
+	return out, nil
+}
+
+// typeDecl declares the type of a named schema: a struct for objects, the underlying type otherwise.
+func (g *generator) typeDecl(name string, s *schema) (string, error) {
+	if isObject(s) {
+		body, err := g.structBody(s)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("type %s struct {\n%s}\n", goName(name), body), nil
+	}
+	f, err := g.fieldOf(s)
+	if err != nil {
+		return "", err
+	}
+	// rules of named scalar types are copied to the fields using them
+	return fmt.Sprintf("type %s %s\n", goName(name), f.typ), nil
+}
+
+// structBody declares the fields of an object schema.
+func (g *generator) structBody(s *schema) (string, error) {
+	var body bytes.Buffer
+	if s.Properties == nil {
+		return "", nil
+	}
+	for _, prop := range s.Properties.names {
+		p := s.Properties.byName[prop]
+		f, err := g.fieldOf(p)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", prop, err)
+		}
+		f.presence(slices.Contains(s.Required, prop))
+		writeComment(&body, p.Description)
+		for _, note := range f.notes {
+			fmt.Fprintf(&body, "// lakery-schema: %s\n", note)
+		}
+		tag := fmt.Sprintf("json:%q", prop)
+		if !slices.Contains(s.Required, prop) {
+			tag = fmt.Sprintf("json:%q", prop+",omitempty")
+		}
+		if len(f.rules) > 0 {
+			tag += fmt.Sprintf(" lakery:%q", strings.Join(f.rules, ","))
+		}
+		fmt.Fprintf(&body, "%s %s `%s`\n", goName(prop), f.typ, tag)
+	}
+	return body.String(), nil
+}
+
+// field is the Go type and the rules of a property.
+type field struct {
+	typ   string
+	rules []string
+	// constraints which cannot be expressed with lakery rules
+	notes []string
+	// whether the zero value of the type means empty, so required applies
+	emptyable bool
+	nullable  bool
+	// whether the type is a struct (or pointer to one) validated with dive
+	object bool
+}
+
+// presence adds the rules (and pointer) expressing whether a property is required.
+// Required strings, arrays and maps must not be empty; numbers and booleans cannot tell
+// a missing value from zero, so they are not checked. Rules of optional properties only run
+// for non-empty values.
+func (f *field) presence(required bool) {
+	if f.nullable && !f.object && !strings.HasPrefix(f.typ, "*") {
+		f.typ = "*" + f.typ
+	}
+	switch {
+	case f.object:
+		if !required && !strings.HasPrefix(f.typ, "*") {
+			f.typ = "*" + f.typ
+		}
+	case required && !f.nullable && f.emptyable:
+		f.rules = append([]string{"required"}, f.rules...)
+	case (!required || f.nullable) && len(f.rules) > 0:
+		f.rules = append([]string{"omitempty"}, f.rules...)
+	}
+}
+
+// fieldOf returns the type and rules of a schema, not yet considering whether it is required.
+func (g *generator) fieldOf(s *schema) (*field, error) {
+	if s.Ref != "" {
+		name, err := refName(s.Ref)
+		if err != nil {
+			return nil, err
+		}
+		target, ok := g.defs.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("reference %q points to an unknown schema", s.Ref)
+		}
+		if isObject(target) {
+			return &field{typ: goName(name), rules: []string{"dive"}, object: true, nullable: s.Nullable}, nil
+		}
+		f, err := g.fieldOf(target)
+		if err != nil {
+			return nil, err
+		}
+		f.typ = goName(name)
+		f.nullable = f.nullable || s.Nullable
+		return f, nil
+	}
+
+	f := &field{nullable: s.Nullable || s.Type.nullable}
+	switch s.Type.name {
+	case "string":
+		f.typ, f.emptyable = "string", true
+		f.lengthRules(s.MinLength, s.MaxLength)
+		if s.Pattern != "" {
+			f.notes = append(f.notes, fmt.Sprintf("pattern %q is not checked", s.Pattern))
+		}
+		if s.Format != "" {
+			f.notes = append(f.notes, fmt.Sprintf("format %s is not checked", s.Format))
+		}
+	case "integer":
+		f.typ = "int64"
+		if s.Format == "int32" {
+			f.typ = "int32"
+		}
+		f.boundRules(s, true)
+	case "number":
+		f.typ = "float64"
+		if s.Format == "float" {
+			f.typ = "float32"
+		}
+		f.boundRules(s, false)
+	case "boolean":
+		f.typ = "bool"
+	case "array":
+		if s.Items == nil {
+			return nil, fmt.Errorf("array without items is not supported")
+		}
+		item, err := g.fieldOf(s.Items)
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		if item.nullable && !item.object {
+			item.typ = "*" + item.typ
+		}
+		f.typ, f.emptyable = "[]"+item.typ, true
+		f.lengthRules(s.MinItems, s.MaxItems)
+		if len(item.rules) > 0 {
+			f.rules = append(f.rules, "each={"+strings.Join(item.rules, ",")+"}")
+		}
+		f.notes = append(f.notes, item.notes...)
+	case "object", "":
+		if isObject(s) {
+			body, err := g.structBody(s)
+			if err != nil {
+				return nil, err
+			}
+			f.typ, f.rules, f.object = "struct {\n"+body+"}", []string{"dive"}, true
+			break
+		}
+		if s.Type.name == "" && len(s.AdditionalProperties) == 0 {
+			f.typ = "any"
+			break
+		}
+		value, err := g.additionalProperties(s.AdditionalProperties)
+		if err != nil {
+			return nil, err
+		}
+		f.typ, f.emptyable = "map[string]"+value.typ, true
+		if len(value.rules) > 0 {
+			f.rules = append(f.rules, "values={"+strings.Join(value.rules, ",")+"}")
+		}
+		f.notes = append(f.notes, value.notes...)
+	default:
+		return nil, fmt.Errorf("type %s is not supported", s.Type.name)
+	}
+	if len(s.Enum) > 0 {
+		f.notes = append(f.notes, "enum is not checked")
+	}
+	return f, nil
+}
+
+// additionalProperties returns the value type of a map schema; true or a missing schema allow any value.
+func (g *generator) additionalProperties(raw json.RawMessage) (*field, error) {
+	var allowed bool
+	if len(raw) == 0 || json.Unmarshal(raw, &allowed) == nil {
+		return &field{typ: "any"}, nil
+	}
+	var value schema
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("additionalProperties: %w", err)
+	}
+	f, err := g.fieldOf(&value)
+	if err != nil {
+		return nil, fmt.Errorf("additionalProperties: %w", err)
+	}
+	if f.nullable && !f.object {
+		f.typ = "*" + f.typ
+	}
+	return f, nil
+}
+
+// lengthRules adds min and max rules for lengths; a minimum of 0 is left out.
+func (f *field) lengthRules(lo, hi *int) {
+	if lo != nil && *lo > 0 {
+		f.rules = append(f.rules, "min="+strconv.Itoa(*lo))
+	}
+	if hi != nil {
+		f.rules = append(f.rules, "max="+strconv.Itoa(*hi))
+	}
+}
+
+// boundRules adds the rules for minimum and maximum. Integer bounds become min and max
+// (exclusive ones of integers are shifted by one); other bounds become inrange when both are inclusive.
+func (f *field) boundRules(s *schema, integer bool) {
+	lo, loExcl := bound(s.Minimum, s.ExclusiveMinimum)
+	hi, hiExcl := bound(s.Maximum, s.ExclusiveMaximum)
+	if lo == nil && hi == nil {
+		return
+	}
+	if integer {
+		if lo != nil && loExcl {
+			shifted := math.Floor(*lo) + 1
+			lo, loExcl = &shifted, false
+		}
+		if hi != nil && hiExcl {
+			shifted := math.Ceil(*hi) - 1
+			hi, hiExcl = &shifted, false
+		}
+	}
+	if isInt(lo) && isInt(hi) && !loExcl && !hiExcl {
+		if lo != nil {
+			f.rules = append(f.rules, "min="+strconv.FormatFloat(*lo, 'f', -1, 64))
+		}
+		if hi != nil {
+			f.rules = append(f.rules, "max="+strconv.FormatFloat(*hi, 'f', -1, 64))
+		}
+		return
+	}
+	if lo != nil && hi != nil && !loExcl && !hiExcl {
+		f.rules = append(f.rules, "inrange="+strconv.FormatFloat(*lo, 'f', -1, 64)+":"+strconv.FormatFloat(*hi, 'f', -1, 64))
+		return
+	}
+	f.notes = append(f.notes, "bounds are not checked, only integer or inclusive lower and upper bounds are supported")
+}
+
+// bound returns a bound and whether it is exclusive. The exclusive keyword is either a number
+// (JSON Schema) or a flag for the inclusive keyword (OpenAPI 3.0).
+func bound(inclusive *float64, exclusive json.RawMessage) (*float64, bool) {
+	var n float64
+	if len(exclusive) > 0 && json.Unmarshal(exclusive, &n) == nil {
+		return &n, true
+	}
+	var flag bool
+	if json.Unmarshal(exclusive, &flag) == nil && flag && inclusive != nil {
+		return inclusive, true
+	}
+	return inclusive, false
+}
+
+// isInt reports whether a bound is missing or an integer usable as min or max param.
+func isInt(n *float64) bool {
+	return n == nil || *n == math.Trunc(*n) && math.Abs(*n) <= math.MaxInt32
+}
+
+// isObject reports whether a schema declares an object with properties.
+func isObject(s *schema) bool {
+	return s.Properties != nil && (s.Type.name == "object" || s.Type.name == "")
+}
+
+// writeComment writes a description as Go comment lines.
+func writeComment(w *bytes.Buffer, text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Fprintf(w, "// %s\n", line)
+		}
+	}
+}
+
+// initialisms are written in upper case in Go names, e.g. pet_id becomes PetID.
+var initialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "https": true, "id": true, "ip": true, "json": true,
+	"sql": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// goName turns a schema or property name like pet_id, petId or pet-id into an exported Go name.
+func goName(name string) string {
+	var b strings.Builder
+	for _, word := range splitWords(name) {
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		r := []rune(word)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	out := b.String()
+	if out == "" || unicode.IsDigit([]rune(out)[0]) {
+		out = "X" + out
+	}
+	return out
+}
+
+// splitWords splits a name at non-alphanumeric characters and at lower to upper case changes.
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	for _, r := range name {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(word) > 0 && !unicode.IsUpper(word[len(word)-1]):
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("lakery-schema", func() {
+	generateFile := func(path string) (string, error) {
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		defs, err := definitions(data)
+		if err != nil {
+			return "", err
+		}
+		src, err := generate("api", filepath.Base(path), defs)
+		return string(src), err
+	}
+
+	It("generates structs from OpenAPI component schemas", func() {
+		src, err := generateFile("testdata/petstore.json")
+		Expect(err).NotTo(HaveOccurred())
+		golden, err := os.ReadFile("testdata/petstore.go.golden")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(src).To(Equal(string(golden)))
+	})
+
+	It("generates structs from JSON Schema definitions and a titled root", func() {
+		src, err := generateFile("testdata/user.schema.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(src).To(ContainSubstring("type Address struct {"))
+		Expect(src).To(ContainSubstring("UserID      string    `json:\"user_id\" lakery:\"required\"`"))
+		Expect(src).To(ContainSubstring("DisplayName *string   `json:\"display_name,omitempty\" lakery:\"omitempty,min=3\"`"))
+		Expect(src).To(ContainSubstring("`json:\"score,omitempty\" lakery:\"omitempty,min=1,max=99\"`"))
+		Expect(src).To(ContainSubstring("`json:\"addresses\" lakery:\"required,each={dive}\"`"))
+		Expect(src).To(ContainSubstring("// lakery-schema: format uuid is not checked"))
+	})
+
+	It("refuses unknown references and untitled roots", func() {
+		_, err := definitions([]byte(`{"type": "object", "properties": {"a": {"type": "string"}}}`))
+		Expect(err).To(MatchError("root schema needs a title to name its type"))
+		_, err = definitions([]byte(`{"type": "string"}`))
+		Expect(err).To(MatchError(ContainSubstring("no schemas found")))
+
+		defs, err := definitions([]byte(`{"$defs": {"A": {"properties": {"b": {"$ref": "#/$defs/B"}}}}}`))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = generate("api", "a.json", defs)
+		Expect(err).To(MatchError(`A: b: reference "#/$defs/B" points to an unknown schema`))
+
+		defs, err = definitions([]byte(`{"$defs": {"A": {"properties": {"b": {"$ref": "other.json#/B"}}}}}`))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = generate("api", "a.json", defs)
+		Expect(err).To(MatchError(ContainSubstring(`reference "other.json#/B" is not supported`)))
+	})
+
+	It("names types and fields like Go code", func() {
+		Expect(goName("pet_id")).To(Equal("PetID"))
+		Expect(goName("petId")).To(Equal("PetID"))
+		Expect(goName("api-url")).To(Equal("APIURL"))
+		Expect(goName("firstName")).To(Equal("FirstName"))
+		Expect(goName("2fa")).To(Equal("X2fa"))
+	})
+
+	It("writes the generated file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "models.go")
+		var out bytes.Buffer
+		Expect(run([]string{"-package=api", "-o", path, "testdata/petstore.json"}, &out)).To(Succeed())
+		Expect(out.String()).To(BeEmpty())
+		golden, err := os.ReadFile("testdata/petstore.go.golden")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.ReadFile(path)).To(Equal(golden))
+
+		Expect(run([]string{"testdata/petstore.json"}, &out)).To(MatchError("-package is required"))
+		Expect(run([]string{"-package=api"}, &out)).To(MatchError("lakery-schema expects one schema file"))
+	})
+})
@@ -0,0 +1,60 @@
+// Command lakery-schema generates Go structs with lakery tags from a JSON Schema or the component
+// schemas of an OpenAPI document (JSON), so models of an API spec come with their validation:
+//
+//	//go:generate lakery-schema -package api -o models.go openapi.json
+//
+// Objects become structs with json tags, $refs to objects become nested structs validated with dive.
+// minLength, maxLength, minItems, maxItems, minimum and maximum become min, max and inrange rules;
+// required strings, arrays and maps must not be empty, and the rules of optional properties are
+// prefixed with omitempty. Constraints without a lakery rule (pattern, format, enum) are noted in
+// a comment above the field.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "lakery-schema:", err)
+		os.Exit(2)
+	}
+}
+
+// run generates the Go file for the schema file in args.
+func run(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("lakery-schema", flag.ContinueOnError)
+	pkg := fs.String("package", "", "`name` of the package of the generated file")
+	out := fs.String("o", "", "write the generated source to `file` instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *pkg == "" {
+		return fmt.Errorf("-package is required")
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("lakery-schema expects one schema file")
+	}
+	path := fs.Arg(0)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	defs, err := definitions(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	src, err := generate(*pkg, filepath.Base(path), defs)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if *out == "" {
+		_, err = stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*out, src, 0o644)
+}
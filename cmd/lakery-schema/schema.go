@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// schema is the subset of JSON Schema (and OpenAPI schema objects) lakery-schema understands.
+type schema struct {
+	Ref                  string            `json:"$ref"`
+	Type                 schemaType        `json:"type"`
+	Format               string            `json:"format"`
+	Description          string            `json:"description"`
+	Title                string            `json:"title"`
+	Nullable             bool              `json:"nullable"`
+	Properties           *schemas          `json:"properties"`
+	Required             []string          `json:"required"`
+	Items                *schema           `json:"items"`
+	AdditionalProperties json.RawMessage   `json:"additionalProperties"`
+	MinLength            *int              `json:"minLength"`
+	MaxLength            *int              `json:"maxLength"`
+	MinItems             *int              `json:"minItems"`
+	MaxItems             *int              `json:"maxItems"`
+	Minimum              *float64          `json:"minimum"`
+	Maximum              *float64          `json:"maximum"`
+	ExclusiveMinimum     json.RawMessage   `json:"exclusiveMinimum"`
+	ExclusiveMaximum     json.RawMessage   `json:"exclusiveMaximum"`
+	Pattern              string            `json:"pattern"`
+	Enum                 []json.RawMessage `json:"enum"`
+	Defs                 *schemas          `json:"$defs"`
+	Definitions          *schemas          `json:"definitions"`
+}
+
+// schemaType is the type of a schema. JSON Schema allows a list like ["string", "null"],
+// which is read as a nullable string.
+type schemaType struct {
+	name     string
+	nullable bool
+}
+
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		t.name = name
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("type must be a string or a list of strings")
+	}
+	for _, name := range names {
+		switch {
+		case name == "null":
+			t.nullable = true
+		case t.name == "":
+			t.name = name
+		default:
+			return fmt.Errorf("union type %s is not supported", strings.Join(names, ","))
+		}
+	}
+	return nil
+}
+
+// schemas maps names to schemas keeping the order of the document, so generated fields and
+// types follow the order of the spec.
+type schemas struct {
+	names  []string
+	byName map[string]*schema
+}
+
+func (s *schemas) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("expected an object of schemas")
+	}
+	s.byName = make(map[string]*schema)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := tok.(string)
+		var sch schema
+		if err := dec.Decode(&sch); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if _, dup := s.byName[name]; !dup {
+			s.names = append(s.names, name)
+		}
+		s.byName[name] = &sch
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// document is a JSON Schema or an OpenAPI document.
+type document struct {
+	schema
+	Components struct {
+		Schemas *schemas `json:"schemas"`
+	} `json:"components"`
+}
+
+// definitions returns the named schemas of a document: the component schemas of an OpenAPI document,
+// or the $defs (definitions) of a JSON Schema followed by the root schema when it is a titled object.
+func definitions(data []byte) (*schemas, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Components.Schemas != nil {
+		return doc.Components.Schemas, nil
+	}
+	defs := &schemas{byName: make(map[string]*schema)}
+	for _, src := range []*schemas{doc.Defs, doc.Definitions} {
+		if src == nil {
+			continue
+		}
+		for _, name := range src.names {
+			defs.names = append(defs.names, name)
+			defs.byName[name] = src.byName[name]
+		}
+	}
+	if doc.Properties != nil {
+		if doc.Title == "" {
+			return nil, fmt.Errorf("root schema needs a title to name its type")
+		}
+		defs.names = append(defs.names, doc.Title)
+		defs.byName[doc.Title] = &doc.schema
+	}
+	if len(defs.names) == 0 {
+		return nil, fmt.Errorf("no schemas found, expected components.schemas, $defs, definitions or a titled root object")
+	}
+	return defs, nil
+}
+
+// refName returns the schema name of a local reference like #/components/schemas/Pet.
+func refName(ref string) (string, error) {
+	for _, prefix := range []string{"#/components/schemas/", "#/$defs/", "#/definitions/"} {
+		if name, ok := strings.CutPrefix(ref, prefix); ok && name != "" && !strings.Contains(name, "/") {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("reference %q is not supported, only local references to named schemas are", ref)
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/trofkm/lakery"
+)
+
+// tagParser parses rules like Validate does by default.
+var tagParser = lakery.DefaultTagParser()
+
+// problem is a malformed rule found by check.
+type problem struct {
+	pos   token.Position
+	field string
+	err   error
+}
+
+func (p problem) String() string {
+	return fmt.Sprintf("%s: %s: %v", p.pos, p.field, p.err)
+}
+
+// runCheck reports malformed rules of struct tags in dir and whether any were found.
+func runCheck(args []string, stdout io.Writer) (bool, error) {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	tag := fs.String("tag", defaultTag, "read rules from the struct tag `key`")
+	aliases := make(aliasFlag)
+	fs.Var(aliases, "alias", "expand the tag `name=rules` like Validator.RegisterAlias (repeatable)")
+	var specs specsFlag
+	fs.Var(&specs, "specs", "report tags not described by the builtin specs or the specs in `file`, written by lakery.WriteSpecs (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return false, err
+	}
+	rs := ruleSet{aliases: aliases}
+	if len(specs) > 0 {
+		known, err := knownTags(specs)
+		if err != nil {
+			return false, err
+		}
+		rs.known = known
+	}
+	if fs.NArg() > 1 {
+		return false, fmt.Errorf("check expects at most one directory")
+	}
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	problems, err := checkDir(dir, *tag, rs)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range problems {
+		fmt.Fprintln(stdout, p)
+	}
+	return len(problems) > 0, nil
+}
+
+// checkDir parses the rules stored under the tag key of every struct field in the non-test Go files of dir.
+// Aliases are expanded before checking.
+func checkDir(dir, tag string, rs ruleSet) ([]problem, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var problems []problem
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				return true
+			}
+			problems = append(problems, checkFields(fset, st, spec.Name.Name+".", tag, rs, nil)...)
+			return true
+		})
+	}
+	return problems, nil
+}
+
+// checkFields checks the rules of the fields of st, descending into fields declared as anonymous structs.
+// Validators not listed by the allow rule of st (or, without one, the allow list inherited from the struct
+// declaring st) are reported too.
+func checkFields(fset *token.FileSet, st *ast.StructType, prefix, tag string, rs ruleSet, inherited []string) []problem {
+	allow, err := allowList(st, tag)
+	if err != nil {
+		return []problem{{pos: fset.Position(st.Pos()), field: prefix + "_", err: err}}
+	}
+	if allow == nil {
+		allow = inherited
+	}
+	var problems []problem
+	for _, field := range st.Fields.List {
+		if isBlank(field) {
+			continue
+		}
+		if field.Tag != nil {
+			if err := checkTag(field.Tag, tag, rs, allow); err != nil {
+				names := fieldNames(field)
+				problems = append(problems, problem{
+					pos:   fset.Position(field.Pos()),
+					field: prefix + strings.Join(names, ","),
+					err:   err,
+				})
+			}
+		}
+		if inline, ok := inlineStruct(field); ok {
+			for _, name := range field.Names {
+				problems = append(problems, checkFields(fset, inline, prefix+name.Name+".", tag, rs, allow)...)
+			}
+		}
+	}
+	return problems
+}
+
+func checkTag(lit *ast.BasicLit, key string, rs ruleSet, allow []string) error {
+	raw, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return err
+	}
+	return checkRules(lakery.TagRules(reflect.StructTag(raw), key), rs, allow)
+}
+
+// isBlank reports whether a field is a blank field declaring rules of the whole struct.
+func isBlank(field *ast.Field) bool {
+	return len(field.Names) == 1 && field.Names[0].Name == "_"
+}
+
+// allowList returns the validators listed by the allow rules of the blank fields of st like
+// Validator.VerifyStruct, nil when st doesn't restrict its validators.
+func allowList(st *ast.StructType, key string) ([]string, error) {
+	var allow []string
+	for _, field := range st.Fields.List {
+		if !isBlank(field) || field.Tag == nil {
+			continue
+		}
+		raw, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return nil, err
+		}
+		rules, err := tagParser.ParseRules(lakery.TagRules(reflect.StructTag(raw), key))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rules {
+			if r.Key != allowRule || r.Param == "" {
+				return nil, fmt.Errorf("%s is not a struct rule, expected allow=validators", r)
+			}
+			allow = append(allow, strings.FieldsFunc(strings.Trim(r.Param, "{}"), func(c rune) bool {
+				return c == ',' || c == ' ' || c == '\t'
+			})...)
+		}
+	}
+	return allow, nil
+}
+
+// allowRule is the struct rule listing the only validators the fields of a struct may use.
+const allowRule = "allow"
+
+// specialTags are handled by the validator itself and always allowed, the rules nested in them are checked.
+var specialTags = map[string]bool{"each": true, "each_sample": true, "keys": true, "values": true, "dive": true, "omitempty": true}
+
+// checkAllowed reports a validator not listed by allow, nil allowing all.
+func checkAllowed(key string, allow []string) error {
+	if allow == nil || specialTags[key] || slices.Contains(allow, key) {
+		return nil
+	}
+	return fmt.Errorf("%s is not allowed by allow=%s", key, strings.Join(allow, " "))
+}
+
+// checkParam reports params which fail every validation, like regexp patterns that don't compile.
+func checkParam(r lakery.Rule) error {
+	if r.Key == "regexp" && r.Param != "" {
+		if _, err := regexp.Compile(lakery.UnquoteParam(r.Param)); err != nil {
+			return fmt.Errorf("regexp has an invalid pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkRules parses rules, descending into rules nested in braces like each={...}, into the
+// variants of profile rules and into aliases, and checks the validators against allow and the known tags.
+func checkRules(rules string, rs ruleSet, allow []string) error {
+	parsed, err := tagParser.ParseRules(rules)
+	if err != nil {
+		return err
+	}
+	for _, r := range parsed {
+		profiles := make([]string, 0, len(r.Profiles))
+		for profile := range r.Profiles {
+			profiles = append(profiles, profile)
+		}
+		sort.Strings(profiles)
+		for _, profile := range profiles {
+			if err := checkRules(r.Profiles[profile].String(), rs, allow); err != nil {
+				return fmt.Errorf("%s: %w", profile, err)
+			}
+		}
+		if alias, ok := rs.aliases[r.Key]; ok && r.Alternatives == nil {
+			// Validator expands only aliases without param, any other use is a misspelled rule
+			if r.Param != "" {
+				return fmt.Errorf("alias %s does not take a param: %q", r.Key, r)
+			}
+			if err := checkRules(alias, rs, allow); err != nil {
+				return fmt.Errorf("%s: %w", r.Key, err)
+			}
+			continue
+		}
+		for _, alt := range r.Alternatives {
+			if err := rs.checkKnown(alt.Key); err != nil {
+				return err
+			}
+			if err := checkAllowed(alt.Key, allow); err != nil {
+				return err
+			}
+			if err := checkParam(alt); err != nil {
+				return err
+			}
+		}
+		if r.Alternatives == nil && r.Profiles == nil {
+			if err := rs.checkKnown(r.Key); err != nil {
+				return err
+			}
+			if err := checkAllowed(r.Key, allow); err != nil {
+				return err
+			}
+			if err := checkParam(r); err != nil {
+				return err
+			}
+		}
+		if inner, ok := strings.CutPrefix(r.Param, "{"); ok {
+			// only collection rules nest validators, other braces hold lists like oneof={a,b}
+			innerAllow := allow
+			if r.Key != "each" && r.Key != "keys" && r.Key != "values" {
+				innerAllow = nil
+			}
+			if err := checkRules(strings.TrimSuffix(inner, "}"), rs, innerAllow); err != nil {
+				return fmt.Errorf("%s: %w", r.Key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ruleSet is what check knows about the tags of the checked rules.
+type ruleSet struct {
+	aliases aliasFlag
+	// tags of the builtin specs and of the -specs files, nil when unknown tags are not reported
+	known map[string]bool
+}
+
+// checkKnown reports a tag neither described by the specs nor special.
+func (rs ruleSet) checkKnown(key string) error {
+	if rs.known == nil || rs.known[key] || specialTags[key] {
+		return nil
+	}
+	return fmt.Errorf("unknown tag %q", key)
+}
+
+// knownTags returns the names of the builtin tags and of the tags described by the specs files.
+func knownTags(files []string) (map[string]bool, error) {
+	known := make(map[string]bool)
+	for _, spec := range lakery.BuiltinSpecs() {
+		known[spec.Name] = true
+	}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		specs, err := lakery.ReadSpecs(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for _, spec := range specs {
+			known[spec.Name] = true
+		}
+	}
+	return known, nil
+}
+
+// specsFlag collects the spec files given as repeated -specs flags.
+type specsFlag []string
+
+func (s *specsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *specsFlag) Set(file string) error {
+	*s = append(*s, file)
+	return nil
+}
+
+// aliasFlag collects the tag aliases given as repeated -alias name=rules flags.
+type aliasFlag map[string]string
+
+func (a aliasFlag) String() string {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (a aliasFlag) Set(s string) error {
+	name, rules, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("alias %q is not in the form name=rules", s)
+	}
+	// aliases may use the aliases given before them, like with RegisterAlias
+	if err := checkRules(rules, ruleSet{aliases: a}, nil); err != nil {
+		return fmt.Errorf("alias %s: %w", name, err)
+	}
+	a[name] = rules
+	return nil
+}
@@ -0,0 +1,139 @@
+// Command lakery-validate works with lakery rule manifests.
+//
+//	lakery-validate manifest [-o file] [-tag key] [dir]
+//	lakery-validate diff-rules [-exit-code] old.manifest.json new.manifest.json
+//	lakery-validate check [-tag key] [-alias name=rules] [-specs file] [dir]
+//	lakery-validate mutate [-tag key] [-pkg packages] [dir]
+//	lakery-validate play [-rules rules] [-value json] [-profile name] [-alias name=rules]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/trofkm/lakery"
+)
+
+const usage = `usage: lakery-validate <command> [arguments]
+
+commands:
+  manifest [-o file] [-tag key] [dir]               print the rule manifest of structs declared in dir
+  diff-rules [-exit-code] old.json new.json         report rules added, removed or changed between manifests
+  check [-tag key] [-alias name=rules] [-specs file] [dir]
+                                                    report malformed rules of structs declared in dir
+  mutate [-tag key] [-pkg packages] [dir]           report rules of structs in dir no test notices mutated
+  play [-rules rules] [-value json]                 validate JSON values (one per line on stdin) against rules
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "manifest":
+		err = runManifest(args, os.Stdout)
+	case "diff-rules":
+		var changed bool
+		changed, err = runDiffRules(args, os.Stdout)
+		if err == nil && changed {
+			os.Exit(1)
+		}
+	case "check":
+		var found bool
+		found, err = runCheck(args, os.Stdout)
+		if err == nil && found {
+			os.Exit(1)
+		}
+	case "mutate":
+		var survived bool
+		survived, err = runMutate(args, os.Stdout, nil)
+		if err == nil && survived {
+			os.Exit(1)
+		}
+	case "play":
+		var failed bool
+		failed, err = runPlay(args, os.Stdin, os.Stdout)
+		if err == nil && failed {
+			os.Exit(1)
+		}
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		err = fmt.Errorf("unknown command %q\n%s", cmd, usage)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lakery-validate:", err)
+		os.Exit(2)
+	}
+}
+
+func runManifest(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("manifest", flag.ContinueOnError)
+	out := fs.String("o", "", "write the manifest to `file` instead of stdout")
+	tag := fs.String("tag", defaultTag, "read rules from the struct tag `key`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	m, err := scanDir(dir, *tag)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		return lakery.WriteManifest(stdout, m)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return lakery.WriteManifest(f, m)
+}
+
+// runDiffRules prints the rule changes and, with -exit-code, reports whether there were any.
+func runDiffRules(args []string, stdout io.Writer) (bool, error) {
+	fs := flag.NewFlagSet("diff-rules", flag.ContinueOnError)
+	exitCode := fs.Bool("exit-code", false, "exit with status 1 when the manifests differ")
+	if err := fs.Parse(args); err != nil {
+		return false, err
+	}
+	if fs.NArg() != 2 {
+		return false, fmt.Errorf("diff-rules expects two manifest files")
+	}
+	old, err := readManifestFile(fs.Arg(0))
+	if err != nil {
+		return false, err
+	}
+	new, err := readManifestFile(fs.Arg(1))
+	if err != nil {
+		return false, err
+	}
+	changes, err := lakery.DiffManifests(old, new)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range changes {
+		fmt.Fprintln(stdout, c)
+	}
+	return *exitCode && len(changes) > 0, nil
+}
+
+func readManifestFile(path string) (*lakery.Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := lakery.ReadManifest(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/trofkm/lakery"
+	"github.com/trofkm/lakery/lakerytest"
+)
+
+var _ = Describe("lakery-validate", func() {
+	Context("manifest", func() {
+		It("collects rules of struct declarations", func() {
+			m, err := scanDir("testdata/api", defaultTag)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Types).To(Equal([]lakery.ManifestType{{
+				Name: "api.User",
+				Fields: []lakery.ManifestField{
+					{Name: "Name", Rules: []string{"required", "min=2"}},
+					{Name: "First", Rules: []string{"max=32"}},
+					{Name: "Last", Rules: []string{"max=32"}},
+					{Name: "Password", Rules: []string{"required", "min=12", "notin=@common_passwords"}},
+				},
+			}}))
+		})
+	})
+
+	Context("manifest of inline structs", func() {
+		It("names fields of anonymous struct fields with dots", func() {
+			m, err := scanDir("testdata/inline", defaultTag)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Types).To(Equal([]lakery.ManifestType{{
+				Name: "inline.Config",
+				Fields: []lakery.ManifestField{
+					{Name: "Retry.Attempts", Rules: []string{"min=1", "max=10"}},
+					{Name: "Retry.Backoff.Max", Rules: []string{"max=60"}},
+				},
+			}}))
+			Expect(mutationsOf(m)).To(BeEmpty())
+		})
+	})
+
+	Context("manifest of embedded structs", func() {
+		It("lists promoted fields under the embedding type", func() {
+			m, err := scanDir("testdata/embedded", defaultTag)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Types).To(Equal([]lakery.ManifestType{
+				{
+					Name: "embedded.Admin",
+					Fields: []lakery.ManifestField{
+						{Name: "ID", Rules: []string{"min=1"}},
+						{Name: "CreatedBy", Rules: []string{"required"}},
+						{Name: "Role", Rules: []string{"oneof=owner admin"}},
+					},
+				},
+				{Name: "embedded.Audit", Fields: []lakery.ManifestField{{Name: "CreatedBy", Rules: []string{"required"}}}},
+				{Name: "embedded.Node", Fields: []lakery.ManifestField{{Name: "Name", Rules: []string{"required"}}}},
+				{Name: "embedded.base", Fields: []lakery.ManifestField{{Name: "ID", Rules: []string{"min=1"}}}},
+			}))
+		})
+	})
+
+	Context("manifest with -tag", func() {
+		It("reads rules from the given tag key", func() {
+			var out bytes.Buffer
+			Expect(runManifest([]string{"-tag", "lakery2", "testdata/api"}, &out)).To(Succeed())
+			m, err := lakery.ReadManifest(&out)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Types).To(Equal([]lakery.ManifestType{{
+				Name:   "api.User",
+				Fields: []lakery.ManifestField{{Name: "Password", Rules: []string{"notin=@common_passwords"}}},
+			}}))
+		})
+	})
+
+	Context("diff-rules", func() {
+		It("prints changes and reports them with -exit-code", func() {
+			dir := GinkgoT().TempDir()
+			oldPath := filepath.Join(dir, "old.json")
+			Expect(runManifest([]string{"-o", oldPath, "testdata/api"}, nil)).To(Succeed())
+
+			m, err := scanDir("testdata/api", defaultTag)
+			Expect(err).NotTo(HaveOccurred())
+			m.Types[0].Fields[0].Rules = []string{"required", "min=3"}
+			var buf bytes.Buffer
+			Expect(lakery.WriteManifest(&buf, m)).To(Succeed())
+			newPath := filepath.Join(dir, "new.json")
+			Expect(os.WriteFile(newPath, buf.Bytes(), 0o644)).To(Succeed())
+
+			var out bytes.Buffer
+			changed, err := runDiffRules([]string{"-exit-code", oldPath, newPath}, &out)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(out.String()).To(Equal("api.User.Name: changed min=2 -> min=3\n"))
+		})
+	})
+
+	Context("check", func() {
+		It("reports malformed rules with their position", func() {
+			var out bytes.Buffer
+			found, err := runCheck([]string{"testdata/broken"}, &out)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			Expect(lines).To(HaveLen(6))
+			Expect(lines[0]).To(HavePrefix(filepath.Join("testdata", "broken", "broken.go") + ":5:"))
+			Expect(lines[0]).To(HaveSuffix(`Contact.Phone: empty alternative in "|phone"`))
+			Expect(lines[1]).To(HaveSuffix(`Contact.Tags: each: omitempty cannot be used in alternatives: "min=1|omitempty"`))
+			Expect(lines[2]).To(ContainSubstring("Contact.Notes: unclosed braces"))
+			Expect(lines[3]).To(HavePrefix(filepath.Join("testdata", "broken", "inline.go") + ":5:"))
+			Expect(lines[3]).To(HaveSuffix(`Settings.Limits.Rate: empty alternative in "min=1|"`))
+			Expect(lines[4]).To(HavePrefix(filepath.Join("testdata", "broken", "pattern.go") + ":4:"))
+			Expect(lines[4]).To(HaveSuffix("Order.Code: regexp has an invalid pattern: error parsing regexp: missing closing ): `^[A-Z]{2,3}(-[0-9]+$`"))
+			Expect(lines[5]).To(HaveSuffix(`Quota.Tags: prod: each: omitempty cannot be used in alternatives: "min=1|omitempty"`))
+		})
+		It("accepts well-formed rules", func() {
+			var out bytes.Buffer
+			found, err := runCheck([]string{"testdata/api"}, &out)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+			Expect(out.String()).To(BeEmpty())
+		})
+		It("reports validators not listed by allow", func() {
+			var out bytes.Buffer
+			found, err := runCheck([]string{"testdata/allow"}, &out)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			Expect(lines).To(HaveLen(4))
+			Expect(lines[0]).To(HaveSuffix("Credentials.Password: notin is not allowed by allow=required min max oneof"))
+			Expect(lines[1]).To(HaveSuffix("Credentials.Scopes: each: email is not allowed by allow=required min max oneof"))
+			Expect(lines[2]).To(HaveSuffix("Credentials.Limits.Rate: inrange is not allowed by allow=required min max oneof"))
+			Expect(lines[3]).To(HaveSuffix("Token._: max=64 is not a struct rule, expected allow=validators"))
+		})
+		It("reports tags not described by the specs", func() {
+			var out bytes.Buffer
+			found, err := runCheck([]string{"testdata/plugin"}, &out)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			specs := filepath.Join("testdata", "plugin", "companyids.specs.json")
+			found, err = runCheck([]string{"-specs", specs, "testdata/plugin"}, &out)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			Expect(lines).To(HaveLen(2))
+			Expect(lines[0]).To(HaveSuffix(`Order.Ref: unknown tag "ulid"`))
+			Expect(lines[1]).To(HaveSuffix(`Order.Lines: each: unknown tag "sku"`))
+
+			_, err = runCheck([]string{"-specs", "testdata/missing.json", "testdata/plugin"}, &out)
+			Expect(err).To(HaveOccurred())
+		})
+		It("expands aliases before checking", func() {
+			var out bytes.Buffer
+			args := []string{"-alias", "tag=omitempty,max=5", "-alias", "password=required,min=8", "testdata/alias"}
+			found, err := runCheck(args, &out)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(strings.TrimSpace(out.String())).To(HaveSuffix(`Signup.Pin: alias password does not take a param: "password=4"`))
+
+			out.Reset()
+			found, err = runCheck([]string{"-alias", "tag=min=1|", "testdata/alias"}, &out)
+			Expect(err).To(MatchError(ContainSubstring(`alias tag: empty alternative in "min=1|"`)))
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Context("play", func() {
+		It("validates a single value", func() {
+			var out bytes.Buffer
+			failed, err := runPlay([]string{"-rules", "required,min=3", "-value", `"ab"`}, nil, &out)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failed).To(BeTrue())
+			Expect(out.String()).To(Equal(`fail "ab": field "value" validation error: should have length at least 3 (received: 'ab')` + "\n"))
+		})
+		It("validates every line of stdin and switches rules", func() {
+			var out bytes.Buffer
+			stdin := strings.NewReader("\"abc\"\n\n:rules each={min=2}\n[\"a\",\"bb\"]\nnot json\n")
+			failed, err := runPlay([]string{"-rules", "min=3"}, stdin, &out)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failed).To(BeTrue())
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			Expect(lines).To(HaveLen(3))
+			Expect(lines[0]).To(Equal(`pass "abc"`))
+			Expect(lines[1]).To(HavePrefix(`fail ["a","bb"]: value[0]: `))
+			Expect(lines[2]).To(HavePrefix("invalid value not json: "))
+		})
+		It("reports malformed rules", func() {
+			var out bytes.Buffer
+			failed, err := runPlay(nil, strings.NewReader(":rules min=1|\n1\n"), &out)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failed).To(BeTrue())
+			Expect(out.String()).To(HavePrefix(`invalid rules: empty alternative in "min=1|"`))
+		})
+		It("expands aliases and profile rules", func() {
+			var out bytes.Buffer
+			args := []string{"-alias", "pin=required,min=4", "-profile", "dev", "-rules", "prod:pin;dev:max=2", "-value", `"123"`}
+			failed, err := runPlay(args, nil, &out)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failed).To(BeTrue())
+			Expect(out.String()).To(ContainSubstring("should have length at most 2"))
+		})
+		It("requires rules for -value", func() {
+			_, err := runPlay([]string{"-value", "1"}, nil, io.Discard)
+			Expect(err).To(MatchError("-value needs -rules"))
+		})
+	})
+
+	Context("mutate", func() {
+		It("reports mutations no test notices", func() {
+			var runs []string
+			run := func(env []string) (bool, error) {
+				if len(env) == 0 {
+					return true, nil
+				}
+				runs = append(runs, env[0])
+				// pretend only the rules of Last are untested
+				return strings.Contains(env[0], `"field":"Last"`), nil
+			}
+			var out bytes.Buffer
+			survived, err := runMutate([]string{"testdata/api"}, &out, run)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(survived).To(BeTrue())
+			Expect(runs).To(HaveLen(15))
+			Expect(runs[0]).To(HavePrefix(lakerytest.MutateEnv + "="))
+			Expect(out.String()).To(Equal("" +
+				"api.User.Last: drop max=32 survived\n" +
+				"api.User.Last: max=32 -> max=31 survived\n" +
+				"api.User.Last: max=32 -> max=33 survived\n" +
+				"mutation score: 12/15 killed (80.0%)\n"))
+		})
+		It("tells build failures from failing tests", func() {
+			Expect(buildFailed([]byte("--- FAIL: TestX\nFAIL\nFAIL\tapi\t0.01s\nFAIL\n"))).To(BeFalse())
+			Expect(buildFailed([]byte("# api [api.test]\n./a.go:2:23: cannot use \"a\"\nFAIL\tapi [build failed]\nFAIL\n"))).To(BeTrue())
+			Expect(buildFailed([]byte("FAIL\tapi [setup failed]\n"))).To(BeTrue())
+		})
+		It("requires passing tests without mutations", func() {
+			run := func([]string) (bool, error) { return false, nil }
+			_, err := runMutate([]string{"testdata/api"}, io.Discard, run)
+			Expect(err).To(MatchError("tests fail without mutations"))
+		})
+	})
+})
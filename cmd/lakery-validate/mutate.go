@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/trofkm/lakery"
+	"github.com/trofkm/lakery/lakerytest"
+)
+
+// testRunner runs the test suite with the extra environment and reports whether it passed.
+type testRunner func(env []string) (bool, error)
+
+// runMutate mutates every rule of the structs in dir, reruns the tests for each mutation and
+// reports the mutations no test noticed. It reports whether any mutation survived.
+func runMutate(args []string, stdout io.Writer, run testRunner) (bool, error) {
+	fs := flag.NewFlagSet("mutate", flag.ContinueOnError)
+	tag := fs.String("tag", defaultTag, "read rules from the struct tag `key`")
+	pkgs := fs.String("pkg", "./...", "space-separated `packages` whose tests are run")
+	if err := fs.Parse(args); err != nil {
+		return false, err
+	}
+	if fs.NArg() > 1 {
+		return false, fmt.Errorf("mutate expects at most one directory")
+	}
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	m, err := scanDir(dir, *tag)
+	if err != nil {
+		return false, err
+	}
+	if run == nil {
+		run = goTest(strings.Fields(*pkgs))
+	}
+	passed, err := run(nil)
+	if err != nil {
+		return false, err
+	}
+	if !passed {
+		return false, fmt.Errorf("tests fail without mutations")
+	}
+
+	mutations := mutationsOf(m)
+	survived := 0
+	for _, mut := range mutations {
+		passed, err := run([]string{lakerytest.MutationEnv(mut)})
+		if err != nil {
+			return false, err
+		}
+		if passed {
+			survived++
+			fmt.Fprintf(stdout, "%s survived\n", mut)
+		}
+	}
+	killed := len(mutations) - survived
+	score := 100.0
+	if len(mutations) > 0 {
+		score = float64(killed) * 100 / float64(len(mutations))
+	}
+	fmt.Fprintf(stdout, "mutation score: %d/%d killed (%.1f%%)\n", killed, len(mutations), score)
+	return survived > 0, nil
+}
+
+// mutationsOf returns the mutations of every rule in the manifest: the rule is dropped and,
+// for integer params like min=3, the param is loosened and tightened by one. Fields of inline
+// structs are not mutated.
+func mutationsOf(m *lakery.Manifest) []lakery.Mutation {
+	var out []lakery.Mutation
+	for _, t := range m.Types {
+		for _, f := range t.Fields {
+			if strings.Contains(f.Name, ".") {
+				// rules of inline struct fields are compiled for the anonymous struct type, which
+				// a mutation naming the outer type cannot target
+				continue
+			}
+			for _, rule := range f.Rules {
+				base := lakery.Mutation{Type: t.Name, Field: f.Name, Rule: rule}
+				out = append(out, base)
+				key, param, ok := strings.Cut(rule, "=")
+				if !ok || strings.Contains(rule, "|") {
+					continue
+				}
+				n, err := strconv.Atoi(param)
+				if err != nil {
+					continue
+				}
+				if n > 0 {
+					lower := base
+					lower.Replace = key + "=" + strconv.Itoa(n-1)
+					out = append(out, lower)
+				}
+				higher := base
+				higher.Replace = key + "=" + strconv.Itoa(n+1)
+				out = append(out, higher)
+			}
+		}
+	}
+	return out
+}
+
+// goTest returns a runner invoking go test for pkgs in the current directory. Failing tests
+// kill a mutation; packages which don't build are an error, they fail with any mutation.
+func goTest(pkgs []string) testRunner {
+	return func(env []string) (bool, error) {
+		cmd := exec.Command("go", append([]string{"test", "-count=1"}, pkgs...)...)
+		cmd.Env = append(os.Environ(), env...)
+		out, err := cmd.CombinedOutput()
+		if _, ok := err.(*exec.ExitError); ok {
+			if buildFailed(out) {
+				return false, fmt.Errorf("go test: build failed:\n%s", out)
+			}
+			return false, nil
+		}
+		return err == nil, err
+	}
+}
+
+// buildFailed reports whether go test output lists a package which failed to build or set up.
+func buildFailed(out []byte) bool {
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "FAIL") && (strings.HasSuffix(line, "[build failed]") || strings.HasSuffix(line, "[setup failed]")) {
+			return true
+		}
+	}
+	return false
+}
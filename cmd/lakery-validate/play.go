@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/trofkm/lakery"
+)
+
+// rulesCommand sets the rules of the following values in a play session.
+const rulesCommand = ":rules "
+
+// runPlay validates sample JSON values against rules with the real engine and prints the outcome of each.
+// With -value a single value is checked; otherwise every line of stdin is a value, and a line like
+// ":rules min=3" changes the rules of the values after it. It reports whether any value failed.
+func runPlay(args []string, stdin io.Reader, stdout io.Writer) (bool, error) {
+	fs := flag.NewFlagSet("play", flag.ContinueOnError)
+	rules := fs.String("rules", "", "validate values against the `rules`, e.g. required,min=3")
+	value := fs.String("value", "", "validate the `json` value instead of reading values from stdin")
+	profile := fs.String("profile", "", "select the variant of profile rules like WithProfile")
+	var aliases [][2]string
+	fs.Func("alias", "expand the tag `name=rules` like Validator.RegisterAlias (repeatable)", func(s string) error {
+		name, rules, ok := strings.Cut(s, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("alias %q is not in the form name=rules", s)
+		}
+		if _, err := lakery.ParseRules(rules); err != nil {
+			return fmt.Errorf("alias %s: %w", name, err)
+		}
+		aliases = append(aliases, [2]string{name, rules})
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return false, err
+	}
+	if fs.NArg() > 0 {
+		return false, fmt.Errorf("play expects no arguments")
+	}
+	v := lakery.NewValidator(lakery.WithCollectAll(), lakery.WithProfile(*profile))
+	for _, alias := range aliases {
+		v.RegisterAlias(alias[0], alias[1])
+	}
+
+	if *value != "" {
+		if *rules == "" {
+			return false, fmt.Errorf("-value needs -rules")
+		}
+		return play(v, *rules, *value, stdout), nil
+	}
+	failed := false
+	current := *rules
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, rulesCommand):
+			current = strings.TrimSpace(strings.TrimPrefix(line, rulesCommand))
+			if _, err := lakery.ParseRules(current); err != nil {
+				fmt.Fprintf(stdout, "invalid rules: %v\n", err)
+				failed = true
+			}
+		case current == "":
+			fmt.Fprintf(stdout, "no rules, set them with %q or -rules\n", rulesCommand+"...")
+			failed = true
+		default:
+			failed = play(v, current, line, stdout) || failed
+		}
+	}
+	return failed, scanner.Err()
+}
+
+// play validates a single JSON value, prints pass or every failure and reports whether it failed.
+func play(v *lakery.Validator, rules, value string, stdout io.Writer) bool {
+	var decoded any
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		fmt.Fprintf(stdout, "invalid value %s: %v\n", value, err)
+		return true
+	}
+	err := v.Var(decoded, rules)
+	if err == nil {
+		fmt.Fprintf(stdout, "pass %s\n", value)
+		return false
+	}
+	var errs lakery.ValidationErrors
+	if !errors.As(err, &errs) {
+		fmt.Fprintf(stdout, "fail %s: %v\n", value, err)
+		return true
+	}
+	for _, fe := range errs {
+		fmt.Fprintf(stdout, "fail %s: %v\n", value, fe)
+	}
+	return true
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/trofkm/lakery"
+)
+
+// defaultTag is the struct tag key read unless -tag is given.
+const defaultTag = "lakery"
+
+// scanDir parses the non-test Go files in dir and collects the rules stored under the tag key of every
+// struct type declaration with rules into a manifest. Type names are qualified with the package name
+// so they match manifests produced at runtime by Validator.Manifest.
+func scanDir(dir, tag string) (*lakery.Manifest, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	s := &scanner{fset: fset, tag: tag, structs: packageStructs(files)}
+	m := &lakery.Manifest{Version: lakery.ManifestVersion}
+	for _, file := range files {
+		types, err := s.scanFile(file.Name.Name, file)
+		if err != nil {
+			return nil, err
+		}
+		m.Types = append(m.Types, types...)
+	}
+	sort.Slice(m.Types, func(i, j int) bool { return m.Types[i].Name < m.Types[j].Name })
+	return m, nil
+}
+
+// scanner collects the rules of the struct declarations of a package.
+type scanner struct {
+	fset *token.FileSet
+	tag  string
+	// package level struct declarations by name, to descend into embedded structs
+	structs map[string]*ast.StructType
+}
+
+// packageStructs returns the struct types declared at package level in files by name.
+func packageStructs(files []*ast.File) map[string]*ast.StructType {
+	structs := make(map[string]*ast.StructType)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					structs[ts.Name.Name] = st
+				}
+			}
+		}
+	}
+	return structs
+}
+
+func (s *scanner) scanFile(pkgName string, file *ast.File) ([]lakery.ManifestType, error) {
+	var types []lakery.ManifestType
+	var scanErr error
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok || scanErr != nil {
+			return scanErr == nil
+		}
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok {
+			return true
+		}
+		mt := lakery.ManifestType{Name: pkgName + "." + spec.Name.Name}
+		fields, err := s.structFields(st, "", []string{spec.Name.Name})
+		if err != nil {
+			scanErr = fmt.Errorf("%s: %s: %w", s.fset.Position(err.pos), mt.Name, err.err)
+			return false
+		}
+		mt.Fields = fields
+		if len(mt.Fields) > 0 {
+			types = append(types, mt)
+		}
+		return true
+	})
+	return types, scanErr
+}
+
+// fieldError is a malformed tag of the field at pos.
+type fieldError struct {
+	pos token.Pos
+	err error
+}
+
+// structFields collects the rules of the fields of st, descending into fields declared as
+// anonymous structs and into embedded structs the way Validate does. Fields of anonymous structs
+// are named with dots (Opts.Retries), promoted fields of embedded structs keep the prefix of st.
+// Only embedded structs declared in the scanned package can be followed. Fields tagged "-" are
+// excluded like at runtime. embedding holds the embedded types entered so far, to stop at cycles.
+func (s *scanner) structFields(st *ast.StructType, prefix string, embedding []string) ([]lakery.ManifestField, *fieldError) {
+	var fields []lakery.ManifestField
+	for _, field := range st.Fields.List {
+		if field.Tag != nil {
+			rules, err := fieldRules(field.Tag, s.tag)
+			if err != nil {
+				return nil, &fieldError{pos: field.Pos(), err: err}
+			}
+			if len(rules) == 1 && rules[0] == "-" {
+				continue
+			}
+			if len(rules) > 0 {
+				for _, name := range fieldNames(field) {
+					fields = append(fields, lakery.ManifestField{Name: prefix + name, Rules: rules})
+				}
+			}
+		}
+		if name, embedded, ok := s.embeddedStruct(field); ok && !slices.Contains(embedding, name) {
+			nested, err := s.structFields(embedded, prefix, append(embedding, name))
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, nested...)
+			continue
+		}
+		inline, ok := inlineStruct(field)
+		if !ok {
+			continue
+		}
+		for _, name := range field.Names {
+			nested, err := s.structFields(inline, prefix+name.Name+".", embedding)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, nested...)
+		}
+	}
+	return fields, nil
+}
+
+// embeddedStruct returns the name and declaration of an embedded struct (or pointer to one) declared
+// in the scanned package. Unexported embedded structs are entered too, their fields are promoted.
+func (s *scanner) embeddedStruct(field *ast.Field) (string, *ast.StructType, bool) {
+	if len(field.Names) > 0 {
+		return "", nil, false
+	}
+	typ := field.Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	ident, ok := typ.(*ast.Ident)
+	if !ok {
+		return "", nil, false
+	}
+	st, ok := s.structs[ident.Name]
+	return ident.Name, st, ok
+}
+
+// inlineStruct returns the type of a field declared as an anonymous struct (or a pointer to one).
+// Only exported fields are traversed, like at runtime.
+func inlineStruct(field *ast.Field) (*ast.StructType, bool) {
+	if len(field.Names) == 0 || !field.Names[0].IsExported() {
+		return nil, false
+	}
+	typ := field.Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	st, ok := typ.(*ast.StructType)
+	return st, ok
+}
+
+func fieldRules(lit *ast.BasicLit, key string) ([]string, error) {
+	raw, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return nil, err
+	}
+	tag := lakery.TagRules(reflect.StructTag(raw), key)
+	if tag == "" {
+		return nil, nil
+	}
+	return lakery.SplitRules(tag)
+}
+
+// fieldNames returns the declared names of a field; embedded fields are named after their type.
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) > 0 {
+		names := make([]string, len(field.Names))
+		for i, ident := range field.Names {
+			names[i] = ident.Name
+		}
+		return names
+	}
+	typ := field.Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	switch t := typ.(type) {
+	case *ast.Ident:
+		return []string{t.Name}
+	case *ast.SelectorExpr:
+		return []string{t.Sel.Name}
+	}
+	return nil
+}
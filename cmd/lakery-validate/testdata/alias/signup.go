@@ -0,0 +1,7 @@
+package alias
+
+type Signup struct {
+	Password string   `lakery:"password"`
+	Pin      string   `lakery:"password=4"`
+	Tags     []string `lakery:"each={tag}"`
+}
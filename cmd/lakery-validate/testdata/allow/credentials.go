@@ -0,0 +1,16 @@
+package allow
+
+type Credentials struct {
+	_        struct{} `lakery:"allow=required min max oneof"`
+	User     string   `lakery:"required,max=64"`
+	Password string   `lakery:"required,min=12,notin=@common"`
+	Scopes   []string `lakery:"each={oneof={read,write}|email}"`
+	Limits   struct {
+		Rate int `lakery:"min=1,inrange=1:10"`
+	}
+}
+
+type Token struct {
+	_     struct{} `lakery:"allow=required,max=64"`
+	Value string   `lakery:"required"`
+}
@@ -0,0 +1,12 @@
+package api
+
+type User struct {
+	Name        string `json:"name" lakery:"required,min=2"`
+	First, Last string `lakery:"max=32"`
+	Internal    string
+	Password    string `lakery:"required,min=12" lakery2:"notin=@common_passwords"`
+}
+
+type Empty struct {
+	ID int
+}
@@ -0,0 +1,8 @@
+package broken
+
+type Contact struct {
+	Email string   `lakery:"required,email|uuid"`
+	Phone string   `lakery:"required,|phone"`
+	Tags  []string `lakery:"each={min=1|omitempty}"`
+	Notes string   `lakery:"max=10,each={"`
+}
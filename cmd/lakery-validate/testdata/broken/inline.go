@@ -0,0 +1,7 @@
+package broken
+
+type Settings struct {
+	Limits struct {
+		Rate int `lakery:"min=1|"`
+	}
+}
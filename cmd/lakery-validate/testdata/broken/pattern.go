@@ -0,0 +1,5 @@
+package broken
+
+type Order struct {
+	Code string `lakery:"required,regexp='^[A-Z]{2,3}(-[0-9]+$'"`
+}
@@ -0,0 +1,5 @@
+package broken
+
+type Quota struct {
+	Tags []string `lakery:"dev:max=3;prod:each={min=1|omitempty}"`
+}
@@ -0,0 +1,15 @@
+package embedded
+
+import "time"
+
+type Admin struct {
+	base
+	*Audit
+	time.Time
+	Role string `lakery:"oneof=owner admin"`
+}
+
+type Node struct {
+	*Node
+	Name string `lakery:"required"`
+}
@@ -0,0 +1,9 @@
+package embedded
+
+type base struct {
+	ID int `lakery:"min=1"`
+}
+
+type Audit struct {
+	CreatedBy string `lakery:"required"`
+}
@@ -0,0 +1,16 @@
+package inline
+
+type Config struct {
+	Retry struct {
+		Attempts int `lakery:"min=1,max=10"`
+		Backoff  *struct {
+			Max int `lakery:"max=60"`
+		}
+	}
+	Debug struct {
+		Level int `lakery:"min=1"`
+	} `lakery:"-"`
+	internal struct {
+		N int `lakery:"min=1"`
+	}
+}
@@ -0,0 +1,7 @@
+package plugin
+
+type Order struct {
+	Customer string   `lakery:"required,companyid"`
+	Ref      string   `lakery:"companyid|ulid"`
+	Lines    []string `lakery:"each={min=1,sku}"`
+}
@@ -0,0 +1,249 @@
+package lakery
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RulesCompatibility tells how a change of rules affects the values they accept.
+type RulesCompatibility string
+
+const (
+	RulesUnchanged RulesCompatibility = "unchanged"
+	// every previously valid value is still valid
+	RulesLooser RulesCompatibility = "looser"
+	// some previously valid values are rejected, no new ones are accepted
+	RulesStricter RulesCompatibility = "stricter"
+	// the change both accepts and rejects values, or its effect cannot be determined
+	RulesIncompatible RulesCompatibility = "incompatible"
+)
+
+// Breaking reports whether previously valid values may be rejected.
+func (c RulesCompatibility) Breaking() bool {
+	return c == RulesStricter || c == RulesIncompatible
+}
+
+// combine returns the compatibility of two changes made together.
+func (c RulesCompatibility) combine(other RulesCompatibility) RulesCompatibility {
+	switch {
+	case c == other || other == RulesUnchanged:
+		return c
+	case c == RulesUnchanged:
+		return other
+	default:
+		return RulesIncompatible
+	}
+}
+
+// FieldCompatibility describes how the rules of a field changed between two versions of a struct type.
+type FieldCompatibility struct {
+	// field name, with dots for fields of inline structs (Opts.Retries)
+	Field         string
+	Compatibility RulesCompatibility
+	// the changed rules with their effect, e.g. "min=2 -> min=3: stricter"
+	Changes []string
+}
+
+func (f FieldCompatibility) String() string {
+	return fmt.Sprintf("%s: %s (%s)", f.Field, f.Compatibility, strings.Join(f.Changes, "; "))
+}
+
+// CompatibleRules compares the rules of two versions of a struct type with the default validator,
+// see Validator.CompatibleRules.
+func CompatibleRules(oldType, newType any) ([]FieldCompatibility, error) {
+	return Default().CompatibleRules(oldType, newType)
+}
+
+// CompatibleRules compares the tag rules of two versions of a struct type (values or pointers) and
+// reports every field whose rules changed, in declaration order, so API owners can gate releases
+// which would start rejecting previously valid payloads:
+//
+//	changes, err := lakery.CompatibleRules(v1.User{}, v2.User{})
+//	for _, c := range changes {
+//		if c.Compatibility.Breaking() {
+//			log.Fatalf("breaking validation change: %s", c)
+//		}
+//	}
+//
+// Rules are matched by key like DiffManifests. Bounds of min, max, inrange, minentropy and money and
+// the lists of notin, incidr and urlhost are compared by value; rules nested in each, keys and values
+// are compared recursively. Other changed params and changed alternatives are reported as incompatible.
+// Fields only present in the new type are stricter when they have rules, removed fields are looser.
+func (v *Validator) CompatibleRules(oldType, newType any) ([]FieldCompatibility, error) {
+	m, err := v.Manifest(oldType, newType)
+	if err != nil {
+		return nil, err
+	}
+	old, new := m.Types[0].Fields, m.Types[1].Fields
+	newRules := make(map[string][]string, len(new))
+	for _, f := range new {
+		newRules[f.Name] = f.Rules
+	}
+	var fields []FieldCompatibility
+	seen := make(map[string]bool, len(old))
+	add := func(name string, oldRules, newRules []string) {
+		c, changes := compareRules(oldRules, newRules)
+		if c != RulesUnchanged {
+			fields = append(fields, FieldCompatibility{Field: name, Compatibility: c, Changes: changes})
+		}
+	}
+	for _, f := range old {
+		seen[f.Name] = true
+		add(f.Name, f.Rules, newRules[f.Name])
+	}
+	for _, f := range new {
+		if !seen[f.Name] {
+			add(f.Name, nil, f.Rules)
+		}
+	}
+	return fields, nil
+}
+
+// compareRules compares two rule lists of a field, matching rules by key.
+func compareRules(old, new []string) (RulesCompatibility, []string) {
+	newByKey := make(map[string]string, len(new))
+	for _, r := range new {
+		newByKey[ruleKey(r)] = r
+	}
+	result := RulesUnchanged
+	var changes []string
+	note := func(c RulesCompatibility, change string) {
+		result = result.combine(c)
+		changes = append(changes, fmt.Sprintf("%s: %s", change, c))
+	}
+	oldKeys := make(map[string]bool, len(old))
+	for _, r := range old {
+		key := ruleKey(r)
+		oldKeys[key] = true
+		newRule, ok := newByKey[key]
+		switch {
+		case !ok && key == omitEmptyTag:
+			note(RulesStricter, "removed "+r)
+		case !ok:
+			note(RulesLooser, "removed "+r)
+		case newRule != r:
+			note(compareRule(key, r, newRule), r+" -> "+newRule)
+		}
+	}
+	for _, r := range new {
+		key := ruleKey(r)
+		switch {
+		case oldKeys[key]:
+		case key == omitEmptyTag:
+			note(RulesLooser, "added "+r)
+		default:
+			note(RulesStricter, "added "+r)
+		}
+	}
+	return result, changes
+}
+
+// compareRule compares two versions of a rule with the same key.
+func compareRule(key, old, new string) RulesCompatibility {
+	_, oldParam, _ := strings.Cut(old, "=")
+	_, newParam, _ := strings.Cut(new, "=")
+	oldParam, newParam = strings.TrimSpace(oldParam), strings.TrimSpace(newParam)
+	switch key {
+	case minTag, minEntropyTag:
+		return compareBound(oldParam, newParam, 1)
+	case maxTag:
+		return compareBound(oldParam, newParam, -1)
+	case inRangeTag:
+		oldLo, oldHi, ok1 := strings.Cut(oldParam, ":")
+		newLo, newHi, ok2 := strings.Cut(newParam, ":")
+		if !ok1 || !ok2 {
+			return RulesIncompatible
+		}
+		return compareBound(oldLo, newLo, 1).combine(compareBound(oldHi, newHi, -1))
+	case moneyTag:
+		oldPlaces, oldSigned, _ := strings.Cut(oldParam, ":")
+		newPlaces, newSigned, _ := strings.Cut(newParam, ":")
+		// fewer decimal places are stricter, dropping signed rejects negative amounts
+		c := compareBound(oldPlaces, newPlaces, -1)
+		switch {
+		case oldSigned == newSigned:
+		case newSigned == "":
+			c = c.combine(RulesStricter)
+		default:
+			c = c.combine(RulesLooser)
+		}
+		return c
+	case notInTag:
+		// a longer denylist is stricter
+		return compareLists(oldParam, newParam, RulesStricter)
+	case inCIDRTag, urlHostTag:
+		// a longer allowlist is looser
+		return compareLists(oldParam, newParam, RulesLooser)
+	case eachTag, keysTag, valuesTag:
+		oldInner, err1 := SplitRules(strings.TrimSuffix(strings.TrimPrefix(oldParam, "{"), "}"))
+		newInner, err2 := SplitRules(strings.TrimSuffix(strings.TrimPrefix(newParam, "{"), "}"))
+		if err1 != nil || err2 != nil {
+			return RulesIncompatible
+		}
+		c, _ := compareRules(oldInner, newInner)
+		return c
+	default:
+		return RulesIncompatible
+	}
+}
+
+// compareBound compares numeric params, or the time params of min and max; with direction 1 a
+// higher bound is stricter (min), with -1 a lower one (max).
+func compareBound(old, new string, direction int) RulesCompatibility {
+	o, ok1 := boundValue(old)
+	n, ok2 := boundValue(new)
+	switch {
+	case !ok1 || !ok2:
+		return RulesIncompatible
+	case o == n:
+		return RulesUnchanged
+	case (n > o) == (direction > 0):
+		return RulesStricter
+	default:
+		return RulesLooser
+	}
+}
+
+// boundValue parses a numeric param, or a date or RFC 3339 time param as seconds since the epoch.
+func boundValue(param string) (float64, bool) {
+	param = strings.TrimSpace(param)
+	if f, err := strconv.ParseFloat(param, 64); err == nil {
+		return f, true
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, param); err == nil {
+			return float64(t.Unix()), true
+		}
+	}
+	return 0, false
+}
+
+// compareLists compares list params like "a b" or "{a,b}"; growing the list has the effect grown.
+func compareLists(old, new string, grown RulesCompatibility) RulesCompatibility {
+	oldItems := (&Value{param: old}).Params()
+	newItems := (&Value{param: new}).Params()
+	if slices.ContainsFunc(slices.Concat(oldItems, newItems), func(s string) bool { return strings.HasPrefix(s, "@") }) {
+		// named sets can't be compared
+		return RulesIncompatible
+	}
+	shrunk := RulesStricter
+	if grown == RulesStricter {
+		shrunk = RulesLooser
+	}
+	superset := func(a, b []string) bool {
+		return !slices.ContainsFunc(b, func(s string) bool { return !slices.Contains(a, s) })
+	}
+	switch {
+	case superset(newItems, oldItems) && superset(oldItems, newItems):
+		return RulesUnchanged
+	case superset(newItems, oldItems):
+		return grown
+	case superset(oldItems, newItems):
+		return shrunk
+	default:
+		return RulesIncompatible
+	}
+}
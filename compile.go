@@ -0,0 +1,226 @@
+package lakery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Compiled validates values of the struct type T with rules resolved once by Compile.
+type Compiled[T any] struct {
+	v *Validator
+	// compiled rules of T and of the struct types reachable from it
+	types map[reflect.Type]*compiledStruct
+}
+
+// Compile resolves the rules of the struct type T and of every struct type reachable from it
+// (dive, each={dive}, embedded and inline structs) and reports configuration errors eagerly:
+// malformed rules, unknown tags, each, keys, values or dive on fields of the wrong type, and
+// validators not listed by the allow rule of their struct. All problems are returned at once,
+// joined with errors.Join.
+//
+//	users, err := lakery.Compile[User](v)
+//	if err != nil {
+//		log.Fatal(err) // Tags: each can be used only with slice or array
+//	}
+//	err = users.Validate(u)
+//
+// The returned validator neither parses tags nor looks up validators at call time. It keeps the rules
+// and validators registered at the time of Compile; registrations made afterwards don't affect it.
+func Compile[T any](v *Validator) (*Compiled[T], error) {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("lakery: Compile needs a struct type, got %v", typ)
+	}
+	types, err := v.checkStruct(typ)
+	if err != nil {
+		return nil, err
+	}
+	return &Compiled[T]{v: v, types: types}, nil
+}
+
+// Validate validates t like Validator.Validate.
+func (c *Compiled[T]) Validate(t T) error {
+	return c.ValidateCtx(context.Background(), t)
+}
+
+// ValidateCtx validates t like Validator.ValidateCtx.
+func (c *Compiled[T]) ValidateCtx(ctx context.Context, t T) error {
+	return c.v.run(&validation{v: c.v, ctx: ctx, types: c.types}, t)
+}
+
+// ValidateT validates value like Validator.Validate after checking at runtime that T is a struct or
+// a pointer to a struct:
+//
+//	err := lakery.ValidateT(v, order)
+//
+// It is not a compile-time guarantee: Go has no constraint for struct types, so ValidateT accepts any
+// T and fails on every call for other types, with an error naming T rather than the dynamic value.
+// Use Compile[T] to reject such types, and the configuration errors of T, once at startup.
+func ValidateT[T any](v *Validator, value T) error {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return fmt.Errorf("lakery: ValidateT needs a struct type, got %v", reflect.TypeFor[T]())
+	}
+	return v.Validate(value)
+}
+
+// VerifyStruct reports the configuration errors of the struct type of s (a value or pointer) and of
+// every struct type reachable from it like Compile, without validating s. Run it in tests or at
+// startup, e.g. to enforce the allow rules of security-sensitive structs:
+//
+//	type Credentials struct {
+//		_        struct{} `lakery:"allow=required min max"`
+//		Password string   `lakery:"required,min=12,notin=@common"` // Password: notin is not allowed by allow=required min max
+//	}
+func (v *Validator) VerifyStruct(s any) error {
+	typ := reflect.TypeOf(s)
+	if typ != nil {
+		typ = structType(typ)
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return fmt.Errorf("lakery: VerifyStruct needs a struct, got %v", typ)
+	}
+	_, err := v.checkStruct(typ)
+	return err
+}
+
+// checkStruct compiles the rules of a struct type and of the struct types reachable from it and
+// checks them against the types of the fields.
+func (v *Validator) checkStruct(typ reflect.Type) (map[reflect.Type]*compiledStruct, error) {
+	c := &structCheck{v: v, types: make(map[reflect.Type]*compiledStruct)}
+	c.compileStruct(typ, "", nil)
+	if len(c.errs) > 0 {
+		return nil, errors.Join(c.errs...)
+	}
+	return c.types, nil
+}
+
+// structCheck collects the compiled struct types and the configuration errors of checkStruct.
+type structCheck struct {
+	v     *Validator
+	types map[reflect.Type]*compiledStruct
+	errs  []error
+}
+
+// compileStruct compiles the rules of a struct type and of the struct types reachable from it,
+// collecting configuration errors named by path. Inline structs without allow rules of their own
+// inherit the allow list of the struct declaring them.
+func (c *structCheck) compileStruct(typ reflect.Type, prefix string, inherited []string) {
+	if _, ok := c.types[typ]; ok {
+		return
+	}
+	compiled := c.v.compiled(typ)
+	c.types[typ] = compiled
+	if compiled.structErr != nil {
+		c.errs = append(c.errs, fmt.Errorf("%s_: %w", prefix, compiled.structErr))
+	}
+	allow := compiled.allow
+	if allow == nil {
+		allow = inherited
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		if compiled.skip[i] {
+			continue
+		}
+		sf := typ.Field(i)
+		path := prefix + sf.Name
+		if err := compiled.errs[i]; err != nil {
+			c.errs = append(c.errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		c.checkRules(sf.Type, path, compiled.rules[i], allow)
+		if compiled.dive[i] {
+			continue
+		}
+		switch {
+		case isEmbeddedStruct(sf):
+			c.compileStruct(structType(sf.Type), prefix, nil)
+		case isInlineStruct(sf):
+			c.compileStruct(structType(sf.Type), path+".", allow)
+		}
+	}
+}
+
+// checkRules checks compiled rules against the type of the value they run on and the allow list of their struct.
+func (c *structCheck) checkRules(typ reflect.Type, path string, rules []rule, allow []string) {
+	fail := func(format string, args ...any) {
+		c.errs = append(c.errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+	checkAllowed := func(key string) {
+		if !allowed(allow, key) {
+			fail("%s is not allowed by allow=%s", key, strings.Join(allow, " "))
+		}
+	}
+	// the rules of interface values and of values unwrapped by custom type functions or for
+	// protobuf depend on the value
+	dynamic := typ.Kind() == reflect.Interface || c.v.protobuf || c.v.hasCustomType(typ)
+	for _, r := range rules {
+		for _, alt := range r.alts {
+			if alt.fn == nil {
+				fail("unknown tag %q", alt.key)
+			} else {
+				checkAllowed(alt.key)
+			}
+		}
+		switch {
+		case r.alts != nil, r.key == omitEmptyTag, r.key == eachSampleTag:
+		case r.key == eachTag:
+			if r.innerErr != nil {
+				fail("%v", r.innerErr)
+			} else if !dynamic && typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+				fail("each can be used only with slice or array")
+			} else if !dynamic {
+				c.checkRules(typ.Elem(), path+"[]", r.inner, allow)
+			}
+		case r.key == keysTag, r.key == valuesTag:
+			switch {
+			case r.innerErr != nil:
+				fail("%v", r.innerErr)
+			case dynamic:
+			case typ.Kind() != reflect.Map:
+				fail("%s can be used only with map", r.key)
+			case r.key == keysTag:
+				c.checkRules(typ.Key(), path+"[]", r.inner, allow)
+			default:
+				c.checkRules(typ.Elem(), path+"[]", r.inner, allow)
+			}
+		case r.key == diveTag:
+			st := structType(typ)
+			switch {
+			case dynamic || st.Kind() == reflect.Interface:
+			case st.Kind() != reflect.Struct:
+				fail("dive can be used only with struct")
+			default:
+				c.compileStruct(st, path+".", nil)
+			}
+		case r.fn == nil:
+			fail("unknown tag %q", r.key)
+		default:
+			checkAllowed(r.key)
+		}
+	}
+}
+
+// hasCustomType reports whether values of typ (or of the type it points to) are unwrapped by a CustomTypeFunc.
+func (v *Validator) hasCustomType(typ reflect.Type) bool {
+	defer v.runlock(v.rlock())
+	_, ok := v.customTypes[typ]
+	if !ok && typ.Kind() == reflect.Pointer {
+		_, ok = v.customTypes[typ.Elem()]
+	}
+	return ok
+}
+
+// structType returns the type behind pointers.
+func structType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
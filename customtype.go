@@ -0,0 +1,63 @@
+package lakery
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// CustomTypeFunc unwraps a value of a type registered with RegisterCustomTypeFunc to the value rules run against.
+// It returns an invalid reflect.Value for null values, which are then validated like a nil interface.
+type CustomTypeFunc = func(reflect.Value) reflect.Value
+
+// nilValue stands in for the null values of custom types.
+var nilValue = reflect.Zero(reflect.TypeOf((*any)(nil)).Elem())
+
+// RegisterCustomTypeFunc makes the rules of fields and elements of the given types (or pointers to them)
+// run against the value returned by fn, so wrapper types work with builtins like required, min and max:
+//
+//	v.RegisterCustomTypeFunc(func(rv reflect.Value) reflect.Value {
+//		if ns := rv.Interface().(sql.NullString); ns.Valid {
+//			return reflect.ValueOf(ns.String)
+//		}
+//		return reflect.Value{}
+//	}, sql.NullString{})
+//
+// Registering a function for a type again replaces it.
+func (v *Validator) RegisterCustomTypeFunc(fn CustomTypeFunc, types ...any) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, typ := range types {
+		t := reflect.TypeOf(typ)
+		v.mustNotBeFrozen("RegisterCustomTypeFunc", fmt.Sprint(t))
+		v.customTypes[t] = fn
+	}
+	v.invalidate()
+}
+
+// customValue returns the value rules run against for rv: the unwrapped value when its type
+// (or the type it points to) has a registered CustomTypeFunc, rv itself otherwise.
+func (v *Validator) customValue(rv reflect.Value) reflect.Value {
+	if !rv.IsValid() || !rv.CanInterface() {
+		return rv
+	}
+	locked := v.rlock()
+	fn, ok := v.customTypes[rv.Type()]
+	if !ok && rv.Kind() == reflect.Pointer {
+		fn, ok = v.customTypes[rv.Type().Elem()]
+		if ok {
+			if rv.IsNil() {
+				v.runlock(locked)
+				return nilValue
+			}
+			rv = rv.Elem()
+		}
+	}
+	v.runlock(locked)
+	if !ok {
+		return rv
+	}
+	if out := fn(rv); out.IsValid() {
+		return out
+	}
+	return nilValue
+}
@@ -0,0 +1,30 @@
+package lakery
+
+import "sync"
+
+// defaultValidator is created on first use by the package-level functions.
+var defaultValidator = sync.OnceValue(func() *Validator {
+	return NewValidator()
+})
+
+// Default returns the validator used by the package-level functions, so simple programs don't
+// need to pass a Validator around. It is created with the default options on first use and is
+// shared by the whole program; libraries should create their own with NewValidator.
+func Default() *Validator {
+	return defaultValidator()
+}
+
+// Validate validates s with the default validator, see Validator.Validate.
+func Validate(s any) error {
+	return Default().Validate(s)
+}
+
+// Var validates a single value with the default validator, see Validator.Var.
+func Var(value any, rules string) error {
+	return Default().Var(value, rules)
+}
+
+// RegisterTag registers a tag with the default validator, see Validator.RegisterTag.
+func RegisterTag(tag string, fn TagValidationFunc) bool {
+	return Default().RegisterTag(tag, fn)
+}
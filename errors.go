@@ -0,0 +1,240 @@
+package lakery
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldError describes a single failed rule. Its message is produced by CurrentErrorFormatFunc.
+type FieldError struct {
+	// name of the struct field
+	Field string
+	// path of the failed value, e.g. "Tags[1]" for an element or "Labels[env]" for a map entry;
+	// equals Field when the rule failed for the field itself
+	Namespace string
+	// rule key which failed, e.g. "min"; empty when the rules could not be parsed
+	Tag string
+	// rule param, e.g. "3" for min=3
+	Param string
+	// value which failed validation
+	Value reflect.Value
+	// error returned by the validator
+	Err error
+
+	formatted error
+}
+
+// NewFieldError creates a FieldError whose message is produced by CurrentErrorFormatFunc, for code
+// reporting failures the way Validate does, e.g. validators generated by lakery-gen.
+func NewFieldError(fieldType reflect.StructField, namespace string, value reflect.Value, tag, param string, err error) *FieldError {
+	return &FieldError{
+		Field:     fieldType.Name,
+		Namespace: namespace,
+		Tag:       tag,
+		Param:     param,
+		Value:     value,
+		Err:       err,
+		formatted: CurrentErrorFormatFunc(fieldType, value, err),
+	}
+}
+
+// Error returns the formatted message, prefixed with the namespace for collection elements.
+func (e *FieldError) Error() string {
+	if e.Namespace != "" && e.Namespace != e.Field {
+		return e.Namespace + ": " + e.formatted.Error()
+	}
+	return e.formatted.Error()
+}
+
+// Unwrap exposes both the formatted error and the validator error to errors.Is and errors.As.
+func (e *FieldError) Unwrap() []error {
+	return []error{e.formatted, e.Err}
+}
+
+// InvalidRuleError is returned by Validate instead of a FieldError when a rule is malformed or cannot
+// be applied to its field (min=abc, min on a bool, unbalanced braces), since that is a programming error
+// rather than bad input. Validation stops at the first invalid rule, also in collect-all mode.
+// It unwraps to the FieldError describing the rule, whose Category is CategoryConfig.
+type InvalidRuleError struct {
+	*FieldError
+}
+
+func (e *InvalidRuleError) Error() string {
+	if e.Tag == "" {
+		return fmt.Sprintf("invalid rules of %s: %v", e.Namespace, e.Err)
+	}
+	r := e.Tag
+	if e.Param != "" {
+		r += "=" + e.Param
+	}
+	return fmt.Sprintf("invalid rule %s of %s: %v", r, e.Namespace, e.Err)
+}
+
+func (e *InvalidRuleError) Unwrap() error {
+	return e.FieldError
+}
+
+// Category classifies the failure, e.g. to answer with 400 for client errors and 500 otherwise.
+func (e *FieldError) Category() ErrorCategory {
+	var c *categorizedError
+	if errors.As(e.Err, &c) {
+		return c.category
+	}
+	return CategoryClient
+}
+
+// isConfigError reports whether err is marked as caused by the rule declaration, see ConfigError.
+func isConfigError(err error) bool {
+	var c *categorizedError
+	return errors.As(err, &c) && c.category == CategoryConfig
+}
+
+// CodeOneOf is the Code of a failure of alternatives like min=10|max=3, when none of them passed.
+// It is also the code of the oneof builtin, which fails for the same reason: the value matched
+// none of the allowed choices; Tag tells them apart.
+const CodeOneOf = "lakery.oneof"
+
+// Code returns a stable identifier of the failed rule which, unlike the message, never changes
+// between versions, so clients, translations and analytics can key off it: "lakery." followed by
+// the tag for builtin tags (lakery.min, lakery.required) and the schema checks of ValidateAs
+// (lakery.unknown_key), CodeOneOf for alternatives and the tag itself for custom tags. It is empty
+// when the rules could not be parsed. Builtin tags overridden with RegisterTag keep their code.
+func (e *FieldError) Code() string {
+	switch {
+	case e.Tag == "":
+		return ""
+	case strings.Contains(e.Tag, "|"):
+		return CodeOneOf
+	case builtinNames[e.Tag], schemaTags[e.Tag]:
+		return builtinCode(e.Tag)
+	default:
+		return e.Tag
+	}
+}
+
+// ErrorCategory tells whether a failure was caused by the validated value, the rule declaration or lakery itself.
+type ErrorCategory int
+
+const (
+	// the value does not satisfy a rule
+	CategoryClient ErrorCategory = iota
+	// the rule is malformed or cannot be applied to the field, e.g. min=abc or min on a bool
+	CategoryConfig
+	// a validator panicked or a dependency it relies on failed
+	CategoryInternal
+)
+
+func (c ErrorCategory) String() string {
+	switch c {
+	case CategoryClient:
+		return "client-error"
+	case CategoryConfig:
+		return "config-error"
+	case CategoryInternal:
+		return "internal-error"
+	default:
+		return fmt.Sprintf("ErrorCategory(%d)", int(c))
+	}
+}
+
+// ConfigError marks an error returned by a validator as caused by the rule declaration, see CategoryConfig.
+func ConfigError(err error) error {
+	return &categorizedError{err: err, category: CategoryConfig}
+}
+
+// InternalError marks an error returned by a validator as an internal failure, see CategoryInternal.
+func InternalError(err error) error {
+	return &categorizedError{err: err, category: CategoryInternal}
+}
+
+// categorizedError carries the category of an error without changing its message.
+type categorizedError struct {
+	err      error
+	category ErrorCategory
+}
+
+func (e *categorizedError) Error() string { return e.err.Error() }
+
+func (e *categorizedError) Unwrap() error { return e.err }
+
+func configErrorf(format string, args ...any) error {
+	return ConfigError(fmt.Errorf(format, args...))
+}
+
+// ValidationErrors aggregates all failures reported in collect-all mode, see WithCollectAll.
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Category returns the most severe category of the failures: internal over config over client.
+func (e ValidationErrors) Category() ErrorCategory {
+	category := CategoryClient
+	for _, err := range e {
+		category = max(category, err.Category())
+	}
+	return category
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ByField returns the failures keyed by their Namespace (Name, Tags[1], Address.City), keeping the
+// first failure of every path, e.g. to answer with the failure of every field.
+func (e ValidationErrors) ByField() map[string]error {
+	fields := make(map[string]error, len(e))
+	for _, err := range e {
+		if _, ok := fields[err.Namespace]; !ok {
+			fields[err.Namespace] = err
+		}
+	}
+	return fields
+}
+
+// Messages returns the messages of the failures of ByField, without the path prefix of Error, so they
+// can be encoded as a {"Tags[1]": "message"} response as is.
+func (e ValidationErrors) Messages() map[string]string {
+	msgs := make(map[string]string, len(e))
+	for _, err := range e {
+		if _, ok := msgs[err.Namespace]; !ok {
+			msgs[err.Namespace] = err.formatted.Error()
+		}
+	}
+	return msgs
+}
+
+// FieldErrors returns the failures of an error returned by Validate: the FieldError, or ValidationErrors
+// in collect-all mode. It returns nil for nil and for errors not caused by the input, such as an
+// InvalidRuleError or the error of a done context:
+//
+//	if errs := lakery.FieldErrors(err); errs != nil {
+//		w.WriteHeader(http.StatusUnprocessableEntity)
+//		json.NewEncoder(w).Encode(errs.Messages())
+//	}
+func FieldErrors(err error) ValidationErrors {
+	var invalid *InvalidRuleError
+	if err == nil || errors.As(err, &invalid) {
+		return nil
+	}
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		return errs
+	}
+	var fe *FieldError
+	if errors.As(err, &fe) {
+		return ValidationErrors{fe}
+	}
+	return nil
+}
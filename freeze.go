@@ -0,0 +1,61 @@
+package lakery
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrFrozen is the panic value (wrapped) of registration calls on a frozen validator.
+var ErrFrozen = errors.New("validator is frozen")
+
+// Freeze locks the validator for production use. Registration on a frozen validator is a
+// programming error, so like regexp.MustCompile every later Register* call (and UnregisterTag,
+// Install, OnFieldStart and OnFieldResult) panics with an error wrapping ErrFrozen instead of
+// returning one. A frozen validator never changes: lookups of tags, sets, rules and types skip
+// the validator's lock, and the rules of a struct type are compiled once and never invalidated.
+//
+//	v := lakery.NewValidator()
+//	v.RegisterTag("credential", credential)
+//	v.Freeze()
+func (v *Validator) Freeze() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.frozen.Store(true)
+}
+
+// Frozen reports whether Freeze was called.
+func (v *Validator) Frozen() bool {
+	return v.frozen.Load()
+}
+
+// mustNotBeFrozen panics when a registration is attempted on a frozen validator.
+// The caller must hold v.mu.
+func (v *Validator) mustNotBeFrozen(call, name string) {
+	if v.frozen.Load() {
+		panic(frozenError(call, name))
+	}
+}
+
+func frozenError(call, name string) error {
+	return fmt.Errorf("lakery: %s(%q): %w", call, name, ErrFrozen)
+}
+
+// rlock read-locks v.mu unless v is frozen and reports whether it did; pass the result to runlock:
+//
+//	defer v.runlock(v.rlock())
+//
+// Frozen validators are never written again, so reading them without the lock is safe.
+func (v *Validator) rlock() bool {
+	if v.frozen.Load() {
+		return false
+	}
+	v.mu.RLock()
+	return true
+}
+
+// runlock releases the read lock taken by rlock.
+func (v *Validator) runlock(locked bool) {
+	if locked {
+		v.mu.RUnlock()
+	}
+}
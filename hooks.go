@@ -0,0 +1,111 @@
+package lakery
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// FieldInfo describes a field validated by Validate, see OnFieldStart.
+type FieldInfo struct {
+	Field reflect.StructField
+	// path of the field, e.g. Addresses[1].City
+	Namespace string
+	// the rules of the field in execution order
+	Rules []string
+}
+
+// FieldResult is the outcome of validating a field, see OnFieldResult.
+type FieldResult struct {
+	FieldInfo
+	// nil when the field passed; the *FieldError of the failure, ValidationErrors for several
+	// failures in collect-all mode, an *InvalidRuleError, the error of a done context or ErrMaxDepth
+	Err      error
+	Duration time.Duration
+}
+
+// FieldStartFunc is called before the rules of a field run.
+type FieldStartFunc func(ctx context.Context, field FieldInfo)
+
+// FieldResultFunc is called after the rules of a field ran.
+type FieldResultFunc func(ctx context.Context, result FieldResult)
+
+// OnFieldStart adds a hook called before the rules of every field with rules run, so callers can
+// implement tracing, auditing or metrics without wrapping every validator:
+//
+//	v.OnFieldStart(func(ctx context.Context, f lakery.FieldInfo) {
+//		log.Printf("validating %s", f.Namespace)
+//	})
+//
+// Hooks run in the order they were added, on the goroutine calling Validate, or on the workers of
+// WithParallelEach for the fields of elements. The fields of structs validated with dive are reported
+// between the start and the result of the dive field.
+func (v *Validator) OnFieldStart(fn FieldStartFunc) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.mustNotBeFrozen("OnFieldStart", "")
+	// hooks are copied on write, so running validations keep the hooks they started with
+	v.fieldStart = append(slices.Clip(v.fieldStart), fn)
+}
+
+// OnFieldResult adds a hook called after the rules of every field with rules ran, with the
+// outcome, see OnFieldStart:
+//
+//	v.OnFieldResult(func(ctx context.Context, r lakery.FieldResult) {
+//		fieldDuration.WithLabelValues(r.Namespace).Observe(r.Duration.Seconds())
+//	})
+func (v *Validator) OnFieldResult(fn FieldResultFunc) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.mustNotBeFrozen("OnFieldResult", "")
+	v.fieldResult = append(slices.Clip(v.fieldResult), fn)
+}
+
+// fieldHooks are the hooks of a single Validate call.
+type fieldHooks struct {
+	start  []FieldStartFunc
+	result []FieldResultFunc
+}
+
+// hooks returns the hooks added so far.
+func (v *Validator) hooks() fieldHooks {
+	defer v.runlock(v.rlock())
+	return fieldHooks{start: v.fieldStart, result: v.fieldResult}
+}
+
+func (h fieldHooks) empty() bool {
+	return len(h.start) == 0 && len(h.result) == 0
+}
+
+// runRulesWithHooks runs the rules of a field like runRules, reporting them to the hooks of the call.
+func (vs *validation) runRulesWithHooks(parent reflect.Value, fieldType reflect.StructField, namespace string, value reflect.Value, rules []rule) bool {
+	info := FieldInfo{Field: fieldType, Namespace: namespace, Rules: make([]string, len(rules))}
+	for i, r := range rules {
+		info.Rules[i] = r.String()
+	}
+	ctx := vs.ctx
+	for _, fn := range vs.hooks.start {
+		fn(ctx, info)
+	}
+	failed := len(vs.errs)
+	start := time.Now()
+	ok := vs.runRules(parent, fieldType, namespace, value, rules)
+	result := FieldResult{FieldInfo: info, Duration: time.Since(start)}
+	switch errs := vs.errs[failed:]; {
+	case vs.ctxErr != nil:
+		result.Err = vs.ctxErr
+	case vs.depthErr != nil:
+		result.Err = vs.depthErr
+	case vs.invalid != nil:
+		result.Err = vs.invalid
+	case len(errs) == 1:
+		result.Err = errs[0]
+	case len(errs) > 1:
+		result.Err = slices.Clone(errs)
+	}
+	for _, fn := range vs.hooks.result {
+		fn(ctx, result)
+	}
+	return ok
+}
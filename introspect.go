@@ -0,0 +1,150 @@
+package lakery
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// FieldRules describes the effective rules of a struct field, see RulesFor.
+type FieldRules struct {
+	Field string
+	// path of the field, e.g. Address.City; elements of collections are named with [] (Items[].SKU)
+	Path string
+	// Go type of the field, e.g. []string
+	Type  string
+	Rules []RuleNode
+	// fields of embedded and inline structs; fields reached through dive are listed by the dive rule
+	Fields []FieldRules
+}
+
+// RuleNode is an effective rule of a field together with the source it came from and the rules
+// nested in it.
+type RuleNode struct {
+	Rule
+	Source RuleSource
+	// rules of each, keys and values, run on every element
+	Inner []RuleNode
+	// fields of the struct entered with dive
+	Fields []FieldRules
+}
+
+// RulesFor returns the rule tree of the struct type of s (a value or pointer): every field with
+// rules in declaration order, with aliases expanded, profile rules resolved and sources merged
+// like Validate does. Rules nested in each, keys and values and the fields of structs entered
+// with dive, embedded or declared inline are listed below their rule or field, so admin UIs and
+// documentation can be built from the rules the engine runs:
+//
+//	fields, err := v.RulesFor(api.Order{})
+//	// Items []Item `lakery:"required,each={dive}"`:
+//	// {Field: "Items", Rules: [required, each {Inner: [dive {Fields: [SKU ...]}]}]}
+//
+// Recursive types are expanded once; the fields of a struct nested in itself are left out.
+func (v *Validator) RulesFor(s any) ([]FieldRules, error) {
+	typ := reflect.TypeOf(s)
+	if typ != nil {
+		typ = structType(typ)
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("lakery: RulesFor needs a struct, got %v", typ)
+	}
+	t := &ruleTree{v: v, visiting: make(map[reflect.Type]bool)}
+	fields, err := t.fields(typ, "")
+	if err != nil {
+		return nil, fmt.Errorf("%s.%w", typ, err)
+	}
+	return fields, nil
+}
+
+// ruleTree builds the result of RulesFor from the compiled rules of struct types.
+type ruleTree struct {
+	v *Validator
+	// struct types being expanded, to stop at recursive types
+	visiting map[reflect.Type]bool
+}
+
+func (t *ruleTree) fields(typ reflect.Type, prefix string) ([]FieldRules, error) {
+	if t.visiting[typ] {
+		return nil, nil
+	}
+	t.visiting[typ] = true
+	defer delete(t.visiting, typ)
+	compiled := t.v.compiled(typ)
+	if compiled.structErr != nil {
+		return nil, fmt.Errorf("%s_: %w", prefix, compiled.structErr)
+	}
+	var fields []FieldRules
+	for i := 0; i < typ.NumField(); i++ {
+		if compiled.skip[i] {
+			continue
+		}
+		sf := typ.Field(i)
+		path := prefix + sf.Name
+		if err := compiled.errs[i]; err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		field := FieldRules{Field: sf.Name, Path: path, Type: sf.Type.String()}
+		var err error
+		if field.Rules, err = t.rules(sf.Type, path, compiled.rules[i]); err != nil {
+			return nil, err
+		}
+		if !compiled.dive[i] {
+			switch {
+			case isEmbeddedStruct(sf):
+				// promoted fields keep the path of the parent, like in errors
+				field.Fields, err = t.fields(structType(sf.Type), prefix)
+			case isInlineStruct(sf):
+				field.Fields, err = t.fields(structType(sf.Type), path+".")
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if field.Rules != nil || field.Fields != nil {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// rules converts the compiled rules of a value of type typ, nil when the type is unknown,
+// e.g. for elements of a field which is not a collection.
+func (t *ruleTree) rules(typ reflect.Type, path string, rules []rule) ([]RuleNode, error) {
+	var nodes []RuleNode
+	for _, r := range rules {
+		node := RuleNode{Rule: exportRule(r), Source: r.source}
+		var err error
+		switch {
+		case r.key == eachTag, r.key == keysTag, r.key == valuesTag:
+			if r.innerErr != nil {
+				return nil, fmt.Errorf("%s: %w", path, r.innerErr)
+			}
+			node.Inner, err = t.rules(elemType(typ, r.key), path+"[]", r.inner)
+		case r.key == diveTag && typ != nil:
+			if st := structType(typ); st.Kind() == reflect.Struct {
+				node.Fields, err = t.fields(st, path+".")
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// elemType returns the type of the elements the collection rule key runs on, nil when typ is
+// not a collection of the right kind.
+func elemType(typ reflect.Type, key string) reflect.Type {
+	if typ == nil {
+		return nil
+	}
+	switch kind := typ.Kind(); {
+	case key == eachTag && (kind == reflect.Slice || kind == reflect.Array):
+		return typ.Elem()
+	case key == keysTag && kind == reflect.Map:
+		return typ.Key()
+	case key == valuesTag && kind == reflect.Map:
+		return typ.Elem()
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+// Package lakerycsv decodes and validates tabular data, such as CSV files or spreadsheet rows,
+// into structs tagged with lakery rules.
+//
+// Columns are mapped to struct fields by header name using `csv:"name"` tags; fields without
+// a csv tag are matched by their Go name and `csv:"-"` skips a field:
+//
+//	type Contact struct {
+//		Name  string `csv:"name" lakery:"required,min=2"`
+//		Email string `csv:"email" lakery:"required"`
+//		Age   int    `csv:"age" lakery:"omitempty,min=18"`
+//	}
+//
+//	contacts, err := lakerycsv.Read[Contact](v, csv.NewReader(f))
+//
+// Errors are annotated with 1-based row and column numbers, the header row being row 1,
+// so they can be reported the way spreadsheet users see them.
+package lakerycsv
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/trofkm/lakery"
+)
+
+const tagName = "csv"
+
+// RowError is a decoding or validation failure of a single cell (or of a whole row when Column is 0).
+type RowError struct {
+	// 1-based row number, the header is row 1
+	Row int
+	// 1-based column number, 0 when the failure is not tied to a column
+	Column int
+	// header of the column, empty when Column is 0
+	Header string
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+	}
+	return fmt.Sprintf("row %d, column %d (%s): %v", e.Row, e.Column, e.Header, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Errors aggregates the failures of all rows.
+type Errors []*RowError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Read reads a header row and all records from r, decodes them into T and validates them with v.
+// It returns the valid rows; failures of the other rows are reported together as Errors.
+// Reading errors of the CSV stream itself and malformed rules of T are returned as is.
+func Read[T any](v *lakery.Validator, r *csv.Reader) ([]T, error) {
+	header, err := r.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("lakerycsv: missing header row")
+		}
+		return nil, err
+	}
+	var rows [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, record)
+	}
+	return Rows[T](v, header, rows)
+}
+
+// Rows decodes and validates rows already split into cells, e.g. read from a spreadsheet.
+// Rows are numbered from 2, right after the header. A malformed rule of T fails every row alike, so
+// Rows stops at the first *lakery.InvalidRuleError and returns it without any rows.
+func Rows[T any](v *lakery.Validator, header []string, rows [][]string) ([]T, error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("lakerycsv: can only decode into structs, got %v", typ)
+	}
+	columns := mapColumns(typ, header)
+
+	var out []T
+	var errs Errors
+	for i, record := range rows {
+		row := i + 2
+		var t T
+		rv := reflect.ValueOf(&t).Elem()
+		decoded := true
+		for col, cell := range record {
+			field, ok := columns[col]
+			if !ok {
+				continue
+			}
+			if err := setCell(rv.FieldByIndex(field.Index), cell); err != nil {
+				errs = append(errs, &RowError{Row: row, Column: col + 1, Header: header[col], Err: err})
+				decoded = false
+			}
+		}
+		if !decoded {
+			continue
+		}
+		if err := v.Validate(&t); err != nil {
+			var invalid *lakery.InvalidRuleError
+			if errors.As(err, &invalid) {
+				return nil, err
+			}
+			errs = append(errs, rowErrors(row, header, columns, err)...)
+			continue
+		}
+		out = append(out, t)
+	}
+	if len(errs) > 0 {
+		return out, errs
+	}
+	return out, nil
+}
+
+// mapColumns maps column indexes to the struct fields they decode into.
+func mapColumns(typ reflect.Type, header []string) map[int]reflect.StructField {
+	byName := make(map[string]reflect.StructField)
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := sf.Name
+		if tag, ok := sf.Tag.Lookup(tagName); ok {
+			name, _, _ = strings.Cut(tag, ",")
+		}
+		if name == "-" {
+			continue
+		}
+		byName[name] = sf
+	}
+	columns := make(map[int]reflect.StructField)
+	for col, name := range header {
+		if sf, ok := byName[strings.TrimSpace(name)]; ok {
+			columns[col] = sf
+		}
+	}
+	return columns
+}
+
+// rowErrors annotates the validation errors of a row with the columns of the failed fields.
+func rowErrors(row int, header []string, columns map[int]reflect.StructField, err error) []*RowError {
+	var fieldErrs lakery.ValidationErrors
+	var fieldErr *lakery.FieldError
+	switch {
+	case errors.As(err, &fieldErrs):
+	case errors.As(err, &fieldErr):
+		fieldErrs = lakery.ValidationErrors{fieldErr}
+	default:
+		return []*RowError{{Row: row, Err: err}}
+	}
+	out := make([]*RowError, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		re := &RowError{Row: row, Err: fe}
+		for col, sf := range columns {
+			if sf.Name == rootField(fe) {
+				re.Column, re.Header = col+1, header[col]
+				break
+			}
+		}
+		out = append(out, re)
+	}
+	return out
+}
+
+// rootField returns the top-level struct field of a failure, e.g. Tags for Tags[1].
+func rootField(fe *lakery.FieldError) string {
+	if fe.Namespace == "" {
+		return fe.Field
+	}
+	if i := strings.IndexAny(fe.Namespace, "[."); i >= 0 {
+		return fe.Namespace[:i]
+	}
+	return fe.Namespace
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setCell parses a cell into a field. Empty cells leave the field at its zero value.
+func setCell(f reflect.Value, cell string) error {
+	if cell == "" {
+		return nil
+	}
+	if f.Addr().Type().Implements(textUnmarshalerType) {
+		return f.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(cell))
+	}
+	switch f.Kind() {
+	case reflect.Pointer:
+		p := reflect.New(f.Type().Elem())
+		if err := setCell(p.Elem(), cell); err != nil {
+			return err
+		}
+		f.Set(p)
+	case reflect.String:
+		f.SetString(cell)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as bool", cell)
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(cell, 10, f.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as integer", cell)
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(cell, 10, f.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as unsigned integer", cell)
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(cell, f.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as number", cell)
+		}
+		f.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", f.Type())
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package lakerycsv_test
+
+import (
+	"encoding/csv"
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/trofkm/lakery"
+	"github.com/trofkm/lakery/lakerycsv"
+)
+
+type contact struct {
+	Name     string    `csv:"name" lakery:"required,min=2"`
+	Email    string    `csv:"email" lakery:"required"`
+	Age      *int      `csv:"age" lakery:"omitempty,min=18"`
+	Joined   time.Time `csv:"joined"`
+	Internal string    `csv:"-"`
+}
+
+func read(v *lakery.Validator, data string) ([]contact, error) {
+	return lakerycsv.Read[contact](v, csv.NewReader(strings.NewReader(data)))
+}
+
+var _ = Describe("lakerycsv", func() {
+	It("decodes and validates rows", func() {
+		rows, err := read(lakery.NewValidator(), "name,email,age,joined\n"+
+			"Ann,ann@example.com,30,2024-01-02T00:00:00Z\n"+
+			"Bob,bob@example.com,,\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rows).To(HaveLen(2))
+		Expect(*rows[0].Age).To(Equal(30))
+		Expect(rows[0].Joined.Year()).To(Equal(2024))
+		Expect(rows[1].Age).To(BeNil())
+	})
+
+	It("maps columns by header regardless of their order", func() {
+		rows, err := read(lakery.NewValidator(), "email,extra,name\nann@example.com,x,Ann\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rows).To(Equal([]contact{{Name: "Ann", Email: "ann@example.com"}}))
+	})
+
+	It("annotates validation errors with row and column", func() {
+		rows, err := read(lakery.NewValidator(lakery.WithCollectAll()), "name,email,age\n"+
+			"Ann,ann@example.com,30\n"+
+			"B,,16\n")
+		Expect(rows).To(HaveLen(1))
+		var errs lakerycsv.Errors
+		Expect(errors.As(err, &errs)).To(BeTrue())
+		Expect(errs).To(HaveLen(3))
+		Expect(errs[0].Row).To(Equal(3))
+		Expect(errs[0].Column).To(Equal(1))
+		Expect(errs[0].Header).To(Equal("name"))
+		Expect(errs[1].Column).To(Equal(2))
+		Expect(errs[2].Column).To(Equal(3))
+		Expect(err.Error()).To(ContainSubstring("row 3, column 1 (name): "))
+
+		var fe *lakery.FieldError
+		Expect(errors.As(errs[1], &fe)).To(BeTrue())
+		Expect(fe.Tag).To(Equal("required"))
+	})
+
+	It("reports cells which cannot be decoded", func() {
+		_, err := read(lakery.NewValidator(), "name,email,age\nAnn,ann@example.com,old\n")
+		Expect(err).To(MatchError(`row 2, column 3 (age): cannot parse "old" as integer`))
+	})
+
+	It("validates rows read from other sources", func() {
+		rows, err := lakerycsv.Rows[contact](lakery.NewValidator(),
+			[]string{"name", "email"},
+			[][]string{{"Ann", "ann@example.com"}, {"Bob", ""}})
+		Expect(rows).To(HaveLen(1))
+		Expect(err).To(MatchError(ContainSubstring("row 3, column 2 (email)")))
+	})
+
+	It("returns a malformed rule once instead of per row", func() {
+		type badRule struct {
+			Name string `csv:"name" lakery:"min=abc"`
+		}
+		rows, err := lakerycsv.Rows[badRule](lakery.NewValidator(lakery.WithCollectAll()),
+			[]string{"name"},
+			[][]string{{"Ann"}, {"Bob"}, {"Cid"}})
+		Expect(rows).To(BeNil())
+		var invalid *lakery.InvalidRuleError
+		Expect(errors.As(err, &invalid)).To(BeTrue())
+		var errs lakerycsv.Errors
+		Expect(errors.As(err, &errs)).To(BeFalse())
+	})
+
+	It("requires a header row", func() {
+		_, err := read(lakery.NewValidator(), "")
+		Expect(err).To(MatchError(ContainSubstring("missing header row")))
+	})
+})
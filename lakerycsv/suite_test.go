@@ -0,0 +1,13 @@
+package lakerycsv_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLakeryCSV(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "lakerycsv Suite")
+}
@@ -0,0 +1,51 @@
+// Package lakerydecimal registers shopspring/decimal and math/big types as numbers, so min, max,
+// money, percent, ratio and inrange work on them without the core module depending on decimal:
+//
+//	v := lakery.NewValidator()
+//	lakerydecimal.Register(v)
+//
+//	type Order struct {
+//		Total decimal.Decimal `lakery:"min=1,money=2"`
+//		Units *big.Int        `lakery:"max=1000"`
+//	}
+package lakerydecimal
+
+import (
+	"math/big"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/trofkm/lakery"
+)
+
+// Register registers decimal.Decimal, big.Int, big.Float and big.Rat (and pointers to them) with v.
+// Infinite big.Float values fail numeric rules.
+func Register(v *lakery.Validator) {
+	v.RegisterNumberType(decimal.Decimal{}, decimalNumber)
+	v.RegisterNumberType(big.Int{}, intNumber)
+	v.RegisterNumberType(big.Float{}, floatNumber)
+	v.RegisterNumberType(big.Rat{}, ratNumber)
+}
+
+func decimalNumber(x any) (*big.Rat, bool) {
+	return x.(decimal.Decimal).Rat(), true
+}
+
+func intNumber(x any) (*big.Rat, bool) {
+	n := x.(big.Int)
+	return new(big.Rat).SetInt(&n), true
+}
+
+func floatNumber(x any) (*big.Rat, bool) {
+	f := x.(big.Float)
+	if f.IsInf() {
+		return nil, false
+	}
+	r, _ := f.Rat(nil)
+	return r, true
+}
+
+func ratNumber(x any) (*big.Rat, bool) {
+	r := x.(big.Rat)
+	return new(big.Rat).Set(&r), true
+}
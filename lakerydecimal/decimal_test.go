@@ -0,0 +1,66 @@
+package lakerydecimal_test
+
+import (
+	"math"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shopspring/decimal"
+
+	"github.com/trofkm/lakery"
+	"github.com/trofkm/lakery/lakerydecimal"
+)
+
+var _ = Describe("Register", func() {
+	var v *lakery.Validator
+
+	BeforeEach(func() {
+		v = lakery.NewValidator()
+		lakerydecimal.Register(v)
+	})
+
+	Context("decimal.Decimal", func() {
+		type Order struct {
+			Total    decimal.Decimal  `lakery:"min=1,max=100,money=2"`
+			Discount *decimal.Decimal `lakery:"percent"`
+		}
+
+		It("passes valid amounts", func() {
+			d := decimal.RequireFromString("12.5")
+			Expect(v.Validate(Order{Total: decimal.RequireFromString("99.99"), Discount: &d})).To(Succeed())
+		})
+
+		It("fails amounts out of bounds", func() {
+			Expect(v.Validate(Order{Total: decimal.RequireFromString("0.99")})).To(MatchError(ContainSubstring("should be >= 1")))
+			Expect(v.Validate(Order{Total: decimal.RequireFromString("100.01")})).To(MatchError(ContainSubstring("should be <= 100")))
+			d := decimal.NewFromInt(101)
+			Expect(v.Validate(Order{Total: decimal.NewFromInt(1), Discount: &d})).To(MatchError(ContainSubstring("between 0 and 100")))
+		})
+
+		It("fails amounts with too many decimal places", func() {
+			Expect(v.Validate(Order{Total: decimal.RequireFromString("10.005")})).To(MatchError(ContainSubstring("at most 2 decimal places")))
+		})
+	})
+
+	Context("math/big", func() {
+		type Ledger struct {
+			Units *big.Int  `lakery:"inrange=1:1000"`
+			Rate  big.Float `lakery:"ratio"`
+			Share *big.Rat  `lakery:"max=1"`
+		}
+
+		It("passes valid numbers", func() {
+			Expect(v.Validate(Ledger{Units: big.NewInt(10), Rate: *big.NewFloat(0.5), Share: big.NewRat(1, 3)})).To(Succeed())
+		})
+
+		It("fails invalid numbers", func() {
+			Expect(v.Validate(Ledger{Units: big.NewInt(1001), Rate: *big.NewFloat(0.5)})).To(MatchError(ContainSubstring("in range 1:1000")))
+			Expect(v.Validate(Ledger{Units: big.NewInt(1), Rate: *big.NewFloat(0.5), Share: big.NewRat(4, 3)})).To(MatchError(ContainSubstring("should be <= 1")))
+		})
+
+		It("fails infinite floats", func() {
+			Expect(v.Validate(Ledger{Units: big.NewInt(1), Rate: *big.NewFloat(math.Inf(1))})).To(MatchError(ContainSubstring("finite number")))
+		})
+	})
+})
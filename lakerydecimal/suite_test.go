@@ -0,0 +1,13 @@
+package lakerydecimal_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLakeryDecimal(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "lakerydecimal Suite")
+}
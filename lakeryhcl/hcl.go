@@ -0,0 +1,176 @@
+// Package lakeryhcl validates configuration structs decoded by gohcl and reports failures
+// as HCL diagnostics pointing at the offending attribute or block, so tools built on lakery
+// print errors with the file and line users need to fix:
+//
+//	var cfg Config
+//	diags := gohcl.DecodeBody(file.Body, nil, &cfg)
+//	diags = append(diags, lakeryhcl.Validate(v, file.Body, &cfg)...)
+package lakeryhcl
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+
+	"github.com/trofkm/lakery"
+)
+
+const tagName = "hcl"
+
+// Validate validates s, which was decoded from body by gohcl, and returns one error diagnostic
+// per failed rule. Diagnostics point at the attribute or block named by the FieldError namespace;
+// when it cannot be resolved (e.g. for JSON bodies) they point at the closest enclosing block.
+// Errors other than validation errors, e.g. a canceled context, are reported without a subject.
+func Validate(v *lakery.Validator, body hcl.Body, s any) hcl.Diagnostics {
+	err := v.Validate(s)
+	if err == nil {
+		return nil
+	}
+	var fieldErrs lakery.ValidationErrors
+	var fieldErr *lakery.FieldError
+	switch {
+	case errors.As(err, &fieldErrs):
+	case errors.As(err, &fieldErr):
+		fieldErrs = lakery.ValidationErrors{fieldErr}
+	default:
+		return hcl.Diagnostics{{Severity: hcl.DiagError, Summary: "Validation failed", Detail: err.Error()}}
+	}
+	typ := reflect.TypeOf(s)
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	diags := make(hcl.Diagnostics, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		rng := Range(body, typ, namespace(fe))
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid value",
+			Detail:   fe.Error(),
+			Subject:  &rng,
+		})
+	}
+	return diags
+}
+
+func namespace(fe *lakery.FieldError) string {
+	if fe.Namespace != "" {
+		return fe.Namespace
+	}
+	return fe.Field
+}
+
+// Range returns the source range of the value addressed by a FieldError namespace like
+// Listener[1].Port in body, which was decoded into a struct of type typ.
+func Range(body hcl.Body, typ reflect.Type, namespace string) hcl.Range {
+	syntaxBody, ok := body.(*hclsyntax.Body)
+	if !ok {
+		return body.MissingItemRange()
+	}
+	return resolve(syntaxBody, nil, typ, segments(namespace))
+}
+
+// segment is a field name with the indexes following it, e.g. Listener[1].
+type segment struct {
+	field   string
+	indexes []string
+}
+
+func segments(namespace string) []segment {
+	var out []segment
+	for _, part := range strings.Split(namespace, ".") {
+		name, rest, _ := strings.Cut(part, "[")
+		seg := segment{field: name}
+		for rest != "" {
+			var index string
+			index, rest, _ = strings.Cut(rest, "]")
+			seg.indexes = append(seg.indexes, index)
+			rest = strings.TrimPrefix(rest, "[")
+		}
+		out = append(out, seg)
+	}
+	return out
+}
+
+// resolve walks the path through body, the body of block (nil for the top level).
+func resolve(body *hclsyntax.Body, block *hclsyntax.Block, typ reflect.Type, path []segment) hcl.Range {
+	fallback := body.SrcRange
+	if block != nil {
+		fallback = block.DefRange()
+	}
+	if len(path) == 0 || typ.Kind() != reflect.Struct {
+		return fallback
+	}
+	seg := path[0]
+	sf, ok := typ.FieldByName(seg.field)
+	if !ok {
+		return fallback
+	}
+	name, kind, _ := strings.Cut(sf.Tag.Get(tagName), ",")
+	switch kind {
+	case "label":
+		if block == nil {
+			return fallback
+		}
+		if i := labelIndex(typ, sf.Name); i < len(block.LabelRanges) {
+			return block.LabelRanges[i]
+		}
+		return fallback
+	case "block":
+		var blocks []*hclsyntax.Block
+		for _, b := range body.Blocks {
+			if b.Type == name {
+				blocks = append(blocks, b)
+			}
+		}
+		elem := sf.Type
+		i := 0
+		if elem.Kind() == reflect.Slice {
+			elem = elem.Elem()
+			if len(seg.indexes) == 0 {
+				if len(blocks) > 0 {
+					return blocks[0].DefRange()
+				}
+				return fallback
+			}
+			n, err := strconv.Atoi(seg.indexes[0])
+			if err != nil {
+				return fallback
+			}
+			i = n
+		}
+		for elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		if i >= len(blocks) {
+			return fallback
+		}
+		return resolve(blocks[i].Body, blocks[i], elem, path[1:])
+	case "remain":
+		return body.SrcRange
+	default:
+		// attributes: "name", "name,attr" and "name,optional"
+		if attr, ok := body.Attributes[name]; ok {
+			return attr.Expr.Range()
+		}
+		return fallback
+	}
+}
+
+// labelIndex returns the position of a label field among the label fields of typ.
+func labelIndex(typ reflect.Type, field string) int {
+	i := 0
+	for j := 0; j < typ.NumField(); j++ {
+		sf := typ.Field(j)
+		if sf.Name == field {
+			return i
+		}
+		if _, kind, _ := strings.Cut(sf.Tag.Get(tagName), ","); kind == "label" {
+			i++
+		}
+	}
+	return i
+}
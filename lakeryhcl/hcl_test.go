@@ -0,0 +1,95 @@
+package lakeryhcl_test
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/trofkm/lakery"
+	"github.com/trofkm/lakery/lakeryhcl"
+)
+
+type listener struct {
+	Name string `hcl:"name,label" lakery:"min=3"`
+	Port int    `hcl:"port" lakery:"inrange=1024:65535"`
+}
+
+type config struct {
+	Region    string     `hcl:"region" lakery:"required,min=4"`
+	Listeners []listener `hcl:"listener,block" lakery:"each={dive}"`
+	Database  *database  `hcl:"database,block" lakery:"dive"`
+	Rest      hcl.Body   `hcl:",remain"`
+}
+
+type database struct {
+	URL string `hcl:"url" lakery:"required,urlnocreds"`
+}
+
+const src = `
+region = "eu"
+
+listener "http" {
+  port = 8080
+}
+
+listener "grpc" {
+  port = 80
+}
+
+database {
+  url = "postgres://admin:secret@db/app"
+}
+`
+
+func decode(src string) (*hcl.File, *config) {
+	file, diags := hclsyntax.ParseConfig([]byte(src), "main.hcl", hcl.InitialPos)
+	Expect(diags.HasErrors()).To(BeFalse(), diags.Error())
+	var cfg config
+	diags = gohcl.DecodeBody(file.Body, nil, &cfg)
+	Expect(diags.HasErrors()).To(BeFalse(), diags.Error())
+	return file, &cfg
+}
+
+var _ = Describe("Validate", func() {
+	It("reports failures at their attribute, block and label ranges", func() {
+		file, cfg := decode(src)
+		diags := lakeryhcl.Validate(lakery.NewValidator(lakery.WithCollectAll()), file.Body, cfg)
+		Expect(diags).To(HaveLen(3))
+		for _, d := range diags {
+			Expect(d.Severity).To(Equal(hcl.DiagError))
+			Expect(d.Subject.Filename).To(Equal("main.hcl"))
+		}
+		Expect(diags[0].Subject.Start.Line).To(Equal(2))
+		Expect(diags[0].Detail).To(ContainSubstring("Region"))
+		Expect(diags[1].Subject.Start.Line).To(Equal(9))
+		Expect(diags[1].Detail).To(HavePrefix("Listeners[1].Port: "))
+		Expect(diags[2].Subject.Start.Line).To(Equal(13))
+		Expect(diags[2].Detail).To(ContainSubstring("credentials"))
+	})
+
+	It("points at block labels", func() {
+		file, cfg := decode(`
+region = "europe"
+listener "ws" {
+  port = 8080
+}
+`)
+		diags := lakeryhcl.Validate(lakery.NewValidator(), file.Body, cfg)
+		Expect(diags).To(HaveLen(1))
+		Expect(diags[0].Subject.Start.Line).To(Equal(3))
+		Expect(string(diags[0].Subject.SliceBytes([]byte("\nregion = \"europe\"\nlistener \"ws\" {\n")))).To(Equal(`"ws"`))
+	})
+
+	It("returns nothing for valid configs", func() {
+		file, cfg := decode(`
+region = "europe"
+listener "http" {
+  port = 8080
+}
+`)
+		Expect(lakeryhcl.Validate(lakery.NewValidator(), file.Body, cfg)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,13 @@
+package lakeryhcl_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLakeryHCL(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "lakeryhcl Suite")
+}
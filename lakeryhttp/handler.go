@@ -0,0 +1,41 @@
+// Package lakeryhttp exposes lakery validation contracts over HTTP.
+package lakeryhttp
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/trofkm/lakery"
+)
+
+// RulesHandler returns an http.Handler serving the rule manifest of the given struct types
+// as JSON, so clients and QA can fetch the validation contracts of a running service:
+//
+//	http.Handle("/debug/lakery/rules", lakeryhttp.RulesHandler(v, api.User{}, api.Order{}))
+//
+// The manifest is built on every request and has the format written by lakery.WriteManifest.
+// Only GET and HEAD are allowed.
+func RulesHandler(v *lakery.Validator, types ...any) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		m, err := v.Manifest(types...)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var buf bytes.Buffer
+		if err := lakery.WriteManifest(&buf, m); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodHead {
+			return
+		}
+		_, _ = w.Write(buf.Bytes())
+	})
+}
@@ -0,0 +1,58 @@
+package lakeryhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/trofkm/lakery"
+	"github.com/trofkm/lakery/lakeryhttp"
+)
+
+type user struct {
+	Name  string `lakery:"required,min=2"`
+	Email string
+}
+
+var _ = Describe("RulesHandler", func() {
+	It("serves the rule manifest as JSON", func() {
+		h := lakeryhttp.RulesHandler(lakery.NewValidator(), user{})
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rules", nil))
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+		m, err := lakery.ReadManifest(rec.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Version).To(Equal(lakery.ManifestVersion))
+		Expect(m.Types).To(Equal([]lakery.ManifestType{{
+			Name:   "lakeryhttp_test.user",
+			Fields: []lakery.ManifestField{{Name: "Name", Rules: []string{"required", "min=2"}}},
+		}}))
+	})
+
+	It("answers HEAD without a body", func() {
+		h := lakeryhttp.RulesHandler(lakery.NewValidator(), user{})
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/rules", nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.Len()).To(BeZero())
+	})
+
+	It("rejects other methods", func() {
+		h := lakeryhttp.RulesHandler(lakery.NewValidator(), user{})
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/rules", nil))
+		Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+		Expect(rec.Header().Get("Allow")).To(Equal("GET, HEAD"))
+	})
+
+	It("reports non-struct types", func() {
+		h := lakeryhttp.RulesHandler(lakery.NewValidator(), 42)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rules", nil))
+		Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+	})
+})
@@ -0,0 +1,13 @@
+package lakeryhttp_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLakeryHTTP(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "lakeryhttp Suite")
+}
@@ -0,0 +1,156 @@
+// Package lakerylimits registers request-shaping rules, so API gateways can enforce abuse limits
+// on decoded payloads with tags:
+//
+//	v := lakery.NewValidator()
+//	lakerylimits.Register(v)
+//
+//	type Search struct {
+//		Query   string   `lakery:"maxbytes=4096,maxstrings=64"`
+//		Filters []Filter `lakery:"maxbytes=4096,maxelems=100,each={dive}"`
+//	}
+//
+// Unlike max, the limits are not checked per field: every field carrying a rule adds its share to a
+// total kept for the whole Validate call, including the fields of nested structs validated with dive,
+// and the first field pushing the total over the limit fails. Tag either the leaves or the containers
+// holding them, not both, or their data is counted twice. The totals are shared by all fields carrying
+// the same tag, so use the same limit on all of them; RegisterTypeRules applies a limit to every field
+// of a type without tagging each. ValidateMap validates every key as a separate call.
+package lakerylimits
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/trofkm/lakery"
+)
+
+const (
+	// maxbytes=N limits the total size of the data in bytes
+	MaxBytesTag = "maxbytes"
+	// maxelems=N limits the total number of slice, array and map elements
+	MaxElemsTag = "maxelems"
+	// maxstrings=N limits the total number of strings
+	MaxStringsTag = "maxstrings"
+)
+
+// Register registers maxbytes, maxelems and maxstrings with v.
+func Register(v *lakery.Validator) {
+	v.RegisterTag(MaxBytesTag, limit(MaxBytesTag, "bytes", payloadBytes))
+	v.RegisterTag(MaxElemsTag, limit(MaxElemsTag, "elements", elems))
+	v.RegisterTag(MaxStringsTag, limit(MaxStringsTag, "strings", stringCount))
+}
+
+// total is the key of the running total of a tag stored in the state of a Validate call.
+type total string
+
+// limit returns a validator adding measure of the value to the total of tag and failing when it exceeds the param.
+func limit(tag, unit string, measure func(rv reflect.Value) int64) lakery.TagValidationFunc {
+	return func(val *lakery.Value) error {
+		n, err := strconv.ParseInt(val.Param(), 10, 64)
+		if err != nil || n < 0 {
+			return lakery.ConfigError(fmt.Errorf("%s expects a non-negative integer param", tag))
+		}
+		var size int64
+		if !val.IsNil() {
+			size = measure(reflect.ValueOf(val.Interface()))
+		}
+		sum := val.Update(total(tag), func(prev any) any {
+			sum, _ := prev.(int64)
+			return sum + size
+		}).(int64)
+		if sum > n {
+			return fmt.Errorf("exceeds the limit of %d %s per request", n, unit)
+		}
+		return nil
+	}
+}
+
+// payloadBytes returns the size of the data of a value: the length of strings, the memory size of
+// other scalars, and the sizes of the keys, elements and fields of collections and structs.
+func payloadBytes(rv reflect.Value) int64 {
+	var size int64
+	walk(rv, func(rv reflect.Value) bool {
+		switch rv.Kind() {
+		case reflect.String:
+			size += int64(rv.Len())
+		case reflect.Slice, reflect.Array:
+			if rv.Type().Elem().Kind() == reflect.Uint8 {
+				size += int64(rv.Len())
+				return false
+			}
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+			size += int64(rv.Type().Size())
+		}
+		return true
+	})
+	return size
+}
+
+// elems returns the number of slice, array and map elements of a value, including nested ones.
+func elems(rv reflect.Value) int64 {
+	var n int64
+	walk(rv, func(rv reflect.Value) bool {
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			n += int64(rv.Len())
+		}
+		return true
+	})
+	return n
+}
+
+// stringCount returns the number of strings of a value, including the ones in collections and structs.
+func stringCount(rv reflect.Value) int64 {
+	var n int64
+	walk(rv, func(rv reflect.Value) bool {
+		if rv.Kind() == reflect.String {
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// walk calls visit for a value and, while visit returns true, for the values it holds, following
+// pointers and interfaces. Pointers already visited are skipped, so cyclic data terminates.
+func walk(rv reflect.Value, visit func(reflect.Value) bool) {
+	seen := make(map[uintptr]bool)
+	var walkValue func(rv reflect.Value)
+	walkValue = func(rv reflect.Value) {
+		for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+			if rv.IsNil() {
+				return
+			}
+			if rv.Kind() == reflect.Pointer {
+				if seen[rv.Pointer()] {
+					return
+				}
+				seen[rv.Pointer()] = true
+			}
+			rv = rv.Elem()
+		}
+		if !rv.IsValid() || !visit(rv) {
+			return
+		}
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				walkValue(rv.Index(i))
+			}
+		case reflect.Map:
+			iter := rv.MapRange()
+			for iter.Next() {
+				walkValue(iter.Key())
+				walkValue(iter.Value())
+			}
+		case reflect.Struct:
+			for i := 0; i < rv.NumField(); i++ {
+				walkValue(rv.Field(i))
+			}
+		}
+	}
+	walkValue(rv)
+}
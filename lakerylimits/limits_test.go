@@ -0,0 +1,116 @@
+package lakerylimits_test
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/trofkm/lakery"
+	"github.com/trofkm/lakery/lakerylimits"
+)
+
+var _ = Describe("Register", func() {
+	var v *lakery.Validator
+
+	BeforeEach(func() {
+		v = lakery.NewValidator()
+		lakerylimits.Register(v)
+	})
+
+	Context("maxbytes", func() {
+		type Filter struct {
+			Field string `lakery:"maxbytes=16"`
+			Value string `lakery:"maxbytes=16"`
+		}
+		type Search struct {
+			Query   string   `lakery:"maxbytes=16"`
+			Filters []Filter `lakery:"each={dive}"`
+		}
+
+		It("passes payloads within the limit", func() {
+			Expect(v.Validate(Search{Query: "shoes", Filters: []Filter{{Field: "size", Value: "42"}}})).To(Succeed())
+		})
+
+		It("sums the fields of the whole struct", func() {
+			err := v.Validate(Search{Query: "shoes", Filters: []Filter{{Field: "size", Value: "42"}, {Field: "color", Value: "red"}}})
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Tag).To(Equal(lakerylimits.MaxBytesTag))
+			Expect(fe.Namespace).To(Equal("Filters[1].Value"))
+			Expect(err).To(MatchError(ContainSubstring("exceeds the limit of 16 bytes per request")))
+		})
+
+		It("measures containers", func() {
+			type Upload struct {
+				Data  []byte         `lakery:"maxbytes=8"`
+				Attrs map[string]int `lakery:"maxbytes=8"`
+			}
+			Expect(v.Validate(Upload{Data: []byte("1234")})).To(Succeed())
+			Expect(v.Validate(Upload{Data: []byte("1234"), Attrs: map[string]int{"a": 1}})).To(MatchError(ContainSubstring("exceeds the limit")))
+		})
+
+		It("sums elements validated by parallel workers", func() {
+			type Batch struct {
+				Names []string `lakery:"each={maxbytes=20000}"`
+			}
+			v := lakery.NewValidator(lakery.WithParallelEach(8))
+			lakerylimits.Register(v)
+			names := make([]string, 20000)
+			for i := range names {
+				names[i] = "x"
+			}
+			Expect(v.Validate(Batch{Names: names})).To(Succeed())
+			names = append(names, "x")
+			Expect(v.Validate(Batch{Names: names})).To(MatchError(ContainSubstring("exceeds the limit of 20000 bytes")))
+		})
+
+		It("keeps the totals of separate calls apart", func() {
+			s := Search{Query: strings.Repeat("x", 16)}
+			Expect(v.Validate(s)).To(Succeed())
+			Expect(v.Validate(s)).To(Succeed())
+		})
+	})
+
+	Context("maxelems", func() {
+		It("counts the elements of all collections", func() {
+			type Batch struct {
+				IDs    []int               `lakery:"maxelems=4"`
+				Groups map[string][]string `lakery:"maxelems=4"`
+			}
+			Expect(v.Validate(Batch{IDs: []int{1, 2}, Groups: map[string][]string{"a": {"x"}}})).To(Succeed())
+			Expect(v.Validate(Batch{IDs: []int{1, 2}, Groups: map[string][]string{"a": {"x", "y"}}})).To(MatchError(ContainSubstring("4 elements")))
+		})
+	})
+
+	Context("maxstrings", func() {
+		type Name string
+		type Profile struct {
+			First Name
+			Last  Name
+			Tags  []string `lakery:"maxstrings=3"`
+		}
+
+		It("counts strings of fields with type rules", func() {
+			v.RegisterTypeRules(Name(""), "maxstrings=3")
+			Expect(v.Validate(Profile{First: "a", Last: "b", Tags: []string{"x"}})).To(Succeed())
+			Expect(v.Validate(Profile{First: "a", Last: "b", Tags: []string{"x", "y"}})).To(MatchError(ContainSubstring("3 strings")))
+		})
+	})
+
+	It("ignores nil values", func() {
+		type S struct {
+			Tags *[]string `lakery:"maxelems=0"`
+		}
+		Expect(v.Validate(S{})).To(Succeed())
+	})
+
+	It("reports invalid params", func() {
+		type S struct {
+			Name string `lakery:"maxbytes=lots"`
+		}
+		var ire *lakery.InvalidRuleError
+		Expect(errors.As(v.Validate(S{}), &ire)).To(BeTrue())
+	})
+})
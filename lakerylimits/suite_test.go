@@ -0,0 +1,13 @@
+package lakerylimits_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLakeryLimits(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "lakerylimits Suite")
+}
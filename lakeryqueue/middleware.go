@@ -0,0 +1,118 @@
+// Package lakeryqueue validates queue message payloads (Kafka, NATS, SQS, ...) before
+// they reach the consumer handler.
+//
+// Consumers adapt their client's message type to Message and wrap their handler:
+//
+//	handler := lakeryqueue.Middleware[OrderCreated](v,
+//		lakeryqueue.WithDeadLetter(publishToDLQ),
+//	)(handleOrder)
+//
+//	func handleOrder(ctx context.Context, msg *lakeryqueue.Message) error {
+//		order := msg.Payload.(*OrderCreated)
+//		...
+//	}
+package lakeryqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/trofkm/lakery"
+)
+
+// ErrInvalidMessage is wrapped by the errors of messages failing decoding or validation.
+var ErrInvalidMessage = errors.New("invalid message")
+
+// Message is a queue message independent of the client library.
+type Message struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+	// decoded and validated payload, set by Middleware as a pointer to the payload type
+	Payload any
+}
+
+// Handler processes a message. Returning an error usually makes the consumer retry it.
+type Handler func(ctx context.Context, msg *Message) error
+
+// DecodeFunc decodes a message value into the payload pointed to by v.
+type DecodeFunc func(data []byte, v any) error
+
+// DeadLetterFunc handles a message which failed decoding or validation. Its result is returned
+// by the middleware instead of calling the next handler: return nil to acknowledge the message
+// once it was published to a dead-letter topic, or an error to make the consumer retry.
+type DeadLetterFunc func(ctx context.Context, msg *Message, err error) error
+
+type config struct {
+	decode     DecodeFunc
+	deadLetter DeadLetterFunc
+}
+
+// Option configures Middleware.
+type Option func(*config)
+
+// WithDecoder replaces the JSON decoder of message values, e.g. with a protobuf or Avro decoder.
+func WithDecoder(decode DecodeFunc) Option {
+	return func(c *config) {
+		c.decode = decode
+	}
+}
+
+// WithDeadLetter sets how invalid messages are handled. By default their error is returned.
+func WithDeadLetter(deadLetter DeadLetterFunc) Option {
+	return func(c *config) {
+		c.deadLetter = deadLetter
+	}
+}
+
+// Middleware decodes message values into T, validates them with v and stores the result in
+// Message.Payload as *T before calling next. Messages which cannot be decoded or break rules are
+// passed to the dead-letter handler with an error wrapping ErrInvalidMessage and the decoding or
+// validation error. Errors which are not the message's fault, like an *InvalidRuleError, an internal
+// error of a validator (e.g. a remote check failing closed) or a done context, are returned as is
+// so the consumer retries the message.
+func Middleware[T any](v *lakery.Validator, opts ...Option) func(next Handler) Handler {
+	c := &config{
+		decode: json.Unmarshal,
+		deadLetter: func(_ context.Context, _ *Message, err error) error {
+			return err
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			payload := new(T)
+			if err := c.decode(msg.Value, payload); err != nil {
+				return c.deadLetter(ctx, msg, fmt.Errorf("%w: cannot decode: %w", ErrInvalidMessage, err))
+			}
+			if err := v.ValidateCtx(ctx, payload); err != nil {
+				if ctxErr := ctx.Err(); (ctxErr != nil && errors.Is(err, ctxErr)) || !isInvalidMessage(err) {
+					// not the message's fault, let the consumer retry
+					return err
+				}
+				return c.deadLetter(ctx, msg, fmt.Errorf("%w: %w", ErrInvalidMessage, err))
+			}
+			msg.Payload = payload
+			return next(ctx, msg)
+		}
+	}
+}
+
+// isInvalidMessage reports whether a validation error is caused by the message: failures of rules
+// (CategoryClient) or a payload nested deeper than the max depth of the validator.
+func isInvalidMessage(err error) bool {
+	var errs lakery.ValidationErrors
+	if errors.As(err, &errs) {
+		return errs.Category() == lakery.CategoryClient
+	}
+	var fe *lakery.FieldError
+	if errors.As(err, &fe) {
+		return fe.Category() == lakery.CategoryClient
+	}
+	return errors.Is(err, lakery.ErrMaxDepth)
+}
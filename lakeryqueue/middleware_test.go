@@ -0,0 +1,114 @@
+package lakeryqueue_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/trofkm/lakery"
+	"github.com/trofkm/lakery/lakeryqueue"
+)
+
+type orderCreated struct {
+	ID    string `json:"id" lakery:"required"`
+	Items int    `json:"items" lakery:"min=1"`
+}
+
+var _ = Describe("Middleware", func() {
+	var (
+		handled []*orderCreated
+		next    lakeryqueue.Handler
+	)
+	BeforeEach(func() {
+		handled = nil
+		next = func(_ context.Context, msg *lakeryqueue.Message) error {
+			handled = append(handled, msg.Payload.(*orderCreated))
+			return nil
+		}
+	})
+
+	It("passes valid payloads to the next handler", func() {
+		h := lakeryqueue.Middleware[orderCreated](lakery.NewValidator())(next)
+		err := h(context.Background(), &lakeryqueue.Message{Value: []byte(`{"id":"o-1","items":2}`)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(handled).To(Equal([]*orderCreated{{ID: "o-1", Items: 2}}))
+	})
+
+	It("returns validation errors by default", func() {
+		h := lakeryqueue.Middleware[orderCreated](lakery.NewValidator())(next)
+		err := h(context.Background(), &lakeryqueue.Message{Value: []byte(`{"id":"o-1","items":0}`)})
+		Expect(errors.Is(err, lakeryqueue.ErrInvalidMessage)).To(BeTrue())
+		var fe *lakery.FieldError
+		Expect(errors.As(err, &fe)).To(BeTrue())
+		Expect(fe.Field).To(Equal("Items"))
+		Expect(handled).To(BeEmpty())
+	})
+
+	It("sends invalid messages to the dead-letter handler", func() {
+		var dead []*lakeryqueue.Message
+		h := lakeryqueue.Middleware[orderCreated](lakery.NewValidator(),
+			lakeryqueue.WithDeadLetter(func(_ context.Context, msg *lakeryqueue.Message, err error) error {
+				Expect(err).To(MatchError(lakeryqueue.ErrInvalidMessage))
+				dead = append(dead, msg)
+				return nil
+			}),
+		)(next)
+		Expect(h(context.Background(), &lakeryqueue.Message{Value: []byte(`{"items":1}`)})).To(Succeed())
+		Expect(h(context.Background(), &lakeryqueue.Message{Value: []byte(`not json`)})).To(Succeed())
+		Expect(dead).To(HaveLen(2))
+		Expect(handled).To(BeEmpty())
+	})
+
+	It("uses a custom decoder", func() {
+		h := lakeryqueue.Middleware[orderCreated](lakery.NewValidator(),
+			lakeryqueue.WithDecoder(func(data []byte, v any) error {
+				*v.(*orderCreated) = orderCreated{ID: string(data), Items: 1}
+				return nil
+			}),
+		)(next)
+		Expect(h(context.Background(), &lakeryqueue.Message{Value: []byte("o-2")})).To(Succeed())
+		Expect(handled).To(Equal([]*orderCreated{{ID: "o-2", Items: 1}}))
+	})
+
+	It("does not dead-letter messages when the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		h := lakeryqueue.Middleware[orderCreated](lakery.NewValidator(),
+			lakeryqueue.WithDeadLetter(func(context.Context, *lakeryqueue.Message, error) error {
+				Fail("dead-letter handler called")
+				return nil
+			}),
+		)(next)
+		err := h(ctx, &lakeryqueue.Message{Value: []byte(`{"id":"o-1","items":2}`)})
+		Expect(err).To(MatchError(context.Canceled))
+	})
+	It("returns errors which are not the message's fault", func() {
+		type badRule struct {
+			ID string `json:"id" lakery:"min=abc"`
+		}
+		type remote struct {
+			ID string `json:"id" lakery:"known"`
+		}
+		deadLetter := lakeryqueue.WithDeadLetter(func(context.Context, *lakeryqueue.Message, error) error {
+			Fail("dead-letter handler called")
+			return nil
+		})
+		msg := &lakeryqueue.Message{Value: []byte(`{"id":"o-1"}`)}
+
+		err := lakeryqueue.Middleware[badRule](lakery.NewValidator(), deadLetter)(next)(context.Background(), msg)
+		var invalid *lakery.InvalidRuleError
+		Expect(errors.As(err, &invalid)).To(BeTrue())
+		Expect(errors.Is(err, lakeryqueue.ErrInvalidMessage)).To(BeFalse())
+
+		v := lakery.NewValidator(lakery.WithCollectAll())
+		v.RegisterTag("known", func(*lakery.Value) error {
+			return lakery.InternalError(errors.New("lookup unavailable"))
+		})
+		err = lakeryqueue.Middleware[remote](v, deadLetter)(next)(context.Background(), msg)
+		Expect(err).To(MatchError(ContainSubstring("lookup unavailable")))
+		Expect(errors.Is(err, lakeryqueue.ErrInvalidMessage)).To(BeFalse())
+		Expect(handled).To(BeEmpty())
+	})
+})
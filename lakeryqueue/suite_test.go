@@ -0,0 +1,13 @@
+package lakeryqueue_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLakeryQueue(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "lakeryqueue Suite")
+}
@@ -0,0 +1,403 @@
+// Package lakerytest generates instances of tagged structs from their rules for property-based
+// and fuzz-style tests of handlers against the declared validation contract.
+//
+//	gen := lakerytest.GenValid[CreateUser](v)
+//	err := quick.Check(func(u CreateUser) bool {
+//		return handler.Create(u) == nil
+//	}, &quick.Config{Values: lakerytest.QuickValues(gen)})
+//
+// Generators understand the common rules (required, min, max, inrange, percent, ratio,
+// omitempty, each, dive and alternatives) and fall back to rejection sampling with the validator
+// for anything else, so custom validators are honored as long as random values can satisfy them.
+package lakerytest
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/trofkm/lakery"
+)
+
+// attempts bounds rejection sampling before a generator gives up.
+const attempts = 1000
+
+const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// GenValid returns a generator of random instances of T passing validation with v.
+// The generator panics when it cannot find a valid instance, e.g. for contradicting rules
+// or bounds without an integer for an integer field (inrange=0.5:0.7).
+func GenValid[T any](v *lakery.Validator) func(r *rand.Rand) T {
+	g := &generator{v: v}
+	return func(r *rand.Rand) T {
+		var t T
+		for i := 0; i < attempts; i++ {
+			rv := reflect.ValueOf(&t).Elem()
+			s, err := g.genStruct(r, rv.Type())
+			if err != nil {
+				panic(fmt.Sprintf("lakerytest: cannot generate a valid %T: %v", t, err))
+			}
+			rv.Set(s)
+			if v.Validate(&t) == nil {
+				return t
+			}
+		}
+		panic(fmt.Sprintf("lakerytest: cannot generate a valid %T", t))
+	}
+}
+
+// GenInvalid returns a generator of random instances of T failing validation with v.
+// Each instance starts from a valid one with a single field mutated to violate one of its rules.
+func GenInvalid[T any](v *lakery.Validator) func(r *rand.Rand) T {
+	valid := GenValid[T](v)
+	g := &generator{v: v}
+	return func(r *rand.Rand) T {
+		for i := 0; i < attempts; i++ {
+			t := valid(r)
+			rv := reflect.ValueOf(&t).Elem()
+			mutated, err := g.mutate(r, rv)
+			if err != nil {
+				panic(fmt.Sprintf("lakerytest: cannot generate an invalid %T: %v", t, err))
+			}
+			if !mutated {
+				break
+			}
+			if v.Validate(&t) != nil {
+				return t
+			}
+		}
+		var t T
+		panic(fmt.Sprintf("lakerytest: cannot generate an invalid %T", t))
+	}
+}
+
+// QuickValues adapts a generator to testing/quick.Config.Values for functions
+// taking a single argument of type T.
+func QuickValues[T any](gen func(r *rand.Rand) T) func(args []reflect.Value, r *rand.Rand) {
+	return func(args []reflect.Value, r *rand.Rand) {
+		args[0] = reflect.ValueOf(gen(r))
+	}
+}
+
+type generator struct {
+	v *lakery.Validator
+}
+
+// fieldRules returns the parsed effective rules of every field of typ.
+func (g *generator) fieldRules(typ reflect.Type) map[string][]lakery.Rule {
+	effective, err := g.v.Explain(reflect.New(typ).Interface())
+	if err != nil {
+		panic(fmt.Sprintf("lakerytest: %v", err))
+	}
+	rules := make(map[string][]lakery.Rule)
+	for _, e := range effective {
+		parsed, err := lakery.ParseRules(e.Rule)
+		if err != nil {
+			panic(fmt.Sprintf("lakerytest: %s.%s: %v", typ, e.Field, err))
+		}
+		rules[e.Field] = append(rules[e.Field], parsed...)
+	}
+	return rules
+}
+
+func (g *generator) genStruct(r *rand.Rand, typ reflect.Type) (reflect.Value, error) {
+	rv := reflect.New(typ).Elem()
+	rules := g.fieldRules(typ)
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fieldRules, ok := rules[sf.Name]
+		if !ok {
+			continue
+		}
+		f, err := g.gen(r, sf.Type, fieldRules)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%s: %w", sf.Name, err)
+		}
+		rv.Field(i).Set(f)
+	}
+	return rv, nil
+}
+
+// constraints are the parts of rules the generator understands.
+type constraints struct {
+	required  bool
+	omitempty bool
+	dive      bool
+	lo, hi    *float64
+	each      []lakery.Rule
+}
+
+func parseConstraints(r *rand.Rand, rules []lakery.Rule) constraints {
+	var c constraints
+	for _, rule := range rules {
+		if rule.Alternatives != nil {
+			rule = rule.Alternatives[r.Intn(len(rule.Alternatives))]
+		}
+		switch rule.Key {
+		case "required":
+			c.required = true
+		case "omitempty":
+			c.omitempty = true
+		case "dive":
+			c.dive = true
+		case "min":
+			c.lo = parseBound(rule.Param)
+		case "max":
+			c.hi = parseBound(rule.Param)
+		case "inrange":
+			lo, hi, _ := strings.Cut(rule.Param, ":")
+			c.lo, c.hi = parseBound(lo), parseBound(hi)
+		case "percent":
+			c.lo, c.hi = bound(0), bound(100)
+		case "ratio":
+			c.lo, c.hi = bound(0), bound(1)
+		case "each":
+			inner, err := lakery.ParseRules(strings.TrimSuffix(strings.TrimPrefix(rule.Param, "{"), "}"))
+			if err == nil {
+				c.each = inner
+			}
+		}
+	}
+	return c
+}
+
+func parseBound(s string) *float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(f) {
+		return nil
+	}
+	return &f
+}
+
+func bound(f float64) *float64 {
+	return &f
+}
+
+// gen generates a value of type t satisfying the understood rules.
+func (g *generator) gen(r *rand.Rand, t reflect.Type, rules []lakery.Rule) (reflect.Value, error) {
+	c := parseConstraints(r, rules)
+	if c.omitempty && !c.required && r.Intn(4) == 0 {
+		return reflect.Zero(t), nil
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		if !c.required && !c.dive && r.Intn(4) == 0 {
+			return reflect.Zero(t), nil
+		}
+		elem, err := g.gen(r, t.Elem(), rules)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(elem)
+		return p, nil
+	case reflect.Struct:
+		if c.dive {
+			return g.genStruct(r, t)
+		}
+		return reflect.Zero(t), nil
+	case reflect.String:
+		n := length(r, c)
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		return reflect.ValueOf(string(b)).Convert(t), nil
+	case reflect.Slice:
+		n := length(r, c)
+		s := reflect.MakeSlice(t, n, n)
+		for i := 0; i < n; i++ {
+			elem, err := g.gen(r, t.Elem(), c.each)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			s.Index(i).Set(elem)
+		}
+		return s, nil
+	case reflect.Bool:
+		return reflect.ValueOf(c.required || r.Intn(2) == 0).Convert(t), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return number(r, t, c)
+	default:
+		return reflect.Zero(t), nil
+	}
+}
+
+// length picks a length for strings and slices within the min/max bounds.
+func length(r *rand.Rand, c constraints) int {
+	lo, hi := 0, 16
+	if c.required {
+		lo = 1
+	}
+	if c.lo != nil {
+		lo = max(lo, int(math.Ceil(*c.lo)))
+	}
+	if c.hi != nil {
+		hi = int(*c.hi)
+	} else if hi < lo {
+		hi = lo + 16
+	}
+	if hi < lo {
+		return lo
+	}
+	return lo + r.Intn(hi-lo+1)
+}
+
+// number picks a number within the bounds, defaulting to [0, 1000] (or the type's range).
+// Integers are picked with big.Int arithmetic, so ranges wider than int64 work; bounds without
+// an integer between them for an integer type are an error.
+func number(r *rand.Rand, t reflect.Type, c constraints) (reflect.Value, error) {
+	lo, hi := 0.0, 1000.0
+	if c.lo != nil {
+		lo = *c.lo
+		if c.hi == nil {
+			hi = lo + 1000
+		}
+	}
+	if c.hi != nil {
+		hi = *c.hi
+		if c.lo == nil {
+			lo = math.Min(0, hi)
+		}
+	}
+	if c.required && lo <= 0 && hi >= 1 {
+		lo = 1
+	}
+	if hi < lo {
+		hi = lo
+	}
+	rv := reflect.New(t).Elem()
+	if t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64 {
+		rv.SetFloat(lo + r.Float64()*(hi-lo))
+		return rv, nil
+	}
+	// the integers of the type within [lo, hi]
+	typeLo, typeHi := new(big.Int), new(big.Int).Lsh(big.NewInt(1), uint(t.Bits()))
+	signed := t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64
+	if signed {
+		typeHi.Rsh(typeHi, 1)
+		typeLo.Neg(typeHi)
+	}
+	typeHi.Sub(typeHi, big.NewInt(1))
+	low := bigMax(floatToInt(math.Ceil(math.Max(lo, -math.MaxFloat64))), typeLo)
+	high := bigMin(floatToInt(math.Floor(math.Min(hi, math.MaxFloat64))), typeHi)
+	if low.Cmp(high) > 0 {
+		return reflect.Value{}, fmt.Errorf("no %s within [%g, %g]", t, lo, hi)
+	}
+	size := new(big.Int).Sub(high, low)
+	size.Add(size, big.NewInt(1))
+	n := new(big.Int).Rand(r, size)
+	n.Add(n, low)
+	if signed {
+		rv.SetInt(n.Int64())
+	} else {
+		rv.SetUint(n.Uint64())
+	}
+	return rv, nil
+}
+
+// floatToInt converts the finite integral f to a big.Int.
+func floatToInt(f float64) *big.Int {
+	n, _ := big.NewFloat(f).Int(nil)
+	return n
+}
+
+func bigMax(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+func bigMin(a, b *big.Int) *big.Int {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+// mutate violates one rule of a random field of the struct rv and reports whether
+// there was a rule to violate.
+func (g *generator) mutate(r *rand.Rand, rv reflect.Value) (bool, error) {
+	rules := g.fieldRules(rv.Type())
+	var fields []string
+	for i := 0; i < rv.NumField(); i++ {
+		if name := rv.Type().Field(i).Name; len(rules[name]) > 0 && rv.Type().Field(i).IsExported() {
+			fields = append(fields, name)
+		}
+	}
+	if len(fields) == 0 {
+		return false, nil
+	}
+	name := fields[r.Intn(len(fields))]
+	f := rv.FieldByName(name)
+	fieldRules := rules[name]
+	rule := fieldRules[r.Intn(len(fieldRules))]
+	switch {
+	// all alternatives have to fail, try random values
+	case rule.Alternatives != nil:
+		return true, g.regen(r, f)
+	case rule.Key == "required":
+		f.Set(reflect.Zero(f.Type()))
+	case rule.Key == "min", rule.Key == "max", rule.Key == "inrange", rule.Key == "percent", rule.Key == "ratio":
+		c := parseConstraints(r, []lakery.Rule{rule})
+		violate(f, rule.Key == "max", c)
+	default:
+		return true, g.regen(r, f)
+	}
+	return true, nil
+}
+
+// regen sets f to a random value of its type regardless of its rules.
+func (g *generator) regen(r *rand.Rand, f reflect.Value) error {
+	v, err := g.gen(r, f.Type(), nil)
+	if err != nil {
+		return err
+	}
+	f.Set(v)
+	return nil
+}
+
+// violate sets f just outside the bounds: above hi when above is set, below lo otherwise.
+func violate(f reflect.Value, above bool, c constraints) {
+	if f.Kind() == reflect.Pointer {
+		p := reflect.New(f.Type().Elem())
+		violate(p.Elem(), above, c)
+		f.Set(p)
+		return
+	}
+	var target float64
+	switch {
+	case (above || c.lo == nil) && c.hi != nil:
+		target = *c.hi + 1
+	case c.lo != nil:
+		target = *c.lo - 1
+	default:
+		return
+	}
+	switch f.Kind() {
+	case reflect.String:
+		n := max(int(target), 0)
+		f.Set(reflect.ValueOf(strings.Repeat("x", n)).Convert(f.Type()))
+	case reflect.Slice:
+		n := max(int(target), 0)
+		f.Set(reflect.MakeSlice(f.Type(), n, n))
+	case reflect.Float32, reflect.Float64:
+		f.SetFloat(target)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if target >= 0 {
+			f.SetUint(uint64(target))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f.SetInt(int64(target))
+	}
+}
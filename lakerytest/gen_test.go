@@ -0,0 +1,112 @@
+package lakerytest_test
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing/quick"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/trofkm/lakery"
+	"github.com/trofkm/lakery/lakerytest"
+)
+
+type address struct {
+	City string `lakery:"required,max=20"`
+	Zip  string `lakery:"min=5,max=5"`
+}
+
+type signup struct {
+	Name     string    `lakery:"required,min=3,max=32"`
+	Age      int       `lakery:"inrange=18:130"`
+	Score    float64   `lakery:"percent"`
+	Nickname *string   `lakery:"omitempty,min=2"`
+	Tags     []string  `lakery:"max=3,each={min=1,max=8}"`
+	Code     string    `lakery:"min=10|max=2"`
+	Home     *address  `lakery:"dive"`
+	Other    []address `lakery:"each={dive}"`
+	Note     string
+}
+
+var _ = Describe("generators", func() {
+	var v *lakery.Validator
+	BeforeEach(func() {
+		v = lakery.NewValidator()
+	})
+
+	It("generates valid instances", func() {
+		gen := lakerytest.GenValid[signup](v)
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 200; i++ {
+			s := gen(r)
+			Expect(v.Validate(s)).To(Succeed())
+		}
+	})
+
+	It("generates invalid instances", func() {
+		gen := lakerytest.GenInvalid[signup](v)
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 200; i++ {
+			s := gen(r)
+			Expect(v.Validate(s)).To(HaveOccurred())
+		}
+	})
+
+	It("honors custom validators by rejection sampling", func() {
+		v.RegisterTag("even", func(val *lakery.Value) error {
+			if val.Interface().(int)%2 != 0 {
+				return errors.New("should be even")
+			}
+			return nil
+		})
+		type counter struct {
+			N int `lakery:"even,min=1,max=100"`
+		}
+		gen := lakerytest.GenValid[counter](v)
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 50; i++ {
+			Expect(gen(r).N % 2).To(BeZero())
+		}
+	})
+
+	It("integrates with testing/quick", func() {
+		cfg := &quick.Config{Values: lakerytest.QuickValues(lakerytest.GenValid[signup](v))}
+		Expect(quick.Check(func(s signup) bool {
+			return v.Validate(s) == nil
+		}, cfg)).To(Succeed())
+	})
+
+	It("panics for contradicting rules", func() {
+		type impossible struct {
+			N int `lakery:"min=10,max=1"`
+		}
+		gen := lakerytest.GenValid[impossible](v)
+		Expect(func() { gen(rand.New(rand.NewSource(1))) }).To(PanicWith(ContainSubstring("cannot generate a valid")))
+	})
+
+	It("panics for bounds without an integer", func() {
+		type fraction struct {
+			N int `lakery:"inrange=0.5:0.7"`
+		}
+		gen := lakerytest.GenValid[fraction](v)
+		Expect(func() { gen(rand.New(rand.NewSource(1))) }).To(PanicWith(ContainSubstring("N: no int within [0.5, 0.7]")))
+	})
+
+	It("generates integers of ranges wider than int64", func() {
+		type wide struct {
+			U uint64 `lakery:"inrange=0:18000000000000000000"`
+			I int64  `lakery:"inrange=-9000000000000000000:9000000000000000000"`
+		}
+		gen := lakerytest.GenValid[wide](v)
+		r := rand.New(rand.NewSource(1))
+		large := false
+		for i := 0; i < 50; i++ {
+			w := gen(r)
+			Expect(v.Validate(w)).To(Succeed())
+			large = large || w.U > math.MaxInt64
+		}
+		Expect(large).To(BeTrue())
+	})
+})
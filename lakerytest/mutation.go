@@ -0,0 +1,44 @@
+package lakerytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/trofkm/lakery"
+)
+
+// MutateEnv names the environment variable holding the JSON encoded lakery.Mutation which
+// `lakery-validate mutate` passes to the test processes it runs.
+const MutateEnv = "LAKERY_MUTATE"
+
+// Mutation returns the option applying the mutation set by `lakery-validate mutate`, or an option
+// doing nothing when MutateEnv is not set. Only validators created with it take part in mutation
+// testing, so the validators under test are built with it in the tests:
+//
+//	func TestMain(m *testing.M) {
+//		api.Validator = lakery.NewValidator(lakerytest.Mutation())
+//		os.Exit(m.Run())
+//	}
+//
+// It panics when MutateEnv holds a malformed mutation.
+func Mutation() lakery.Option {
+	env := os.Getenv(MutateEnv)
+	if env == "" {
+		return func(*lakery.Validator) {}
+	}
+	var m lakery.Mutation
+	if err := json.Unmarshal([]byte(env), &m); err != nil {
+		panic(fmt.Sprintf("lakerytest: malformed %s: %v", MutateEnv, err))
+	}
+	if m.Type == "" || m.Field == "" || m.Rule == "" {
+		panic(fmt.Sprintf("lakerytest: malformed %s: mutation needs type, field and rule", MutateEnv))
+	}
+	return lakery.WithMutationForTesting(m)
+}
+
+// MutationEnv returns the MutateEnv=... entry enabling m in a child process.
+func MutationEnv(m lakery.Mutation) string {
+	data, _ := json.Marshal(m)
+	return MutateEnv + "=" + string(data)
+}
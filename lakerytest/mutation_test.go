@@ -0,0 +1,37 @@
+package lakerytest_test
+
+import (
+	"reflect"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/trofkm/lakery"
+	"github.com/trofkm/lakery/lakerytest"
+)
+
+var _ = Describe("Mutation", func() {
+	type S struct {
+		Name string `lakery:"required,min=3"`
+	}
+	m := lakery.Mutation{Type: reflect.TypeOf(S{}).String(), Field: "Name", Rule: "min=3"}
+
+	It("applies the mutation passed by lakery-validate mutate", func() {
+		env := lakerytest.MutationEnv(m)
+		Expect(env).To(HavePrefix(lakerytest.MutateEnv + "="))
+		GinkgoT().Setenv(lakerytest.MutateEnv, strings.TrimPrefix(env, lakerytest.MutateEnv+"="))
+		Expect(lakery.NewValidator(lakerytest.Mutation()).Validate(S{Name: "x"})).To(Succeed())
+		Expect(lakery.NewValidator().Validate(S{Name: "x"})).To(HaveOccurred())
+	})
+	It("does nothing without a mutation", func() {
+		GinkgoT().Setenv(lakerytest.MutateEnv, "")
+		Expect(lakery.NewValidator(lakerytest.Mutation()).Validate(S{Name: "x"})).To(HaveOccurred())
+	})
+	It("panics on malformed mutations", func() {
+		GinkgoT().Setenv(lakerytest.MutateEnv, "{")
+		Expect(func() { lakerytest.Mutation() }).To(PanicWith(ContainSubstring("malformed LAKERY_MUTATE")))
+		GinkgoT().Setenv(lakerytest.MutateEnv, `{"type":"x"}`)
+		Expect(func() { lakerytest.Mutation() }).To(PanicWith(ContainSubstring("needs type, field and rule")))
+	})
+})
@@ -0,0 +1,13 @@
+package lakerytest_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLakeryTest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "lakerytest Suite")
+}
@@ -0,0 +1,340 @@
+package lakery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Manifest is a serializable snapshot of the validation rules declared on struct types.
+// It is produced by Validator.Manifest or by `lakery-validate manifest` and can be committed
+// next to an API definition so reviewers see validation changes with `lakery-validate diff-rules`.
+type Manifest struct {
+	// format version, see ManifestVersion
+	Version int            `json:"version"`
+	Types   []ManifestType `json:"types"`
+}
+
+// ManifestVersion is the manifest format version produced by this package.
+// Manifests without a version field were written before versioning and are treated as version 0.
+const ManifestVersion = 1
+
+// ErrUnsupportedManifestVersion is returned for manifests written by a newer version of lakery.
+var ErrUnsupportedManifestVersion = errors.New("unsupported manifest version")
+
+// manifestMigrations upgrade a raw manifest document from the version used as key to the next one.
+// They work on the decoded JSON so older layouts don't have to be representable by current types.
+var manifestMigrations = map[int]func(doc map[string]any) error{
+	// version 0 -> 1: the version field was introduced, the layout is unchanged
+	0: func(doc map[string]any) error { return nil },
+}
+
+// ManifestType lists the rules of a single struct type.
+type ManifestType struct {
+	// package-qualified type name, e.g. "api.User"
+	Name   string          `json:"name"`
+	Fields []ManifestField `json:"fields"`
+}
+
+// ManifestField lists the rules of a single struct field in declaration order.
+type ManifestField struct {
+	Name  string   `json:"name"`
+	Rules []string `json:"rules"`
+}
+
+// Manifest builds a manifest for the given struct values (or pointers to them).
+func (v *Validator) Manifest(types ...any) (*Manifest, error) {
+	m := &Manifest{Version: ManifestVersion}
+	for _, t := range types {
+		typ := reflect.TypeOf(t)
+		for typ != nil && typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		if typ == nil || typ.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("manifest can only describe structs, got %v", typ)
+		}
+		mt := ManifestType{Name: typ.String()}
+		fields, err := v.manifestFields(typ, "", []reflect.Type{typ})
+		if err != nil {
+			return nil, fmt.Errorf("%s.%w", mt.Name, err)
+		}
+		mt.Fields = fields
+		m.Types = append(m.Types, mt)
+	}
+	return m, nil
+}
+
+// manifestRules splits the rules of a tag as written, or as formatted by Rule.String for tags read
+// with WithTagParser.
+func (v *Validator) manifestRules(tag string) ([]string, error) {
+	if v.tagParser == nil {
+		return SplitRules(tag)
+	}
+	parsed, err := v.tagParser.ParseRules(tag)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]string, len(parsed))
+	for i, r := range parsed {
+		rules[i] = r.String()
+	}
+	return rules, nil
+}
+
+// manifestFields lists the fields of typ with rules, including the fields of inline struct fields
+// named with dots (Opts.Retries) and the promoted fields of embedded structs, named like the fields
+// of typ as Validate does. embedding holds the embedded types entered so far, to stop at cycles.
+func (v *Validator) manifestFields(typ reflect.Type, prefix string, embedding []reflect.Type) ([]ManifestField, error) {
+	var fields []ManifestField
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if v.skipped(sf) {
+			continue
+		}
+		if tag := TagRules(sf.Tag, v.tagName); tag != "" {
+			rules, err := v.manifestRules(tag)
+			if err != nil {
+				return nil, fmt.Errorf("%s%s: %w", prefix, sf.Name, err)
+			}
+			fields = append(fields, ManifestField{Name: prefix + sf.Name, Rules: rules})
+		}
+		inner := sf.Type
+		if inner.Kind() == reflect.Pointer {
+			inner = inner.Elem()
+		}
+		switch {
+		case isEmbeddedStruct(sf) && !slices.Contains(embedding, inner):
+			nested, err := v.manifestFields(inner, prefix, append(embedding, inner))
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, nested...)
+		case isInlineStruct(sf):
+			nested, err := v.manifestFields(inner, prefix+sf.Name+".", embedding)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, nested...)
+		}
+	}
+	return fields, nil
+}
+
+// WriteManifest writes the manifest as indented JSON.
+func WriteManifest(w io.Writer, m *Manifest) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// ReadManifest decodes a manifest written by WriteManifest, migrating older versions.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read manifest: %w", err)
+	}
+	return MigrateManifest(data)
+}
+
+// MigrateManifest decodes a manifest document of any supported version and upgrades it
+// to ManifestVersion. Documents from newer versions fail with ErrUnsupportedManifestVersion.
+func MigrateManifest(data []byte) (*Manifest, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("cannot decode manifest: %w", err)
+	}
+	version := 0
+	if raw, ok := doc["version"]; ok {
+		n, ok := raw.(float64)
+		if !ok || n != float64(int(n)) {
+			return nil, fmt.Errorf("cannot decode manifest: invalid version %v", raw)
+		}
+		version = int(n)
+	}
+	if err := checkManifestVersion(version); err != nil {
+		return nil, err
+	}
+	for ; version < ManifestVersion; version++ {
+		if err := manifestMigrations[version](doc); err != nil {
+			return nil, fmt.Errorf("cannot migrate manifest from version %d: %w", version, err)
+		}
+		doc["version"] = version + 1
+	}
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(upgraded, m); err != nil {
+		return nil, fmt.Errorf("cannot decode manifest: %w", err)
+	}
+	return m, nil
+}
+
+// CheckManifestVersion reports whether the manifest can be processed by this version of lakery.
+// Manifests obtained from ReadManifest are always current; the check is meant for manifests
+// constructed or decoded by other means.
+func CheckManifestVersion(m *Manifest) error {
+	if m.Version != ManifestVersion {
+		if err := checkManifestVersion(m.Version); err != nil {
+			return err
+		}
+		return fmt.Errorf("manifest version %d is outdated, migrate it to version %d", m.Version, ManifestVersion)
+	}
+	return nil
+}
+
+func checkManifestVersion(version int) error {
+	if version < 0 || version > ManifestVersion {
+		return fmt.Errorf("%w %d: this version of lakery supports up to %d", ErrUnsupportedManifestVersion, version, ManifestVersion)
+	}
+	return nil
+}
+
+// SplitRules splits a rule string like "required,min=1,each={min=1,max=5}" into
+// trimmed top-level rules, keeping brace blocks intact. Empty rules are dropped.
+func SplitRules(rules string) ([]string, error) {
+	parts, err := splitTopLevelByComma(rules)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out, nil
+}
+
+// RuleChangeKind describes how a rule changed between two manifests.
+type RuleChangeKind string
+
+const (
+	RuleAdded   RuleChangeKind = "added"
+	RuleRemoved RuleChangeKind = "removed"
+	RuleChanged RuleChangeKind = "changed"
+)
+
+// RuleChange is a single difference between two manifests. Rules are matched by their key
+// (the part before '='), so min=2 -> min=3 is reported as a change rather than remove+add.
+type RuleChange struct {
+	Type  string
+	Field string
+	Kind  RuleChangeKind
+	// rule text in the old manifest, empty for added rules
+	Old string
+	// rule text in the new manifest, empty for removed rules
+	New string
+}
+
+func (c RuleChange) String() string {
+	switch c.Kind {
+	case RuleAdded:
+		return fmt.Sprintf("%s.%s: added %s", c.Type, c.Field, c.New)
+	case RuleRemoved:
+		return fmt.Sprintf("%s.%s: removed %s", c.Type, c.Field, c.Old)
+	default:
+		return fmt.Sprintf("%s.%s: changed %s -> %s", c.Type, c.Field, c.Old, c.New)
+	}
+}
+
+// DiffManifests reports added, removed and changed rules per struct field, sorted by type
+// and keeping field declaration order. Fields and types missing on one side have all their
+// rules reported as added or removed.
+func DiffManifests(old, new *Manifest) ([]RuleChange, error) {
+	if old == nil || new == nil {
+		return nil, errors.New("cannot diff nil manifest")
+	}
+	for _, m := range []*Manifest{old, new} {
+		if err := CheckManifestVersion(m); err != nil {
+			return nil, err
+		}
+	}
+	oldTypes := manifestTypes(old)
+	newTypes := manifestTypes(new)
+
+	names := make([]string, 0, len(oldTypes)+len(newTypes))
+	for name := range oldTypes {
+		names = append(names, name)
+	}
+	for name := range newTypes {
+		if _, ok := oldTypes[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []RuleChange
+	for _, name := range names {
+		changes = append(changes, diffTypes(name, oldTypes[name], newTypes[name])...)
+	}
+	return changes, nil
+}
+
+func manifestTypes(m *Manifest) map[string]ManifestType {
+	types := make(map[string]ManifestType, len(m.Types))
+	for _, t := range m.Types {
+		types[t.Name] = t
+	}
+	return types
+}
+
+func diffTypes(name string, old, new ManifestType) []RuleChange {
+	newFields := make(map[string][]string, len(new.Fields))
+	for _, f := range new.Fields {
+		newFields[f.Name] = f.Rules
+	}
+	var changes []RuleChange
+	seen := make(map[string]bool, len(old.Fields))
+	for _, f := range old.Fields {
+		seen[f.Name] = true
+		changes = append(changes, diffRules(name, f.Name, f.Rules, newFields[f.Name])...)
+	}
+	for _, f := range new.Fields {
+		if !seen[f.Name] {
+			changes = append(changes, diffRules(name, f.Name, nil, f.Rules)...)
+		}
+	}
+	return changes
+}
+
+func diffRules(typeName, field string, old, new []string) []RuleChange {
+	newByKey := make(map[string]string, len(new))
+	for _, rule := range new {
+		newByKey[ruleKey(rule)] = rule
+	}
+	var changes []RuleChange
+	oldKeys := make(map[string]bool, len(old))
+	for _, rule := range old {
+		key := ruleKey(rule)
+		oldKeys[key] = true
+		newRule, ok := newByKey[key]
+		switch {
+		case !ok:
+			changes = append(changes, RuleChange{Type: typeName, Field: field, Kind: RuleRemoved, Old: rule})
+		case newRule != rule:
+			changes = append(changes, RuleChange{Type: typeName, Field: field, Kind: RuleChanged, Old: rule, New: newRule})
+		}
+	}
+	for _, rule := range new {
+		if !oldKeys[ruleKey(rule)] {
+			changes = append(changes, RuleChange{Type: typeName, Field: field, Kind: RuleAdded, New: rule})
+		}
+	}
+	return changes
+}
+
+func ruleKey(rule string) string {
+	// alternatives like email|uuid are matched as a whole
+	if alts, err := splitTopLevel(rule, '|'); err == nil && len(alts) > 1 {
+		return strings.TrimSpace(rule)
+	}
+	key, _, _ := strings.Cut(rule, "=")
+	return strings.TrimSpace(key)
+}
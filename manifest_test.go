@@ -0,0 +1,268 @@
+package lakery_test
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/trofkm/lakery"
+)
+
+type manifestUser struct {
+	Name  string   `lakery:"required,min=2"`
+	Tags  []string `lakery:"each={min=1,max=5}"`
+	Notes string
+}
+
+type manifestAudit struct {
+	CreatedBy string `lakery:"required"`
+}
+
+type manifestBase struct {
+	ID int `lakery:"min=1"`
+	*manifestBase
+}
+
+var _ = Describe("Manifest", func() {
+	It("describes the rules of struct fields", func() {
+		v := lakery.NewValidator()
+		m, err := v.Manifest(&manifestUser{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Version).To(Equal(lakery.ManifestVersion))
+		Expect(m.Types).To(Equal([]lakery.ManifestType{{
+			Name: "lakery_test.manifestUser",
+			Fields: []lakery.ManifestField{
+				{Name: "Name", Rules: []string{"required", "min=2"}},
+				{Name: "Tags", Rules: []string{"each={min=1,max=5}"}},
+			},
+		}}))
+	})
+
+	It("names fields of inline structs with dots", func() {
+		type Config struct {
+			Retry struct {
+				Attempts int `lakery:"min=1"`
+			}
+		}
+		m, err := lakery.NewValidator().Manifest(Config{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Types[0].Fields).To(Equal([]lakery.ManifestField{{Name: "Retry.Attempts", Rules: []string{"min=1"}}}))
+	})
+
+	It("lists promoted fields of embedded structs under the parent", func() {
+		type Admin struct {
+			manifestBase
+			*manifestAudit
+			Role string `lakery:"oneof=owner admin"`
+		}
+		m, err := lakery.NewValidator().Manifest(Admin{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Types[0].Fields).To(Equal([]lakery.ManifestField{
+			{Name: "ID", Rules: []string{"min=1"}},
+			{Name: "CreatedBy", Rules: []string{"required"}},
+			{Name: "Role", Rules: []string{"oneof=owner admin"}},
+		}))
+		m, err = lakery.NewValidator().Manifest(manifestBase{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Types[0].Fields).To(Equal([]lakery.ManifestField{{Name: "ID", Rules: []string{"min=1"}}}))
+	})
+
+	It("leaves out fields tagged with -", func() {
+		type Config struct {
+			Name  string `lakery:"required"`
+			Debug struct {
+				Level int `lakery:"min=1"`
+			} `lakery:"-"`
+		}
+		m, err := lakery.NewValidator().Manifest(Config{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Types[0].Fields).To(Equal([]lakery.ManifestField{{Name: "Name", Rules: []string{"required"}}}))
+	})
+
+	It("rejects non-struct types", func() {
+		v := lakery.NewValidator()
+		_, err := v.Manifest(42)
+		Expect(err).To(MatchError(ContainSubstring("only describe structs")))
+	})
+
+	It("round-trips through JSON", func() {
+		v := lakery.NewValidator()
+		m, err := v.Manifest(manifestUser{})
+		Expect(err).NotTo(HaveOccurred())
+		var buf bytes.Buffer
+		Expect(lakery.WriteManifest(&buf, m)).To(Succeed())
+		read, err := lakery.ReadManifest(&buf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read).To(Equal(m))
+	})
+
+	Context("diff", func() {
+		old := &lakery.Manifest{Version: lakery.ManifestVersion, Types: []lakery.ManifestType{{
+			Name: "api.User",
+			Fields: []lakery.ManifestField{
+				{Name: "Name", Rules: []string{"required", "min=2"}},
+				{Name: "Age", Rules: []string{"max=150"}},
+			},
+		}}}
+
+		It("reports added, removed and changed rules", func() {
+			new := &lakery.Manifest{Version: lakery.ManifestVersion, Types: []lakery.ManifestType{{
+				Name: "api.User",
+				Fields: []lakery.ManifestField{
+					{Name: "Name", Rules: []string{"min=3", "max=64"}},
+					{Name: "Email", Rules: []string{"required"}},
+				},
+			}}}
+			changes, err := lakery.DiffManifests(old, new)
+			Expect(err).NotTo(HaveOccurred())
+			var lines []string
+			for _, c := range changes {
+				lines = append(lines, c.String())
+			}
+			Expect(lines).To(Equal([]string{
+				"api.User.Name: removed required",
+				"api.User.Name: changed min=2 -> min=3",
+				"api.User.Name: added max=64",
+				"api.User.Age: removed max=150",
+				"api.User.Email: added required",
+			}))
+		})
+
+		It("matches alternatives as a whole", func() {
+			new := &lakery.Manifest{Version: lakery.ManifestVersion, Types: []lakery.ManifestType{{
+				Name: "api.User",
+				Fields: []lakery.ManifestField{
+					{Name: "Name", Rules: []string{"required", "min=2|max=0"}},
+					{Name: "Age", Rules: []string{"max=150"}},
+				},
+			}}}
+			changes, err := lakery.DiffManifests(old, new)
+			Expect(err).NotTo(HaveOccurred())
+			var lines []string
+			for _, c := range changes {
+				lines = append(lines, c.String())
+			}
+			Expect(lines).To(Equal([]string{
+				"api.User.Name: removed min=2",
+				"api.User.Name: added min=2|max=0",
+			}))
+		})
+
+		It("reports nothing for equal manifests", func() {
+			changes, err := lakery.DiffManifests(old, old)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(BeEmpty())
+		})
+
+		It("refuses outdated manifests", func() {
+			_, err := lakery.DiffManifests(&lakery.Manifest{}, old)
+			Expect(err).To(MatchError(ContainSubstring("outdated")))
+		})
+	})
+
+	Context("versioning", func() {
+		It("migrates unversioned manifests", func() {
+			m, err := lakery.MigrateManifest([]byte(`{"types":[{"name":"api.User","fields":[{"name":"Name","rules":["required"]}]}]}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Version).To(Equal(lakery.ManifestVersion))
+			Expect(m.Types[0].Fields[0].Rules).To(Equal([]string{"required"}))
+			Expect(lakery.CheckManifestVersion(m)).To(Succeed())
+		})
+
+		It("rejects manifests from newer versions", func() {
+			_, err := lakery.MigrateManifest([]byte(`{"version":99,"types":[]}`))
+			Expect(err).To(MatchError(lakery.ErrUnsupportedManifestVersion))
+			Expect(lakery.CheckManifestVersion(&lakery.Manifest{Version: 99})).To(MatchError(lakery.ErrUnsupportedManifestVersion))
+		})
+
+		It("rejects malformed versions", func() {
+			_, err := lakery.MigrateManifest([]byte(`{"version":"one"}`))
+			Expect(err).To(MatchError(ContainSubstring("invalid version")))
+		})
+	})
+
+	Context("compatibility", func() {
+		type UserV1 struct {
+			Name     string   `lakery:"required,min=2,max=32"`
+			Nick     string   `lakery:"max=10"`
+			Age      int      `lakery:"inrange=0:150"`
+			Tags     []string `lakery:"each={min=1,max=10}"`
+			Password string   `lakery:"notin=root admin"`
+			Bio      string   `lakery:"omitempty,min=10"`
+			Kind     string   `lakery:"email|uuid"`
+			Opts     struct {
+				Retries int `lakery:"max=5"`
+			}
+		}
+		type UserV2 struct {
+			Name     string   `lakery:"required,min=3,max=64"`
+			Nick     string   `lakery:"max=20"`
+			Age      int      `lakery:"inrange=18:150"`
+			Tags     []string `lakery:"each={min=1,max=5}"`
+			Password string   `lakery:"notin=root"`
+			Bio      string   `lakery:"min=10"`
+			Kind     string   `lakery:"email|uuid"`
+			Opts     struct {
+				Retries int `lakery:"max=5"`
+			}
+			Email string `lakery:"required"`
+		}
+
+		It("classifies the rule changes of every field", func() {
+			changes, err := lakery.CompatibleRules(UserV1{}, &UserV2{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]lakery.FieldCompatibility{
+				{Field: "Name", Compatibility: lakery.RulesIncompatible, Changes: []string{"min=2 -> min=3: stricter", "max=32 -> max=64: looser"}},
+				{Field: "Nick", Compatibility: lakery.RulesLooser, Changes: []string{"max=10 -> max=20: looser"}},
+				{Field: "Age", Compatibility: lakery.RulesStricter, Changes: []string{"inrange=0:150 -> inrange=18:150: stricter"}},
+				{Field: "Tags", Compatibility: lakery.RulesStricter, Changes: []string{"each={min=1,max=10} -> each={min=1,max=5}: stricter"}},
+				{Field: "Password", Compatibility: lakery.RulesLooser, Changes: []string{"notin=root admin -> notin=root: looser"}},
+				{Field: "Bio", Compatibility: lakery.RulesStricter, Changes: []string{"removed omitempty: stricter"}},
+				{Field: "Email", Compatibility: lakery.RulesStricter, Changes: []string{"added required: stricter"}},
+			}))
+			Expect(changes[0].Compatibility.Breaking()).To(BeTrue())
+			Expect(changes[1].Compatibility.Breaking()).To(BeFalse())
+			Expect(changes[1].String()).To(Equal("Nick: looser (max=10 -> max=20: looser)"))
+		})
+
+		It("reports removed fields and unknown changes", func() {
+			type Old struct {
+				Name string `lakery:"required,sku=A"`
+				Code string `lakery:"min=3"`
+			}
+			type New struct {
+				Name string `lakery:"required,sku=B"`
+			}
+			changes, err := lakery.NewValidator().CompatibleRules(Old{}, New{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]lakery.FieldCompatibility{
+				{Field: "Name", Compatibility: lakery.RulesIncompatible, Changes: []string{"sku=A -> sku=B: incompatible"}},
+				{Field: "Code", Compatibility: lakery.RulesLooser, Changes: []string{"removed min=3: looser"}},
+			}))
+			changes, err = lakery.CompatibleRules(Old{}, Old{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(BeEmpty())
+			_, err = lakery.CompatibleRules(Old{}, "x")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("compares time bounds", func() {
+			type Old struct {
+				Start time.Time `lakery:"min=2020-01-01,max=2030-01-01T00:00:00Z"`
+			}
+			type New struct {
+				Start time.Time `lakery:"min=2021-01-01,max=2035-01-01"`
+			}
+			changes, err := lakery.CompatibleRules(Old{}, New{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]lakery.FieldCompatibility{
+				{Field: "Start", Compatibility: lakery.RulesIncompatible, Changes: []string{
+					"min=2020-01-01 -> min=2021-01-01: stricter",
+					"max=2030-01-01T00:00:00Z -> max=2035-01-01: looser",
+				}},
+			}))
+		})
+	})
+})
@@ -0,0 +1,71 @@
+package lakery
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ValidateMap validates dynamic data, e.g. JSON decoded into a map, against rule strings keyed
+// by field. Nested maps are addressed with dotted keys:
+//
+//	errs, err := v.ValidateMap(data, map[string]string{
+//		"name":         "required,min=3",
+//		"address.city": "required",
+//		"tags":         "each={min=1}",
+//	})
+//
+// The returned map holds the error of every key failing validation (all of its failures in
+// collect-all mode) and is empty when data is valid. Missing keys are validated as nil, so only
+// rules like required or omitempty pass for them. Malformed rules are not failures of a key: they
+// are returned as err, an *InvalidRuleError like Validate returns, and errs is nil then.
+func (v *Validator) ValidateMap(data map[string]any, rules map[string]string) (map[string]error, error) {
+	return v.ValidateMapCtx(context.Background(), data, rules)
+}
+
+// ValidateMapCtx validates dynamic data like ValidateMap and makes ctx available to validators.
+// It stops with the context error once ctx is done.
+func (v *Validator) ValidateMapCtx(ctx context.Context, data map[string]any, rules map[string]string) (map[string]error, error) {
+	if v == nil {
+		return nil, errors.New("cannot validate nil")
+	}
+	keys := make([]string, 0, len(rules))
+	for key := range rules {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	errs := make(map[string]error)
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		err := v.validateRules(ctx, key, lookupPath(data, key), rules[key])
+		var invalid *InvalidRuleError
+		switch {
+		case err == nil:
+		case errors.As(err, &invalid), ctx.Err() != nil:
+			return nil, err
+		default:
+			errs[key] = err
+		}
+	}
+	return errs, nil
+}
+
+// lookupPath returns the value under a dotted key, descending into nested maps.
+// It returns nil when any part of the path is missing.
+func lookupPath(data map[string]any, key string) any {
+	var value any = data
+	for _, part := range strings.Split(key, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		if value, ok = m[part]; !ok {
+			return nil
+		}
+	}
+	return value
+}
@@ -0,0 +1,60 @@
+package lakery
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Mutation replaces (or drops) a rule of a struct field. `lakery-validate mutate` reruns a test
+// suite with every mutation of the rules to find rules no test would notice missing or changed;
+// validators apply one only when created with WithMutationForTesting, see lakerytest.Mutation.
+type Mutation struct {
+	// struct type as printed by reflect, e.g. api.User
+	Type  string `json:"type"`
+	Field string `json:"field"`
+	// rule to mutate as written in the rules, e.g. min=2
+	Rule string `json:"rule"`
+	// replacement rule, e.g. min=3; empty drops the rule
+	Replace string `json:"replace,omitempty"`
+}
+
+func (m Mutation) String() string {
+	if m.Replace == "" {
+		return fmt.Sprintf("%s.%s: drop %s", m.Type, m.Field, m.Rule)
+	}
+	return fmt.Sprintf("%s.%s: %s -> %s", m.Type, m.Field, m.Rule, m.Replace)
+}
+
+// WithMutationForTesting makes the validator mutate the rules of the field m targets: the field
+// named m.Field of the struct type printed as m.Type. Every rule of the field written exactly like
+// m.Rule, before aliases are expanded and whether it comes from a tag, RegisterStructRules or
+// RegisterTypeRules, is replaced by the rules of m.Replace or dropped when it is empty. A malformed
+// replacement makes Validate return an InvalidRuleError.
+//
+// It exists for mutation testing only and must not be used in production code: a mutated validator
+// accepts values its rules reject. Tests get it through lakerytest.Mutation.
+func WithMutationForTesting(m Mutation) Option {
+	return func(v *Validator) {
+		v.mutation = &m
+	}
+}
+
+// apply mutates the merged rules of a field when the mutation targets it.
+func (m *Mutation) apply(structType reflect.Type, sf reflect.StructField, rules []rule) ([]rule, error) {
+	if structType.String() != m.Type || sf.Name != m.Field {
+		return rules, nil
+	}
+	out := make([]rule, 0, len(rules))
+	for _, r := range rules {
+		if r.String() != m.Rule {
+			out = append(out, r)
+			continue
+		}
+		replaced, err := parseRules(m.Replace, r.source)
+		if err != nil {
+			return nil, fmt.Errorf("mutation %s: %w", m, err)
+		}
+		out = append(out, replaced...)
+	}
+	return out, nil
+}
@@ -0,0 +1,123 @@
+package lakery
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+)
+
+// NumberFunc converts a value of a type registered with RegisterNumberType to an exact rational number.
+// It reports false when the value has no finite numeric value, e.g. an infinite big.Float.
+type NumberFunc = func(any) (*big.Rat, bool)
+
+// RegisterNumberType makes min, max, eq, ne, gt, gte, lt, lte, money, percent, ratio and inrange treat values of typ
+// (or pointers to it) as numbers converted by fn, so arbitrary precision types work with them:
+//
+//	v.RegisterNumberType(big.Int{}, func(x any) (*big.Rat, bool) {
+//		n := x.(big.Int)
+//		return new(big.Rat).SetInt(&n), true
+//	})
+//
+// The rules compare the exact value, never a float64 approximation.
+// Registering the same type again replaces its conversion.
+func (v *Validator) RegisterNumberType(typ any, fn NumberFunc) {
+	t := reflect.TypeOf(typ)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.mustNotBeFrozen("RegisterNumberType", fmt.Sprint(t))
+	v.numberTypes[t] = fn
+	v.invalidate()
+}
+
+// number converts rv (already dereferenced) with the conversion registered for its type.
+// registered is false when its type has none; r is nil when the value is not a finite number.
+func (val *Value) number(rv reflect.Value) (r *big.Rat, registered bool) {
+	if val.validator == nil || !rv.IsValid() || !rv.CanInterface() {
+		return nil, false
+	}
+	locked := val.validator.rlock()
+	fn, ok := val.validator.numberTypes[rv.Type()]
+	val.validator.runlock(locked)
+	if !ok {
+		return nil, false
+	}
+	r, ok = fn(rv.Interface())
+	if !ok {
+		return nil, true
+	}
+	return r, true
+}
+
+// exactNumber returns rv (already dereferenced) as an exact rational number, using the conversion
+// registered for its type if there is one. ok is false when rv is not a number; r is nil when it
+// has no finite value, e.g. a NaN float.
+func (val *Value) exactNumber(rv reflect.Value) (r *big.Rat, ok bool) {
+	if r, registered := val.number(rv); registered {
+		return r, true
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Rat).SetInt64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Rat).SetUint64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return new(big.Rat).SetFloat64(rv.Float()), true
+	default:
+		return nil, false
+	}
+}
+
+// ratDecimals reports how many decimal places r has, or false if its decimal expansion is infinite.
+func ratDecimals(r *big.Rat) (int, bool) {
+	d := new(big.Int).Set(r.Denom())
+	twos, fives := 0, 0
+	two, five := big.NewInt(2), big.NewInt(5)
+	rem := new(big.Int)
+	for {
+		q, m := new(big.Int).QuoRem(d, two, rem)
+		if m.Sign() != 0 {
+			break
+		}
+		d, twos = q, twos+1
+	}
+	for {
+		q, m := new(big.Int).QuoRem(d, five, rem)
+		if m.Sign() != 0 {
+			break
+		}
+		d, fives = q, fives+1
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return 0, false
+	}
+	return max(twos, fives), true
+}
+
+// compareNumber compares the number rv (already dereferenced) with the decimal param bound and returns
+// -1, 0 or +1. Floats are compared with bound rounded to their precision, as strconv.ParseFloat at their
+// bit size would parse it, so a float64 0.1 equals eq=0.1 and a float32 1.1 equals eq=1.1; integers and
+// registered number types are compared exactly. isNumber is false when rv is not a number.
+func (val *Value) compareNumber(rv reflect.Value, bound *big.Rat) (result int, isNumber bool, err error) {
+	if _, registered := val.number(rv); !registered && (rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64) {
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, true, fmt.Errorf("should be a finite number")
+		}
+		b, _ := bound.Float64()
+		if rv.Kind() == reflect.Float32 {
+			b32, _ := bound.Float32()
+			b = float64(b32)
+		}
+		return cmp.Compare(f, b), true, nil
+	}
+	r, isNumber := val.exactNumber(rv)
+	if !isNumber {
+		return 0, false, nil
+	}
+	if r == nil {
+		return 0, true, fmt.Errorf("should be a finite number")
+	}
+	return r.Cmp(bound), true, nil
+}
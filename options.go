@@ -0,0 +1,142 @@
+package lakery
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// Option configures a Validator created by NewValidator.
+type Option func(*Validator)
+
+// debugEnv enables tracing to stderr for every validator when set to 1.
+const debugEnv = "LAKERY_DEBUG"
+
+// WithTrace makes the validator log, per field, the parsed rules, the validator function
+// resolved for each rule, its param and whether it passed. Passing nil disables tracing,
+// including tracing enabled by LAKERY_DEBUG=1.
+func WithTrace(w io.Writer) Option {
+	return func(v *Validator) {
+		v.trace = w
+	}
+}
+
+// WithTagName reads rules from the given struct tag key instead of "lakery", so teams migrating
+// from other libraries can keep their existing tags:
+//
+//	v := lakery.NewValidator(lakery.WithTagName("validate"))
+//
+// Continuation keys follow the new name (validate2, validate3, ...).
+func WithTagName(name string) Option {
+	return func(v *Validator) {
+		v.tagName = name
+	}
+}
+
+// WithTagParser parses struct tags with p instead of the lakery grammar, e.g. to validate structs
+// tagged for another library together with WithTagName:
+//
+//	v := lakery.NewValidator(lakery.WithTagName("validate"), lakery.WithTagParser(playgroundParser))
+//
+// Rules declared in Go code (RegisterTypeRules, RegisterStructRules, RegisterAlias, Var) keep the
+// lakery grammar. Passing nil restores the default parser.
+func WithTagParser(p TagParser) Option {
+	return func(v *Validator) {
+		v.tagParser = p
+	}
+}
+
+// WithCollectAll makes Validate evaluate every rule of every field and return all failures
+// as ValidationErrors instead of stopping at the first failed rule.
+func WithCollectAll() Option {
+	return func(v *Validator) {
+		v.collectAll = true
+	}
+}
+
+// WithPanicOnInvalidRule makes Validate panic with the *InvalidRuleError instead of returning it,
+// so malformed rules are caught during development and tests rather than surfacing as errors.
+func WithPanicOnInvalidRule() Option {
+	return func(v *Validator) {
+		v.panicOnInvalidRule = true
+	}
+}
+
+// WithStrictTags makes Validate return an InvalidRuleError for rules without a registered validator,
+// which are skipped by default, so typos like requird do not silently disable validation. Compile and
+// VerifyStruct report unknown tags either way.
+func WithStrictTags() Option {
+	return func(v *Validator) {
+		v.strictTags = true
+	}
+}
+
+// DefaultMaxDepth is the nesting limit of structs used unless WithMaxDepth is given.
+const DefaultMaxDepth = 100
+
+// ErrMaxDepth is wrapped by the error of Validate for structs nested deeper than the max depth.
+var ErrMaxDepth = errors.New("maximum depth exceeded")
+
+// WithMaxDepth limits how deep structs entered through dive, embedded or declared inline may be
+// nested, e.g. the nodes of a linked list or tree, so deeply nested input fails with an error
+// wrapping ErrMaxDepth instead of exhausting the stack. The root struct is at depth 0. Values
+// below 1 restore DefaultMaxDepth.
+func WithMaxDepth(n int) Option {
+	return func(v *Validator) {
+		if n < 1 {
+			n = DefaultMaxDepth
+		}
+		v.maxDepth = n
+	}
+}
+
+// WithUnexportedFields sets what happens to rules declared for unexported fields. The default is
+// UnexportedSkip; UnexportedError turns them into an InvalidRuleError so a forgotten capital letter
+// does not silently disable validation. Embedded structs are traversed either way.
+func WithUnexportedFields(policy UnexportedPolicy) Option {
+	return func(v *Validator) {
+		v.unexported = policy
+	}
+}
+
+// WithProfile selects the variant of profile rules, so bounds can differ per environment:
+//
+//	type Signup struct {
+//		Password string `lakery:"required,prod:min=12;dev:min=4;default:min=8"`
+//	}
+//
+//	v := lakery.NewValidator(lakery.WithProfile(os.Getenv("APP_ENV")))
+//
+// The default variant runs when the rule has no variant for the profile, or no profile is set;
+// without one the rule is an InvalidRuleError.
+func WithProfile(name string) Option {
+	return func(v *Validator) {
+		v.profile = name
+	}
+}
+
+// WithRulePrecedence sets the order of rule sources from the highest to the lowest precedence.
+// The default is RuleSourceProgrammatic, RuleSourceTag, RuleSourceType. Sources left out are ignored,
+// e.g. WithRulePrecedence(RuleSourceTag) only evaluates struct tags.
+func WithRulePrecedence(sources ...RuleSource) Option {
+	return func(v *Validator) {
+		v.precedence = append([]RuleSource(nil), sources...)
+	}
+}
+
+// WithRuleMerge sets how rules of a field declared by several sources are combined. The default is MergeAppend.
+func WithRuleMerge(merge RuleMerge) Option {
+	return func(v *Validator) {
+		v.merge = merge
+	}
+}
+
+// applyOptions applies environment defaults first, so explicit options take precedence.
+func (v *Validator) applyOptions(opts []Option) {
+	if os.Getenv(debugEnv) == "1" {
+		v.trace = os.Stderr
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+}
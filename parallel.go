@@ -0,0 +1,117 @@
+package lakery
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// minParallelElements is the number of elements from which WithParallelEach validates concurrently,
+// smaller collections are not worth the goroutines.
+const minParallelElements = 512
+
+// WithParallelEach validates the elements checked with each={...} on up to workers goroutines when
+// a slice or array has at least 512 of them, e.g. the rows of a bulk import:
+//
+//	v := lakery.NewValidator(lakery.WithParallelEach(runtime.GOMAXPROCS(0)))
+//
+// Failures are merged in element order, so Validate returns the same errors as without it: the failure
+// of the first invalid element, or all failures in collect-all mode. Elements after the first failure
+// may have been validated nonetheless. Validators and field hooks run for elements must be safe for
+// concurrent use; Value.Store, Value.Load and Value.Update are. Collections nested in elements are validated
+// sequentially. Values below 2 disable parallel validation.
+func WithParallelEach(workers int) Option {
+	return func(v *Validator) {
+		v.parallelEach = workers
+	}
+}
+
+// runEachParallel validates the elements of value at indexes like runEach, splitting them into
+// contiguous chunks validated by workers, and merges their outcome in element order.
+func (vs *validation) runEachParallel(parent reflect.Value, fieldType reflect.StructField, namespace string, value reflect.Value, inner []rule, indexes []int) bool {
+	workers := min(vs.v.parallelEach, len(indexes))
+	// created upfront, workers share it
+	if vs.state == nil {
+		vs.state = &callState{}
+	}
+	// position in indexes of the earliest element which stopped validation; later elements
+	// are skipped, earlier ones still run so the first failure is the same as sequentially
+	var stop atomic.Int64
+	stop.Store(int64(len(indexes)))
+	subs := make([]*validation, workers)
+	panics := make([]any, workers)
+	var wg sync.WaitGroup
+	for w := range subs {
+		lo, hi := w*len(indexes)/workers, (w+1)*len(indexes)/workers
+		sub := vs.fork()
+		subs[w] = sub
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// re-raised on the calling goroutine, e.g. for WithPanicOnInvalidRule
+			defer func() { panics[w] = recover() }()
+			for pos := lo; pos < hi && int64(pos) < stop.Load(); pos++ {
+				i := indexes[pos]
+				if !sub.runRules(parent, fieldType, fmt.Sprintf("%s[%d]", namespace, i), value.Index(i), inner) {
+					lowerStop(&stop, pos)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for w, sub := range subs {
+		if panics[w] != nil {
+			panic(panics[w])
+		}
+		if !vs.join(sub) {
+			return false
+		}
+	}
+	return true
+}
+
+// lowerStop lowers stop to pos unless an earlier element stopped validation already.
+func lowerStop(stop *atomic.Int64, pos int) {
+	for {
+		cur := stop.Load()
+		if int64(pos) >= cur || stop.CompareAndSwap(cur, int64(pos)) {
+			return
+		}
+	}
+}
+
+// fork returns a validation for a worker of runEachParallel, sharing the state of the call.
+func (vs *validation) fork() *validation {
+	return &validation{
+		v:       vs.v,
+		ctx:     vs.ctx,
+		include: vs.include,
+		state:   vs.state,
+		types:   vs.types,
+		hooks:   vs.hooks,
+		depth:   vs.depth,
+		entered: slices.Clip(vs.entered),
+		worker:  true,
+	}
+}
+
+// join merges the outcome of a worker into vs and reports whether validation should go on.
+func (vs *validation) join(sub *validation) bool {
+	vs.errs = append(vs.errs, sub.errs...)
+	vs.coverage = append(vs.coverage, sub.coverage...)
+	switch {
+	case sub.ctxErr != nil:
+		vs.ctxErr = sub.ctxErr
+	case sub.depthErr != nil:
+		vs.depthErr = sub.depthErr
+	case sub.invalid != nil:
+		vs.invalid = sub.invalid
+	case len(sub.errs) > 0 && !vs.v.collectAll:
+	default:
+		return true
+	}
+	return false
+}
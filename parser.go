@@ -0,0 +1,86 @@
+package lakery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TagParser parses the rules of a struct tag, so tags written in another grammar, e.g. in the style of
+// go-playground/validator or as JSON, can be validated without rewriting them, see WithTagParser.
+//
+// Parsed rules use the keys of registered validators and special tags; the params of each, keys and
+// values hold the nested rules in the lakery grammar, like Rule.String formats them.
+type TagParser interface {
+	ParseRules(tag string) ([]Rule, error)
+}
+
+// TagParserFunc adapts a function to TagParser.
+type TagParserFunc func(tag string) ([]Rule, error)
+
+func (f TagParserFunc) ParseRules(tag string) ([]Rule, error) {
+	return f(tag)
+}
+
+// DefaultTagParser returns the parser of the lakery grammar, used by Validate unless WithTagParser
+// is given and by ParseRules. Custom parsers may delegate to it, e.g. to accept both grammars.
+func DefaultTagParser() TagParser {
+	return TagParserFunc(ParseRules)
+}
+
+// parseTag parses the rules of a struct tag with the parser of the validator.
+func (v *Validator) parseTag(tag string) ([]rule, error) {
+	if v.tagParser == nil {
+		return parseRules(tag, RuleSourceTag)
+	}
+	if tag == "" {
+		return nil, nil
+	}
+	parsed, err := v.tagParser.ParseRules(tag)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]rule, len(parsed))
+	for i, r := range parsed {
+		if rules[i], err = importRule(r, RuleSourceTag); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// importRule converts a rule returned by a TagParser, checking it the way parseRules checks tags.
+func importRule(r Rule, source RuleSource) (rule, error) {
+	switch {
+	case r.Profiles != nil:
+		out := rule{source: source, profiles: make(map[string]rule, len(r.Profiles))}
+		for name, pr := range r.Profiles {
+			if !isProfileName(name) || pr.Profiles != nil {
+				return rule{}, fmt.Errorf("malformed profile rule %q", r)
+			}
+			imported, err := importRule(pr, source)
+			if err != nil {
+				return rule{}, err
+			}
+			out.profiles[name] = imported
+		}
+		out.key = r.String()
+		return out, nil
+	case r.Alternatives != nil:
+		out := rule{source: source, alts: make([]rule, len(r.Alternatives))}
+		for i, alt := range r.Alternatives {
+			if alt.Alternatives != nil || alt.Profiles != nil || strings.TrimSpace(alt.Key) == "" {
+				return rule{}, fmt.Errorf("malformed alternative in %q", r)
+			}
+			if isSpecialTag(alt.Key) {
+				return rule{}, fmt.Errorf("%s cannot be used in alternatives: %q", alt.Key, r)
+			}
+			out.alts[i] = rule{key: alt.Key, param: alt.Param, source: source}
+		}
+		out.key = r.String()
+		return out, nil
+	case strings.TrimSpace(r.Key) == "":
+		return rule{}, fmt.Errorf("rule without key: %q", r)
+	default:
+		return rule{key: r.Key, param: r.Param, source: source}, nil
+	}
+}
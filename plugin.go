@@ -0,0 +1,80 @@
+package lakery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Plugin is a pack of validators shipped as an importable module, e.g. the ID formats or tenant rules
+// of an organization, which describes its tags like the builtins:
+//
+//	v := lakery.NewValidator()
+//	v.Install(companyids.Plugin(), tenants.Plugin(cfg))
+//
+// Specs feed Validator.Specs and, written with WriteSpecs, lakery-validate check -specs.
+type Plugin interface {
+	// Name identifies the plugin, e.g. the import path of its module.
+	Name() string
+	// Register registers the tags, sets, aliases and types of the plugin.
+	Register(v *Validator)
+	// Specs describes the tags registered by Register.
+	Specs() []BuiltinSpec
+}
+
+// Install registers plugins in order and records their specs. Installing two plugins with the same
+// name panics, like registering on a frozen validator, since that is a programming error.
+func (v *Validator) Install(plugins ...Plugin) {
+	for _, p := range plugins {
+		name := p.Name()
+		v.mu.Lock()
+		v.mustNotBeFrozen("Install", name)
+		for _, installed := range v.plugins {
+			if installed.Name() == name {
+				v.mu.Unlock()
+				panic(fmt.Sprintf("lakery: Install: plugin %q is already installed", name))
+			}
+		}
+		v.plugins = append(v.plugins, p)
+		v.mu.Unlock()
+		p.Register(v)
+	}
+}
+
+// Specs describes the tags available with v, the builtin tags and the tags of the installed
+// plugins, sorted by name. Plugin tags are marked with the name of their plugin and default
+// to the tag as code, like FieldError.Code.
+func (v *Validator) Specs() []BuiltinSpec {
+	v.mu.RLock()
+	plugins := append([]Plugin(nil), v.plugins...)
+	v.mu.RUnlock()
+	specs := BuiltinSpecs()
+	for _, p := range plugins {
+		for _, spec := range p.Specs() {
+			spec.Plugin = p.Name()
+			if spec.Code == "" {
+				spec.Code = spec.Name
+			}
+			specs = append(specs, spec)
+		}
+	}
+	sort.SliceStable(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
+
+// WriteSpecs writes specs as indented JSON, e.g. from a go:generate step of a plugin module.
+func WriteSpecs(w io.Writer, specs []BuiltinSpec) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(specs)
+}
+
+// ReadSpecs decodes specs written by WriteSpecs.
+func ReadSpecs(r io.Reader) ([]BuiltinSpec, error) {
+	var specs []BuiltinSpec
+	if err := json.NewDecoder(r).Decode(&specs); err != nil {
+		return nil, fmt.Errorf("cannot read specs: %w", err)
+	}
+	return specs, nil
+}
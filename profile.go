@@ -0,0 +1,84 @@
+package lakery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isProfileRule reports whether a rule selects its variant by profile, e.g. prod:min=12;dev:min=4.
+// The profile name ends at the first colon, which must come before any =, | or brace. Special tags
+// are not profile names, each:{} is a misspelled each={}.
+func isProfileRule(part string) bool {
+	i := strings.IndexAny(part, ":=|{")
+	return i > 0 && part[i] == ':' && !isSpecialTag(strings.TrimSpace(part[:i]))
+}
+
+// parseProfileRule parses a rule like prod:min=12;dev:min=4 into the rule of every profile.
+func parseProfileRule(part string, source RuleSource) (rule, error) {
+	variants, err := splitTopLevel(part, ';')
+	if err != nil {
+		return rule{}, err
+	}
+	r := rule{source: source, profiles: make(map[string]rule, len(variants))}
+	keys := make([]string, len(variants))
+	for i, variant := range variants {
+		name, body, ok := strings.Cut(variant, ":")
+		name, body = strings.TrimSpace(name), strings.TrimSpace(body)
+		if !ok || !isProfileName(name) || body == "" {
+			return rule{}, fmt.Errorf("malformed profile rule %q, expected profile:rule;...", part)
+		}
+		if _, dup := r.profiles[name]; dup {
+			return rule{}, fmt.Errorf("profile %s is repeated in %q", name, part)
+		}
+		pr, err := parsePart(body, source)
+		if err != nil {
+			return rule{}, err
+		}
+		r.profiles[name] = pr
+		keys[i] = name + ":" + pr.String()
+	}
+	r.key = strings.Join(keys, ";")
+	return r, nil
+}
+
+func isProfileName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultProfile names the variant of a profile rule used for profiles without their own variant
+// and for validators without a profile, e.g. prod:min=12;default:min=8.
+const defaultProfile = "default"
+
+// resolveProfiles replaces profile rules with the rule of the validator's profile, or the default
+// variant, keeping their source. A rule with neither fails, rather than dropping a rule the profile
+// may rely on, e.g. prod:min=12;dev:min=4 on a validator without a profile.
+func (v *Validator) resolveProfiles(rules []rule) ([]rule, error) {
+	out := rules[:0:0]
+	for _, r := range rules {
+		if r.profiles == nil {
+			out = append(out, r)
+			continue
+		}
+		pr, ok := r.profiles[v.profile]
+		if !ok || v.profile == "" {
+			pr, ok = r.profiles[defaultProfile]
+		}
+		if !ok {
+			if v.profile == "" {
+				return nil, fmt.Errorf("profile rule %q needs a default variant or a profile set with WithProfile", r)
+			}
+			return nil, fmt.Errorf("profile rule %q has no variant for profile %s and no default variant", r, v.profile)
+		}
+		pr.source = r.source
+		out = append(out, pr)
+	}
+	return out, nil
+}
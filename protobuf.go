@@ -0,0 +1,105 @@
+package lakery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// ProtoRules holds sidecar rules for protobuf messages keyed by message full name
+// ("acme.v1.User") and then by field. Fields may be referenced by their Go name (UserName)
+// or their proto name (user_name).
+type ProtoRules map[string]map[string]string
+
+// LoadProtoRules decodes sidecar rules from JSON:
+//
+//	{"acme.v1.User": {"user_name": "required,min=3", "email": "required"}}
+func LoadProtoRules(r io.Reader) (ProtoRules, error) {
+	var rules ProtoRules
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, fmt.Errorf("cannot decode proto rules: %w", err)
+	}
+	return rules, nil
+}
+
+// WithProtobuf enables validation of protoc-gen-go structs:
+//   - internal message state (state, sizeCache, unknownFields) is skipped
+//   - google.protobuf wrapper fields (*wrapperspb.StringValue, ...) are validated by their
+//     wrapped value; a nil wrapper behaves like a nil pointer
+//   - rules from the sidecar are applied to message fields as programmatic rules, since
+//     generated structs cannot carry lakery tags; rules may be nil
+func WithProtobuf(rules ProtoRules) Option {
+	return func(v *Validator) {
+		v.protobuf = true
+		v.protoRules = rules
+	}
+}
+
+// protoWrapperTypes are the well-known wrapper messages unwrapped in protobuf mode.
+var protoWrapperTypes = map[string]bool{
+	"google.protobuf.DoubleValue": true,
+	"google.protobuf.FloatValue":  true,
+	"google.protobuf.Int64Value":  true,
+	"google.protobuf.UInt64Value": true,
+	"google.protobuf.Int32Value":  true,
+	"google.protobuf.UInt32Value": true,
+	"google.protobuf.BoolValue":   true,
+	"google.protobuf.StringValue": true,
+	"google.protobuf.BytesValue":  true,
+}
+
+// isProtoInternalField reports whether sf holds protobuf implementation state.
+func isProtoInternalField(sf reflect.StructField) bool {
+	if sf.IsExported() {
+		return false
+	}
+	switch sf.Name {
+	case "state", "sizeCache", "unknownFields", "extensionFields", "weakFields":
+		return true
+	}
+	return false
+}
+
+// unwrapProto replaces a pointer to a wrapper message with a pointer to the wrapped value.
+func (v *Validator) unwrapProto(rv reflect.Value) reflect.Value {
+	if rv.Kind() != reflect.Pointer || !protoWrapperTypes[v.protoMessageName(rv.Type().Elem())] {
+		return rv
+	}
+	inner, ok := rv.Type().Elem().FieldByName("Value")
+	if !ok {
+		return rv
+	}
+	if rv.IsNil() {
+		return reflect.Zero(reflect.PointerTo(inner.Type))
+	}
+	ptr := reflect.New(inner.Type)
+	ptr.Elem().Set(rv.Elem().FieldByIndex(inner.Index))
+	return ptr
+}
+
+// protoFieldRules returns sidecar rules for a field of a generated message.
+func (v *Validator) protoFieldRules(structType reflect.Type, sf reflect.StructField) string {
+	if v.protoRules == nil {
+		return ""
+	}
+	fields := v.protoRules[v.protoMessageName(structType)]
+	if fields == nil {
+		return ""
+	}
+	if rules, ok := fields[sf.Name]; ok {
+		return rules
+	}
+	return fields[protoFieldName(sf)]
+}
+
+// protoFieldName extracts name=... from the protobuf struct tag, e.g. `protobuf:"bytes,1,opt,name=user_name,proto3"`.
+func protoFieldName(sf reflect.StructField) string {
+	for _, part := range strings.Split(sf.Tag.Get("protobuf"), ",") {
+		if name, ok := strings.CutPrefix(part, "name="); ok {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+//go:build !lakery_tiny
+
+package lakery
+
+import "reflect"
+
+// protoMessageName returns the full name of a generated message struct type, or "" for other types.
+// The name is read through ProtoReflect().Descriptor().FullName() without depending on the protobuf module.
+func (v *Validator) protoMessageName(t reflect.Type) string {
+	if cached, ok := v.protoNames.Load(t); ok {
+		return cached.(string)
+	}
+	name := ""
+	if t.Kind() == reflect.Struct {
+		if m := reflect.New(t).MethodByName("ProtoReflect"); m.IsValid() && m.Type().NumIn() == 0 && m.Type().NumOut() == 1 {
+			name = callStringChain(m, "Descriptor", "FullName")
+		}
+	}
+	v.protoNames.Store(t, name)
+	return name
+}
+
+// callStringChain calls fn and then the named no-argument methods on each result, returning the final string.
+func callStringChain(fn reflect.Value, methods ...string) string {
+	rv := fn.Call(nil)[0]
+	for _, name := range methods {
+		m := rv.MethodByName(name)
+		if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+			return ""
+		}
+		rv = m.Call(nil)[0]
+	}
+	if rv.Kind() != reflect.String {
+		return ""
+	}
+	return rv.String()
+}
@@ -0,0 +1,12 @@
+//go:build lakery_tiny
+
+package lakery
+
+import "reflect"
+
+// protoMessageName finds no messages in the lakery_tiny build profile: TinyGo cannot look up and
+// call ProtoReflect through reflection, so WithProtobuf skips internal message state only, without
+// unwrapping wrapper fields or applying sidecar rules.
+func (v *Validator) protoMessageName(reflect.Type) string {
+	return ""
+}
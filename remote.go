@@ -0,0 +1,220 @@
+package lakery
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RemoteFunc checks a value against an external service, e.g. a username availability API.
+// It reports whether the value is accepted; a non-nil error means the check itself failed
+// and is handled according to the FailurePolicy.
+type RemoteFunc = func(ctx context.Context, value string) (bool, error)
+
+// Cache configures caching of remote check results. Both accepted and rejected values are
+// cached; failed checks are not. The zero value disables caching.
+type Cache struct {
+	// how long a result is reused
+	TTL time.Duration
+	// maximum number of cached values, 0 means unlimited; expired entries are evicted first
+	MaxEntries int
+}
+
+// RateLimit limits how often the remote service is called with a token bucket.
+// Checks wait for a token, honoring the validation context. The zero value disables limiting.
+type RateLimit struct {
+	// calls per second
+	Rate float64
+	// calls allowed at once, at least 1
+	Burst int
+}
+
+// FailurePolicy decides the outcome of a validation when the remote check fails.
+type FailurePolicy int
+
+const (
+	// FailClosed rejects the value when the remote check fails.
+	FailClosed FailurePolicy = iota
+	// FailOpen accepts the value when the remote check fails, e.g. for non-critical checks.
+	FailOpen
+)
+
+// RemoteValidator wraps a check calling an external service into a validator, so teams don't
+// have to hand-roll caching, rate limiting and failure handling:
+//
+//	v.RegisterTag("username_available", lakery.RemoteValidator(checkUsername,
+//		lakery.Cache{TTL: time.Minute}, lakery.RateLimit{Rate: 50, Burst: 10}, lakery.FailClosed))
+//
+// Concurrent checks of the same value share a single call; when the context of the caller making
+// it ends first, the others check the value again with their own. Empty values and nil pointers are
+// not checked, use required to reject them. The context passed to ValidateCtx is used for calls.
+func RemoteValidator(fn RemoteFunc, cache Cache, limit RateLimit, policy FailurePolicy) TagValidationFunc {
+	r := &remote{
+		fn:       fn,
+		cache:    cache,
+		limit:    limit,
+		policy:   policy,
+		results:  make(map[string]remoteResult),
+		inflight: make(map[string]*remoteCall),
+		tokens:   float64(max(limit.Burst, 1)),
+		now:      time.Now,
+	}
+	return r.validate
+}
+
+type remoteResult struct {
+	ok      bool
+	expires time.Time
+}
+
+// remoteCall is an in-flight check shared by concurrent validations of the same value.
+type remoteCall struct {
+	done chan struct{}
+	ok   bool
+	err  error
+	// the result belongs to the caller who made the call, not to the checked value: its context
+	// ended or fn panicked, so waiters check the value again
+	retry bool
+}
+
+type remote struct {
+	fn     RemoteFunc
+	cache  Cache
+	limit  RateLimit
+	policy FailurePolicy
+
+	mu       sync.Mutex
+	results  map[string]remoteResult
+	inflight map[string]*remoteCall
+	// token bucket state
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func (r *remote) validate(val *Value) error {
+	rv := val.Deref().val
+	if !rv.IsValid() {
+		return nil
+	}
+	s := fmt.Sprint(rv)
+	if s == "" {
+		return nil
+	}
+	ok, err := r.check(val.Context(), s)
+	if err != nil {
+		if r.policy == FailOpen {
+			return nil
+		}
+		return InternalError(fmt.Errorf("could not be checked: %w", err))
+	}
+	if !ok {
+		return fmt.Errorf("is not accepted")
+	}
+	return nil
+}
+
+func (r *remote) check(ctx context.Context, s string) (bool, error) {
+	for {
+		r.mu.Lock()
+		if res, ok := r.results[s]; ok {
+			if r.now().Before(res.expires) {
+				r.mu.Unlock()
+				return res.ok, nil
+			}
+			delete(r.results, s)
+		}
+		call, ok := r.inflight[s]
+		if !ok {
+			call = &remoteCall{done: make(chan struct{}), retry: true}
+			r.inflight[s] = call
+			r.mu.Unlock()
+			return r.lead(ctx, s, call)
+		}
+		r.mu.Unlock()
+		select {
+		case <-call.done:
+			if !call.retry {
+				return call.ok, call.err
+			}
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// lead makes the call shared by the concurrent checks of s. The call is released even when fn
+// panics, the panic is then reported by callValidator to this caller only.
+func (r *remote) lead(ctx context.Context, s string, call *remoteCall) (bool, error) {
+	defer func() {
+		r.mu.Lock()
+		delete(r.inflight, s)
+		if !call.retry && call.err == nil && r.cache.TTL > 0 {
+			r.store(s, call.ok)
+		}
+		r.mu.Unlock()
+		close(call.done)
+	}()
+	call.ok, call.err = r.call(ctx, s)
+	call.retry = call.err != nil && ctx.Err() != nil
+	return call.ok, call.err
+}
+
+func (r *remote) call(ctx context.Context, s string) (bool, error) {
+	if err := r.wait(ctx); err != nil {
+		return false, err
+	}
+	return r.fn(ctx, s)
+}
+
+// store caches a result, evicting expired entries (and then arbitrary ones) when the cache is full.
+// Called with mu held.
+func (r *remote) store(s string, ok bool) {
+	now := r.now()
+	if r.cache.MaxEntries > 0 && len(r.results) >= r.cache.MaxEntries {
+		for k, res := range r.results {
+			if !now.Before(res.expires) {
+				delete(r.results, k)
+			}
+		}
+		for k := range r.results {
+			if len(r.results) < r.cache.MaxEntries {
+				break
+			}
+			delete(r.results, k)
+		}
+	}
+	r.results[s] = remoteResult{ok: ok, expires: now.Add(r.cache.TTL)}
+}
+
+// wait takes a token from the bucket, sleeping until one is available or ctx is done.
+func (r *remote) wait(ctx context.Context) error {
+	if r.limit.Rate <= 0 {
+		return nil
+	}
+	burst := float64(max(r.limit.Burst, 1))
+	for {
+		r.mu.Lock()
+		now := r.now()
+		if !r.last.IsZero() {
+			r.tokens = min(burst, r.tokens+now.Sub(r.last).Seconds()*r.limit.Rate)
+		}
+		r.last = now
+		if r.tokens >= 1 {
+			r.tokens--
+			r.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - r.tokens) / r.limit.Rate * float64(time.Second))
+		r.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
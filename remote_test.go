@@ -0,0 +1,143 @@
+package lakery_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/trofkm/lakery"
+)
+
+var _ = Describe("RemoteValidator", func() {
+	type S struct {
+		Username string `lakery:"available"`
+	}
+	taken := func(calls *atomic.Int32) lakery.RemoteFunc {
+		return func(_ context.Context, value string) (bool, error) {
+			calls.Add(1)
+			return value != "admin", nil
+		}
+	}
+
+	It("accepts and rejects values", func() {
+		var calls atomic.Int32
+		v := lakery.NewValidator()
+		v.RegisterTag("available", lakery.RemoteValidator(taken(&calls), lakery.Cache{}, lakery.RateLimit{}, lakery.FailClosed))
+		Expect(v.Validate(S{Username: "john"})).To(Succeed())
+		Expect(v.Validate(S{Username: "admin"})).To(MatchError(ContainSubstring("is not accepted")))
+		Expect(v.Validate(S{})).To(Succeed())
+		Expect(calls.Load()).To(BeEquivalentTo(2))
+	})
+
+	It("caches results for the TTL", func() {
+		var calls atomic.Int32
+		v := lakery.NewValidator()
+		v.RegisterTag("available", lakery.RemoteValidator(taken(&calls), lakery.Cache{TTL: 50 * time.Millisecond}, lakery.RateLimit{}, lakery.FailClosed))
+		for i := 0; i < 3; i++ {
+			Expect(v.Validate(S{Username: "admin"})).To(HaveOccurred())
+		}
+		Expect(calls.Load()).To(BeEquivalentTo(1))
+		time.Sleep(60 * time.Millisecond)
+		Expect(v.Validate(S{Username: "admin"})).To(HaveOccurred())
+		Expect(calls.Load()).To(BeEquivalentTo(2))
+	})
+
+	It("coalesces identical in-flight checks", func() {
+		var calls atomic.Int32
+		release := make(chan struct{})
+		fn := func(ctx context.Context, value string) (bool, error) {
+			calls.Add(1)
+			<-release
+			return true, nil
+		}
+		v := lakery.NewValidator()
+		v.RegisterTag("available", lakery.RemoteValidator(fn, lakery.Cache{}, lakery.RateLimit{}, lakery.FailClosed))
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Expect(v.Validate(S{Username: "john"})).To(Succeed())
+			}()
+		}
+		Eventually(calls.Load).Should(BeEquivalentTo(1))
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		Expect(calls.Load()).To(BeEquivalentTo(1))
+	})
+
+	It("releases coalesced checks when the call panics", func() {
+		var calls atomic.Int32
+		fn := func(context.Context, string) (bool, error) {
+			if calls.Add(1) == 1 {
+				panic("boom")
+			}
+			return true, nil
+		}
+		v := lakery.NewValidator()
+		v.RegisterTag("available", lakery.RemoteValidator(fn, lakery.Cache{}, lakery.RateLimit{}, lakery.FailClosed))
+		Expect(v.Validate(S{Username: "john"})).To(MatchError(ContainSubstring("validator panicked: boom")))
+		done := make(chan error)
+		go func() { done <- v.Validate(S{Username: "john"}) }()
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+	It("checks again for waiters when the caller of the shared call is canceled", func() {
+		var calls atomic.Int32
+		started := make(chan struct{})
+		fn := func(ctx context.Context, value string) (bool, error) {
+			if calls.Add(1) == 1 {
+				close(started)
+				<-ctx.Done()
+				return false, ctx.Err()
+			}
+			return true, nil
+		}
+		v := lakery.NewValidator()
+		v.RegisterTag("available", lakery.RemoteValidator(fn, lakery.Cache{}, lakery.RateLimit{}, lakery.FailClosed))
+		ctx, cancel := context.WithCancel(context.Background())
+		leader := make(chan error)
+		go func() { leader <- v.ValidateCtx(ctx, S{Username: "john"}) }()
+		<-started
+		waiter := make(chan error)
+		go func() { waiter <- v.Validate(S{Username: "john"}) }()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		Eventually(leader).Should(Receive(MatchError(context.Canceled)))
+		Eventually(waiter).Should(Receive(BeNil()))
+		Expect(calls.Load()).To(BeEquivalentTo(2))
+	})
+
+	It("applies the failure policy", func() {
+		fn := func(context.Context, string) (bool, error) {
+			return false, errors.New("service unavailable")
+		}
+		closed := lakery.NewValidator()
+		closed.RegisterTag("available", lakery.RemoteValidator(fn, lakery.Cache{}, lakery.RateLimit{}, lakery.FailClosed))
+		Expect(closed.Validate(S{Username: "john"})).To(MatchError(ContainSubstring("could not be checked: service unavailable")))
+
+		open := lakery.NewValidator()
+		open.RegisterTag("available", lakery.RemoteValidator(fn, lakery.Cache{}, lakery.RateLimit{}, lakery.FailOpen))
+		Expect(open.Validate(S{Username: "john"})).To(Succeed())
+	})
+
+	It("rate limits calls and honors the context while waiting", func() {
+		var calls atomic.Int32
+		v := lakery.NewValidator()
+		v.RegisterTag("available", lakery.RemoteValidator(taken(&calls), lakery.Cache{}, lakery.RateLimit{Rate: 1, Burst: 1}, lakery.FailClosed))
+		Expect(v.Validate(S{Username: "john"})).To(Succeed())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err := v.ValidateCtx(ctx, S{Username: "jane"})
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(calls.Load()).To(BeEquivalentTo(1))
+	})
+})
@@ -0,0 +1,380 @@
+package lakery
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// RuleSource identifies where a rule of a field was declared.
+type RuleSource int
+
+const (
+	// rules attached to the field type with RegisterTypeRules
+	RuleSourceType RuleSource = iota
+	// rules from the struct tag
+	RuleSourceTag
+	// rules registered for struct fields with RegisterStructRules or protobuf sidecar rules
+	RuleSourceProgrammatic
+)
+
+func (s RuleSource) String() string {
+	switch s {
+	case RuleSourceType:
+		return "type"
+	case RuleSourceTag:
+		return "tag"
+	case RuleSourceProgrammatic:
+		return "programmatic"
+	default:
+		return fmt.Sprintf("RuleSource(%d)", int(s))
+	}
+}
+
+// defaultPrecedence lists rule sources from the highest to the lowest precedence.
+var defaultPrecedence = []RuleSource{RuleSourceProgrammatic, RuleSourceTag, RuleSourceType}
+
+// RuleMerge controls how rules of a field declared by several sources are combined.
+type RuleMerge int
+
+const (
+	// MergeAppend runs the rules of all sources, lowest precedence first. When several sources
+	// declare a rule with the same key, only the one from the source with the highest precedence is kept.
+	// A required rule runs before the omitempty of another source, so empty values still fail it.
+	MergeAppend RuleMerge = iota
+	// MergeReplace runs only the rules of the source with the highest precedence which declares any rules for the field.
+	MergeReplace
+)
+
+// UnexportedPolicy controls what happens to rules declared for unexported fields, which
+// validators cannot read through Value.Interface.
+type UnexportedPolicy int
+
+const (
+	// UnexportedSkip silently ignores the rules of unexported fields.
+	UnexportedSkip UnexportedPolicy = iota
+	// UnexportedError reports rules of unexported fields as an InvalidRuleError.
+	UnexportedError
+)
+
+// rule is a single parsed rule like min=3. For alternatives like email|uuid, alts holds
+// the alternatives and key the whole rule; for profile rules like prod:min=12;dev:min=4,
+// profiles holds the rule per profile and key the whole rule.
+type rule struct {
+	key      string
+	param    string
+	source   RuleSource
+	alts     []rule
+	profiles map[string]rule
+	// set by compileRules for the rules of struct fields, so validation doesn't look them up again
+	compiled bool
+	// validator of key, nil for special and unknown tags
+	fn TagValidationFunc
+	// parsed rules of each={...}, keys={...} and values={...}
+	inner    []rule
+	innerErr error
+}
+
+func (r rule) String() string {
+	if r.param == "" {
+		return r.key
+	}
+	return r.key + "=" + r.param
+}
+
+// Rule is a parsed rule of a tag, e.g. min=3. Rules joined with | (email|uuid) have
+// no key and list their Alternatives instead; profile rules (prod:min=12;dev:min=4)
+// have no key and list the rule of every profile in Profiles.
+type Rule struct {
+	Key          string
+	Param        string
+	Alternatives []Rule
+	Profiles     map[string]Rule
+}
+
+func (r Rule) String() string {
+	if r.Profiles != nil {
+		names := make([]string, 0, len(r.Profiles))
+		for name := range r.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		variants := make([]string, len(names))
+		for i, name := range names {
+			variants[i] = name + ":" + r.Profiles[name].String()
+		}
+		return strings.Join(variants, ";")
+	}
+	if r.Alternatives != nil {
+		alts := make([]string, len(r.Alternatives))
+		for i, alt := range r.Alternatives {
+			alts[i] = alt.String()
+		}
+		return strings.Join(alts, "|")
+	}
+	if r.Param == "" {
+		return r.Key
+	}
+	return r.Key + "=" + r.Param
+}
+
+// ParseRules parses a rule string like "required,min=3,email|uuid" the way Validate does,
+// reporting malformed rules such as unbalanced braces or empty alternatives.
+// Rules nested in braces (each={...}) are kept as the param of their rule. It is the default
+// TagParser, see DefaultTagParser.
+func ParseRules(rules string) ([]Rule, error) {
+	parsed, err := parseRules(rules, RuleSourceTag)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Rule, len(parsed))
+	for i, r := range parsed {
+		out[i] = exportRule(r)
+	}
+	return out, nil
+}
+
+func exportRule(r rule) Rule {
+	if r.profiles != nil {
+		profiles := make(map[string]Rule, len(r.profiles))
+		for name, pr := range r.profiles {
+			profiles[name] = exportRule(pr)
+		}
+		return Rule{Profiles: profiles}
+	}
+	if r.alts == nil {
+		return Rule{Key: r.key, Param: r.param}
+	}
+	alts := make([]Rule, len(r.alts))
+	for i, alt := range r.alts {
+		alts[i] = exportRule(alt)
+	}
+	return Rule{Alternatives: alts}
+}
+
+// EffectiveRule is a rule which will run for a field, together with the source it came from.
+type EffectiveRule struct {
+	Field  string
+	Rule   string
+	Source RuleSource
+}
+
+// RegisterStructRules declares rules for fields of a struct type without touching its tags,
+// e.g. for types from other packages:
+//
+//	v.RegisterStructRules(api.User{}, map[string]string{"Name": "required,min=3"})
+//
+// It panics if s is not a struct or a field does not exist, since that is a programming error.
+func (v *Validator) RegisterStructRules(s any, rules map[string]string) {
+	typ := reflect.TypeOf(s)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.mustNotBeFrozen("RegisterStructRules", fmt.Sprint(typ))
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("lakery: RegisterStructRules expects a struct, got %v", typ))
+	}
+	fields := make(map[string]string, len(rules))
+	for name, r := range rules {
+		if _, ok := typ.FieldByName(name); !ok {
+			panic(fmt.Sprintf("lakery: RegisterStructRules: %s has no field %q", typ, name))
+		}
+		fields[name] = r
+	}
+	v.structRules[typ] = fields
+	v.invalidate()
+}
+
+// Explain lists the effective rules of every field of s in execution order together with
+// the source each rule came from, according to the configured precedence and merge strategy.
+func (v *Validator) Explain(s any) ([]EffectiveRule, error) {
+	typ := reflect.TypeOf(s)
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("can only explain structs, got %v", typ)
+	}
+	var out []EffectiveRule
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if v.skipped(sf) {
+			continue
+		}
+		rules, err := v.fieldRules(typ, sf)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", typ, sf.Name, err)
+		}
+		for _, r := range rules {
+			out = append(out, EffectiveRule{Field: sf.Name, Rule: r.String(), Source: r.source})
+		}
+	}
+	return out, nil
+}
+
+// fieldRules collects the rules of a field from all sources and merges them.
+// Unexported fields other than embedded structs have no rules, see WithUnexportedFields.
+func (v *Validator) fieldRules(structType reflect.Type, sf reflect.StructField) ([]rule, error) {
+	rules, err := v.mergedRules(structType, sf)
+	if sf.IsExported() || sf.Anonymous {
+		return rules, err
+	}
+	if v.unexported == UnexportedError && (len(rules) > 0 || err != nil) {
+		return nil, configErrorf("rules of unexported field %s cannot be evaluated", sf.Name)
+	}
+	return nil, nil
+}
+
+// skipped reports whether a field is excluded from validation with lakery:"-" or declares
+// rules of the whole struct, see isStructRulesField.
+func (v *Validator) skipped(sf reflect.StructField) bool {
+	return isStructRulesField(sf) || TagRules(sf.Tag, v.tagName) == skipTag
+}
+
+func (v *Validator) mergedRules(structType reflect.Type, sf reflect.StructField) ([]rule, error) {
+	locked := v.rlock()
+	programmatic, ok := v.structRules[structType][sf.Name]
+	typeRules := v.rulesForType(sf.Type)
+	v.runlock(locked)
+	if !ok && v.protobuf {
+		programmatic = v.protoFieldRules(structType, sf)
+	}
+	declared := map[RuleSource]string{
+		RuleSourceType: typeRules,
+		// "lakery:..." tag, possibly continued in "lakery2:...", "lakery3:..."
+		RuleSourceTag:          TagRules(sf.Tag, v.tagName),
+		RuleSourceProgrammatic: programmatic,
+	}
+	// parsed rules per source, in precedence order (highest first)
+	bySource := make([][]rule, 0, len(v.precedence))
+	for _, source := range v.precedence {
+		var rules []rule
+		var err error
+		if source == RuleSourceTag {
+			rules, err = v.parseTag(declared[source])
+		} else {
+			rules, err = parseRules(declared[source], source)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rules) == 0 {
+			continue
+		}
+		// mutations target rules as written, before aliases are expanded
+		if v.mutation != nil {
+			if rules, err = v.mutation.apply(structType, sf, rules); err != nil {
+				return nil, err
+			}
+		}
+		if rules, err = v.expandRules(rules); err != nil {
+			return nil, err
+		}
+		if v.merge == MergeReplace {
+			return rules, nil
+		}
+		bySource = append(bySource, rules)
+	}
+
+	var merged []rule
+	seen := make(map[string]bool)
+	// walk from the highest precedence so it wins duplicated keys, then restore lowest-first order
+	for _, rules := range bySource {
+		var kept []rule
+		for _, r := range rules {
+			if !seen[r.key] {
+				kept = append(kept, r)
+			}
+		}
+		for _, r := range rules {
+			seen[r.key] = true
+		}
+		merged = append(kept, merged...)
+	}
+	return requiredFirst(merged), nil
+}
+
+// requiredFirst moves a required rule ahead of an omitempty declared by another source, so e.g.
+// the omitempty of type rules does not skip the required of the field tag for empty values.
+func requiredFirst(rules []rule) []rule {
+	omit := slices.IndexFunc(rules, func(r rule) bool { return r.key == omitEmptyTag })
+	if omit < 0 {
+		return rules
+	}
+	req := slices.IndexFunc(rules, func(r rule) bool { return r.key == requiredTag })
+	if req < omit || rules[req].source == rules[omit].source {
+		return rules
+	}
+	required := rules[req]
+	rules = slices.Delete(rules, req, req+1)
+	return slices.Insert(rules, omit, required)
+}
+
+// parseRules splits a rule string into rules. Empty rules are dropped.
+func parseRules(s string, source RuleSource) ([]rule, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts, err := SplitRules(s)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]rule, 0, len(parts))
+	for _, part := range parts {
+		parse := parsePart
+		if isProfileRule(part) {
+			parse = parseProfileRule
+		}
+		r, err := parse(part, source)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// parsePart parses a single rule, which may list alternatives like email|uuid.
+func parsePart(part string, source RuleSource) (rule, error) {
+	alts, err := splitTopLevel(part, '|')
+	if err != nil {
+		return rule{}, err
+	}
+	if len(alts) == 1 {
+		return parseRule(part, source), nil
+	}
+	r := rule{source: source, alts: make([]rule, len(alts))}
+	for i, alt := range alts {
+		if strings.TrimSpace(alt) == "" {
+			return rule{}, fmt.Errorf("empty alternative in %q", part)
+		}
+		if isProfileRule(alt) {
+			return rule{}, fmt.Errorf("profile rules cannot be used in alternatives: %q", part)
+		}
+		r.alts[i] = parseRule(alt, source)
+		if isSpecialTag(r.alts[i].key) {
+			return rule{}, fmt.Errorf("%s cannot be used in alternatives: %q", r.alts[i].key, part)
+		}
+		alts[i] = r.alts[i].String()
+	}
+	r.key = strings.Join(alts, "|")
+	return r, nil
+}
+
+func parseRule(s string, source RuleSource) rule {
+	key, param, _ := strings.Cut(s, "=")
+	return rule{key: strings.TrimSpace(key), param: strings.TrimSpace(param), source: source}
+}
+
+// isSpecialTag reports whether key is handled by the tag processing flow rather than a validator.
+func isSpecialTag(key string) bool {
+	switch key {
+	case eachTag, eachSampleTag, keysTag, valuesTag, diveTag, omitEmptyTag:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package lakery
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+)
+
+// each_sample=N limits the following each, keys and values rules to N elements
+const eachSampleTag = "each_sample"
+
+// SampleCoverage tells how many elements of a collection were validated because of each_sample.
+type SampleCoverage struct {
+	// path of the collection, e.g. Rows or Batches[2].Rows
+	Namespace string
+	// rule whose elements were sampled, e.g. each={min=1}
+	Rule    string
+	Checked int
+	Total   int
+}
+
+// Ratio returns the validated share of the elements, 1 for empty collections.
+func (c SampleCoverage) Ratio() float64 {
+	if c.Total == 0 {
+		return 1
+	}
+	return float64(c.Checked) / float64(c.Total)
+}
+
+// ValidateWithCoverage validates s like ValidateCtx and reports the coverage of every collection
+// sampled with each_sample, in the order they were visited. Sampling makes advisory checks of huge
+// datasets affordable: a passing result only means no failure was found among the checked elements.
+//
+//	type Dataset struct {
+//		Rows []Row `lakery:"each_sample=1000,each={dive}"`
+//	}
+func (v *Validator) ValidateWithCoverage(ctx context.Context, s any) ([]SampleCoverage, error) {
+	vs := &validation{v: v, ctx: ctx}
+	err := v.run(vs, s)
+	return vs.coverage, err
+}
+
+// parseSample returns the limit of each_sample=N.
+func parseSample(r rule) (int, error) {
+	n, err := strconv.Atoi(r.param)
+	if err != nil || n < 1 {
+		return 0, configErrorf("each_sample expects a positive integer param")
+	}
+	return n, nil
+}
+
+// sampleIndexes returns the indexes of the elements to validate out of n, in ascending order.
+// Without a limit (or with fewer elements than it) all elements are validated; otherwise the
+// elements are split into limit strata of (almost) equal size and a random element of each is
+// picked, so the sample covers the whole collection.
+func (vs *validation) sampleIndexes(namespace string, r rule, n, limit int) []int {
+	count := n
+	if limit > 0 && limit < n {
+		count = limit
+	}
+	indexes := make([]int, count)
+	for i := range indexes {
+		lo, hi := i*n/count, (i+1)*n/count
+		indexes[i] = lo
+		if hi-lo > 1 {
+			indexes[i] += rand.IntN(hi - lo)
+		}
+	}
+	if limit > 0 {
+		vs.coverage = append(vs.coverage, SampleCoverage{Namespace: namespace, Rule: r.String(), Checked: count, Total: n})
+		vs.v.tracef("%s: %s checks %d of %d elements", namespace, r, count, n)
+	}
+	return indexes
+}
@@ -0,0 +1,303 @@
+package lakery
+
+import "sort"
+
+// Version is the version of lakery. BuiltinSpecs reports it as Since for the tags added in it.
+const Version = "0.2.0"
+
+// BuiltinSpec describes a builtin tag, so docs, CLIs and rule editors can list the capabilities
+// of the linked lakery version.
+type BuiltinSpec struct {
+	Name string `json:"name"`
+	// stable code of failures of the tag, see FieldError.Code
+	Code string `json:"code"`
+	// param syntax, e.g. "N" or "Field value ...", empty when the tag takes no param
+	Param string `json:"param,omitempty"`
+	// whether the param is optional
+	OptionalParam bool `json:"optional_param,omitempty"`
+	// what the tag checks, per kind of value
+	Kinds []KindBehavior `json:"kinds"`
+	// whether the tag modifies the value in place; the struct must be passed by pointer
+	Sanitizer bool `json:"sanitizer,omitempty"`
+	// whether the tag is handled by tag processing (each, dive, omitempty, ...) rather than a validator
+	Special bool `json:"special,omitempty"`
+	// lakery version which introduced the tag, or the version of the plugin providing it
+	Since string `json:"since"`
+	// name of the plugin providing the tag, empty for builtins, see Validator.Install
+	Plugin string `json:"plugin,omitempty"`
+}
+
+// KindBehavior describes what a tag checks for some kinds of values. Kinds are one of
+// "string", "collection" (slice, array, map), "number" (integers, floats and types registered
+// with RegisterNumberType), "stringer" (fmt.Stringer), "time" (time.Time), "struct" and "any".
+type KindBehavior struct {
+	Kinds    []string `json:"kinds"`
+	Behavior string   `json:"behavior"`
+}
+
+// builtinSpecs lists the tags available in every build profile.
+var builtinSpecs = []BuiltinSpec{
+	{Name: requiredTag, Since: "0.1.0", Kinds: []KindBehavior{
+		{Kinds: []string{"any"}, Behavior: "not the zero value, as reported by an IsZero() bool method if the type has one; nil pointers fail"},
+	}},
+	{Name: minTag, Param: "N", Since: "0.1.0", Kinds: []KindBehavior{
+		{Kinds: []string{"string", "collection"}, Behavior: "length at least N; nil pointers fail unless N <= 0"},
+		{Kinds: []string{"number"}, Behavior: "value at least N"},
+		{Kinds: []string{"time"}, Behavior: "not before N, an RFC 3339 time or a date compared with the date of the value; nil pointers pass"},
+	}},
+	{Name: maxTag, Param: "N", Since: "0.1.0", Kinds: []KindBehavior{
+		{Kinds: []string{"string", "collection"}, Behavior: "length at most N"},
+		{Kinds: []string{"number"}, Behavior: "value at most N"},
+		{Kinds: []string{"time"}, Behavior: "not after N, an RFC 3339 time or a date compared with the date of the value"},
+	}},
+	{Name: lenTag, Param: "N", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string", "collection"}, Behavior: "length exactly N; nil pointers have length 0"},
+	}},
+	{Name: betweenTag, Param: "lo:hi", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string", "collection"}, Behavior: "length between lo and hi inclusive; nil pointers have length 0"},
+		{Kinds: []string{"number"}, Behavior: "value between lo and hi inclusive; nil pointers pass"},
+	}},
+	{Name: eachTag, Param: "{rules}", Special: true, Since: "0.1.0", Kinds: []KindBehavior{
+		{Kinds: []string{"collection"}, Behavior: "runs the rules against every element"},
+	}},
+	{Name: diveTag, Special: true, Since: "0.1.0", Kinds: []KindBehavior{
+		{Kinds: []string{"struct"}, Behavior: "validates the fields of the nested struct"},
+	}},
+	{Name: eachSampleTag, Param: "N", Special: true, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"collection"}, Behavior: "limits the following each, keys and values rules to N elements, a random one per stratum"},
+	}},
+	{Name: keysTag, Param: "{rules}", Special: true, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"collection"}, Behavior: "runs the rules against every key of a map"},
+	}},
+	{Name: valuesTag, Param: "{rules}", Special: true, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"collection"}, Behavior: "runs the rules against every value of a map"},
+	}},
+	{Name: omitEmptyTag, Special: true, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"any"}, Behavior: "skips the remaining rules for zero values"},
+	}},
+	{Name: eqTag, Param: "value", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "exactly the param"},
+		{Kinds: []string{"number"}, Behavior: "numerically equal to the param; nil pointers pass"},
+		{Kinds: []string{"any"}, Behavior: "booleans equal to the param, parsed like strconv.ParseBool"},
+	}},
+	{Name: neTag, Param: "value", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "anything but the param"},
+		{Kinds: []string{"number"}, Behavior: "numerically different from the param; nil pointers pass"},
+		{Kinds: []string{"any"}, Behavior: "booleans different from the param, parsed like strconv.ParseBool"},
+	}},
+	{Name: gtTag, Param: "N", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"number"}, Behavior: "value greater than N; nil pointers pass"},
+		{Kinds: []string{"time"}, Behavior: "after N, an RFC 3339 time or a date compared with the date of the value; nil pointers pass"},
+	}},
+	{Name: gteTag, Param: "N", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"number"}, Behavior: "value greater than or equal to N; nil pointers pass"},
+		{Kinds: []string{"time"}, Behavior: "not before N, an RFC 3339 time or a date compared with the date of the value; nil pointers pass"},
+	}},
+	{Name: ltTag, Param: "N", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"number"}, Behavior: "value less than N; nil pointers pass"},
+		{Kinds: []string{"time"}, Behavior: "before N, an RFC 3339 time or a date compared with the date of the value; nil pointers pass"},
+	}},
+	{Name: lteTag, Param: "N", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"number"}, Behavior: "value less than or equal to N; nil pointers pass"},
+		{Kinds: []string{"time"}, Behavior: "not after N, an RFC 3339 time or a date compared with the date of the value; nil pointers pass"},
+	}},
+	{Name: minEntropyTag, Param: "bits", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "Shannon entropy of at least bits per character"},
+	}},
+	{Name: oneOfTag, Param: "values ...", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "exactly one of the values"},
+		{Kinds: []string{"number"}, Behavior: "numerically equal to one of the values; nil pointers pass"},
+	}},
+	{Name: notInTag, Param: "values ... | @set", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "not one of the space-separated values or a member of the registered set"},
+	}},
+	{Name: notForbiddenTag, Param: "set", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "not contained in the registered set"},
+	}},
+	{Name: moneyTag, Param: "places[:signed]", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"number", "string", "stringer"}, Behavior: "decimal amount with at most places decimals, not negative unless signed"},
+	}},
+	{Name: percentTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"number"}, Behavior: "between 0 and 100 inclusive"},
+	}},
+	{Name: ratioTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"number"}, Behavior: "between 0 and 1 inclusive"},
+	}},
+	{Name: inRangeTag, Param: "lo:hi", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"number"}, Behavior: "between lo and hi inclusive"},
+	}},
+	{Name: ipTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "IPv4 or IPv6 address without zone; empty strings pass"},
+	}},
+	{Name: ipv4Tag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "IPv4 address in dotted decimal form; empty strings pass"},
+	}},
+	{Name: ipv6Tag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "IPv6 address without zone, IPv4-mapped included; empty strings pass"},
+	}},
+	{Name: cidrTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "network in CIDR notation, host bits allowed; empty strings pass"},
+	}},
+	{Name: macTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "EUI-48, EUI-64 or IPoIB hardware address as parsed by net.ParseMAC; empty strings pass"},
+	}},
+	{Name: inCIDRTag, Param: "prefix ...", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "IP address inside one of the space-separated networks"},
+	}},
+	{Name: inCIDRFieldTag, Param: "Field", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "IP address inside the network held by the sibling field"},
+	}},
+	{Name: regexpTag, Param: "pattern | 'pattern'", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "matches the RE2 pattern, unanchored; empty strings pass"},
+	}},
+	{Name: emailTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "a plain email address with a dotted domain, without display name; empty strings pass"},
+	}},
+	{Name: uuidTag, Param: "version | anycase ...", OptionalParam: true, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "a lower case canonical UUID, of the version if given, upper case too with anycase; empty strings pass"},
+	}},
+	{Name: urlTag, Param: "scheme ...", OptionalParam: true, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "an absolute URL with scheme and host, with one of the schemes if given; empty strings pass"},
+	}},
+	{Name: uriTag, Param: "scheme ...", OptionalParam: true, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "a URI with a scheme, with one of the schemes if given; empty strings pass"},
+	}},
+	{Name: urlHostTag, Param: "host ... | {host,...}", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "URL with one of the allowed hosts; *.example.org matches subdomains"},
+	}},
+	{Name: urlNoCredsTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "URL without user:password@ credentials"},
+	}},
+	{Name: safePathTag, Param: "relative|absolute", OptionalParam: true, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "path without .. segments or NUL bytes, optionally restricted to the form"},
+	}},
+	{Name: alphaTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "only ASCII letters; empty strings pass"},
+	}},
+	{Name: alphaNumTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "only ASCII letters and digits; empty strings pass"},
+	}},
+	{Name: alphaUnicodeTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "only Unicode letters; empty strings pass"},
+	}},
+	{Name: alphaNumUnicodeTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "only Unicode letters and numbers; empty strings pass"},
+	}},
+	{Name: containsTag, Param: "substring", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "contains the substring; empty strings pass"},
+	}},
+	{Name: excludesTag, Param: "substring", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "does not contain the substring"},
+	}},
+	{Name: containsAnyTag, Param: "chars", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "contains at least one of the characters; empty strings pass"},
+	}},
+	{Name: excludesAllTag, Param: "chars", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "contains none of the characters"},
+	}},
+	{Name: startsWithTag, Param: "prefix", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "starts with the prefix; empty strings pass"},
+	}},
+	{Name: endsWithTag, Param: "suffix", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "ends with the suffix; empty strings pass"},
+	}},
+	{Name: lowercaseTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "no upper case letters in any script; empty strings pass"},
+	}},
+	{Name: uppercaseTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "no lower case letters in any script; empty strings pass"},
+	}},
+	{Name: numericTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "decimal number with optional sign and fraction, no exponent; empty strings pass"},
+	}},
+	{Name: numberTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "only ASCII digits; empty strings pass"},
+	}},
+	{Name: sqlIdentTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "unquoted SQL identifier which is not a reserved keyword"},
+	}},
+	{Name: goIdentTag, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "Go identifier which is not a keyword"},
+	}},
+	{Name: eqFieldTag, Param: "Field", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"any"}, Behavior: "equal to the sibling field"},
+	}},
+	{Name: neFieldTag, Param: "Field", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"any"}, Behavior: "different from the sibling field"},
+	}},
+	{Name: gtFieldTag, Param: "Field", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"number", "string", "time"}, Behavior: "greater than the sibling field"},
+	}},
+	{Name: gteFieldTag, Param: "Field", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"number", "string", "time"}, Behavior: "greater than or equal to the sibling field"},
+	}},
+	{Name: ltFieldTag, Param: "Field", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"number", "string", "time"}, Behavior: "less than the sibling field"},
+	}},
+	{Name: lteFieldTag, Param: "Field", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"number", "string", "time"}, Behavior: "less than or equal to the sibling field"},
+	}},
+	{Name: requiredIfTag, Param: "Field value ...", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"any"}, Behavior: "required when every sibling field holds its value"},
+	}},
+	{Name: requiredUnlessTag, Param: "Field value ...", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"any"}, Behavior: "required unless every sibling field holds its value"},
+	}},
+	{Name: requiredWithTag, Param: "Field ...", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"any"}, Behavior: "required when any of the sibling fields is set"},
+	}},
+	{Name: requiredWithoutTag, Param: "Field ...", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"any"}, Behavior: "required when any of the sibling fields is not set"},
+	}},
+	{Name: requiredWithoutAllTag, Param: "Field ...", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"any"}, Behavior: "required when none of the sibling fields is set"},
+	}},
+	{Name: excludedWithTag, Param: "Field ...", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"any"}, Behavior: "empty when any of the sibling fields is set"},
+	}},
+	{Name: excludedIfTag, Param: "Field value ...", Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"any"}, Behavior: "empty when every sibling field holds its value"},
+	}},
+	{Name: trimTag, Sanitizer: true, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "removes leading and trailing white space"},
+	}},
+	{Name: lowerTag, Sanitizer: true, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "converts to lower case"},
+	}},
+	{Name: truncateTag, Param: "N", Sanitizer: true, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "shortens to at most N bytes without splitting characters"},
+	}},
+	{Name: defaultTag, Param: "value", Sanitizer: true, Since: Version, Kinds: []KindBehavior{
+		{Kinds: []string{"string", "number"}, Behavior: "sets the zero value to the param (default=30s for a time.Duration); booleans are set too, nil pointers to a new value"},
+	}},
+}
+
+// BuiltinSpecs describes the builtin tags of the linked lakery version and build profile, sorted by name.
+// The returned slice is a copy and may be modified.
+func BuiltinSpecs() []BuiltinSpec {
+	specs := append(append([]BuiltinSpec(nil), builtinSpecs...), normSpecs...)
+	for i := range specs {
+		kinds := make([]KindBehavior, len(specs[i].Kinds))
+		for j, kb := range specs[i].Kinds {
+			kinds[j] = KindBehavior{Kinds: append([]string(nil), kb.Kinds...), Behavior: kb.Behavior}
+		}
+		specs[i].Kinds = kinds
+		specs[i].Code = builtinCode(specs[i].Name)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
+
+// builtinNames holds the names of the builtin tags of the build profile, which have codes of their own.
+var builtinNames = func() map[string]bool {
+	names := make(map[string]bool, len(builtinSpecs)+len(normSpecs))
+	for _, spec := range append(append([]BuiltinSpec(nil), builtinSpecs...), normSpecs...) {
+		names[spec.Name] = true
+	}
+	return names
+}()
+
+// builtinCode returns the code of a builtin tag, e.g. lakery.min.
+func builtinCode(name string) string {
+	return mainTag + "." + name
+}
@@ -0,0 +1,31 @@
+package lakery
+
+import "fmt"
+
+func (v *Validator) tracef(format string, args ...any) {
+	if v.trace == nil {
+		return
+	}
+	v.traceMu.Lock()
+	defer v.traceMu.Unlock()
+	fmt.Fprintf(v.trace, "lakery: "+format+"\n", args...)
+}
+
+// traceRule logs the outcome of a single rule. fn is nil when no validator is registered for the key.
+func (v *Validator) traceRule(field, key, param string, fn TagValidationFunc, err error) {
+	if v.trace == nil {
+		return
+	}
+	rule := key
+	if param != "" {
+		rule += "=" + param
+	}
+	switch {
+	case fn == nil:
+		v.tracef("%s: %s -> no validator registered, skipped", field, rule)
+	case err != nil:
+		v.tracef("%s: %s (%s) -> fail: %v", field, rule, funcName(fn), err)
+	default:
+		v.tracef("%s: %s (%s) -> pass", field, rule, funcName(fn))
+	}
+}
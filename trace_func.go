@@ -0,0 +1,22 @@
+//go:build !lakery_tiny
+
+package lakery
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// funcName returns the short name of the function, e.g. lakery.builtinMin or main.credentialValidator.
+func funcName(fn TagValidationFunc) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "unknown"
+	}
+	name := f.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,9 @@
+//go:build lakery_tiny
+
+package lakery
+
+// funcName cannot resolve function names in the lakery_tiny build profile, as TinyGo lacks
+// runtime.FuncForPC, so traces name every validator "unknown".
+func funcName(TagValidationFunc) string {
+	return "unknown"
+}
@@ -0,0 +1,28 @@
+//go:build !lakery_tiny
+
+package lakery_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/trofkm/lakery"
+)
+
+var _ = Describe("Tracing", func() {
+	It("names the resolved validators", func() {
+		type S struct {
+			Name  string   `lakery:"required,min=3"`
+			Creds []string `lakery:"each={max=2}"`
+		}
+		var buf bytes.Buffer
+		v := lakery.NewValidator(lakery.WithTrace(&buf))
+		Expect(v.Validate(S{Name: "john", Creds: []string{"abc"}})).To(HaveOccurred())
+		out := buf.String()
+		Expect(out).To(ContainSubstring("Name: required (lakery.builtinRequired) -> pass"))
+		Expect(out).To(ContainSubstring("Name: min=3 (lakery.builtinMin) -> pass"))
+		Expect(out).To(ContainSubstring("Creds[0]: max=2 (lakery.builtinMax) -> fail: should have length at most 2"))
+	})
+})
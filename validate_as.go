@@ -0,0 +1,177 @@
+package lakery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// tags of the FieldErrors reported by ValidateAs for keys not matching the schema
+const (
+	unknownKeyTag = "unknown_key"
+	missingKeyTag = "missing_key"
+	keyTypeTag    = "type"
+)
+
+// schemaTags are the tags of failures which are not caused by a rule but have a code of their own.
+var schemaTags = map[string]bool{unknownKeyTag: true, missingKeyTag: true, keyTypeTag: true}
+
+// ValidateAs validates dynamic data, e.g. a JSON object decoded into map[string]any, against the rules
+// of the struct type T, for handlers which can't fully decode into T before validating:
+//
+//	user, err := lakery.ValidateAs[User](v, body)
+//
+// Keys are matched to the fields of T like encoding/json does (json tags, case-insensitive names,
+// promoted fields) and their values are converted to the field types, so 42.0 fills an int field.
+// Keys without a field (lakery.unknown_key), fields without a key (lakery.missing_key, unless their
+// json tag has omitempty or omitzero) and values which can't be converted (lakery.type) are reported
+// as FieldErrors named by the key, followed by the failures of the rules of the fields present in m.
+// Rules of missing fields don't run. It returns the converted T, also when validation failed.
+func ValidateAs[T any](v *Validator, m map[string]any) (T, error) {
+	var t T
+	typ := reflect.TypeOf(t)
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return t, fmt.Errorf("ValidateAs needs a struct type, got %v", typ)
+	}
+	var errs ValidationErrors
+	present := make(map[string]bool, len(m))
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fields := jsonFields(typ)
+	for _, key := range keys {
+		field, ok := matchJSONField(fields, key)
+		if !ok {
+			errs = append(errs, schemaError(key, m[key], unknownKeyTag, errors.New("unknown field")))
+			continue
+		}
+		if err := decodeKey(&t, key, m[key]); err != nil {
+			errs = append(errs, schemaError(key, m[key], keyTypeTag, fmt.Errorf("should be %s", field.typ)))
+			continue
+		}
+		present[field.goName] = true
+	}
+	for _, f := range fields {
+		if !f.optional && !present[f.goName] && !hasKey(m, f.name) {
+			errs = append(errs, schemaError(f.name, nil, missingKeyTag, errors.New("missing field")))
+		}
+	}
+
+	err := v.validate(context.Background(), &t, func(path string) bool {
+		name, _, _ := strings.Cut(path, ".")
+		return present[name]
+	})
+	var invalid *InvalidRuleError
+	var ruleErrs ValidationErrors
+	var fe *FieldError
+	switch {
+	case err == nil:
+	case errors.As(err, &invalid):
+		return t, err
+	case errors.As(err, &ruleErrs):
+		errs = append(errs, ruleErrs...)
+	case errors.As(err, &fe):
+		errs = append(errs, fe)
+	default:
+		return t, err
+	}
+	if len(errs) == 0 {
+		return t, nil
+	}
+	if len(errs) == 1 {
+		return t, errs[0]
+	}
+	return t, errs
+}
+
+// jsonField is a field of a struct as seen by encoding/json.
+type jsonField struct {
+	// key of the field, from the json tag or the field name
+	name   string
+	goName string
+	typ    reflect.Type
+	// whether the json tag has omitempty or omitzero
+	optional bool
+}
+
+// jsonFields lists the fields encoding/json decodes into, including the promoted fields of embedded structs.
+func jsonFields(typ reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if isEmbeddedStruct(sf) && name == "" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			fields = append(fields, jsonFields(embedded)...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		optional := strings.Contains(","+opts+",", ",omitempty,") || strings.Contains(","+opts+",", ",omitzero,")
+		fields = append(fields, jsonField{name: name, goName: sf.Name, typ: sf.Type, optional: optional})
+	}
+	return fields
+}
+
+// matchJSONField returns the field of a key, preferring an exact match over a case-insensitive one like encoding/json.
+func matchJSONField(fields []jsonField, key string) (jsonField, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return jsonField{}, false
+}
+
+// hasKey reports whether m has a key matching name case-insensitively.
+func hasKey(m map[string]any, name string) bool {
+	for key := range m {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeKey converts the value of a single key into the matching field of t.
+func decodeKey(t any, key string, value any) error {
+	data, err := json.Marshal(map[string]any{key: value})
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(t)
+}
+
+// schemaError reports a key of the data which doesn't match the struct type.
+func schemaError(key string, value any, tag string, err error) *FieldError {
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() {
+		rv = reflect.ValueOf(&value).Elem()
+	}
+	return NewFieldError(reflect.StructField{Name: key, Type: rv.Type()}, key, rv, tag, "", err)
+}
@@ -0,0 +1,39 @@
+package lakery
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Validated holds a value of the struct type T which is validated by the default validator as soon
+// as it is decoded from JSON, so a single Unmarshal or Decode reports both decoding and validation
+// errors:
+//
+//	var req lakery.Validated[CreateUser]
+//	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+//		return err // unexpected end of JSON input, or Name is required
+//	}
+//	user := req.Value
+//
+// Values of the wrong JSON type (json.UnmarshalTypeError) don't stop validation, the fields decoded
+// are validated and both errors are returned joined with errors.Join; FieldErrors extracts the
+// validation failures. Malformed JSON is returned as is. Validation runs on a pointer to Value, so
+// sanitizers and default modify it. Validated encodes to JSON like T.
+type Validated[T any] struct {
+	Value T
+}
+
+// UnmarshalJSON decodes data into Value and validates it with the default validator.
+func (v *Validated[T]) UnmarshalJSON(data []byte) error {
+	err := json.Unmarshal(data, &v.Value)
+	var typeErr *json.UnmarshalTypeError
+	if err != nil && !errors.As(err, &typeErr) {
+		return err
+	}
+	return errors.Join(err, Default().Validate(&v.Value))
+}
+
+// MarshalJSON encodes Value.
+func (v Validated[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Value)
+}
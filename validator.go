@@ -1,38 +1,202 @@
 package lakery
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 const (
 	mainTag = "lakery"
+	// tag value excluding a field from validation, lakery:"-"
+	skipTag = "-"
 )
 
 type TagValidationFunc = func(*Value) error
 
+// SetContainsFunc reports whether a value belongs to a named set. It allows plugging
+// denylist services, bloom filters or profanity dictionaries into tags.
+type SetContainsFunc = func(string) bool
+
+// A Validator is safe for concurrent use: registrations may happen while other goroutines validate,
+// e.g. when a single validator is shared by the handlers of an HTTP server.
 type Validator struct {
+	// guards validators, sets, rules and number types registered after creation; unused once frozen
+	mu         sync.RWMutex
 	validators map[string]TagValidationFunc
+	// struct tag key holding the rules, see WithTagName
+	tagName string
+	// named string sets referenced from tags (e.g. notin=@common_passwords, notforbidden=usernames_denylist)
+	sets map[string]SetContainsFunc
+	// destination of rule tracing, nil when disabled
+	trace io.Writer
+	// serializes tracing of parallel workers, see WithParallelEach
+	traceMu sync.Mutex
+	// report every failed rule instead of stopping at the first one
+	collectAll bool
+	// rules attached to named types with RegisterTypeRules
+	typeRules map[reflect.Type]string
+	// conversions of arbitrary precision number types, see RegisterNumberType
+	numberTypes map[reflect.Type]NumberFunc
+	// unwrapping of wrapper types like sql.NullString, see RegisterCustomTypeFunc
+	customTypes map[reflect.Type]CustomTypeFunc
+	// rule sets a single tag word expands into, see RegisterAlias
+	aliases map[string][]rule
+	// rules attached to struct fields with RegisterStructRules
+	structRules map[reflect.Type]map[string]string
+	// rule sources from the highest to the lowest precedence
+	precedence []RuleSource
+	// how rules of several sources are combined
+	merge RuleMerge
+	// protoc-gen-go aware traversal, see WithProtobuf
+	protobuf   bool
+	protoRules ProtoRules
+	// message full names per struct type, "" for non-messages
+	protoNames sync.Map
+	// compiled rules per struct type, see compiled
+	cache sync.Map
+	// compiled patterns of regexp rules, keyed by pattern
+	regexps sync.Map
+	// bumped on every registration to invalidate the cache
+	gen atomic.Uint64
+	// set by Freeze, registration panics and lookups skip mu afterwards
+	frozen atomic.Bool
+	// rule mutation for mutation testing, see WithMutationForTesting
+	mutation *Mutation
+	// nesting limit of structs, see WithMaxDepth
+	maxDepth int
+	// workers validating the elements of large collections, see WithParallelEach
+	parallelEach int
+	// panic instead of returning InvalidRuleError, see WithPanicOnInvalidRule
+	panicOnInvalidRule bool
+	// fail on rules without a registered validator, see WithStrictTags
+	strictTags bool
+	// what to do with rules of unexported fields, see WithUnexportedFields
+	unexported UnexportedPolicy
+	// selects the variant of profile rules, see WithProfile
+	profile string
+	// parses struct tags, nil for the lakery grammar, see WithTagParser
+	tagParser TagParser
+	// plugins installed with Install, in order
+	plugins []Plugin
+	// hooks added with OnFieldStart and OnFieldResult
+	fieldStart  []FieldStartFunc
+	fieldResult []FieldResultFunc
 }
 
-func NewValidator() *Validator {
+func NewValidator(opts ...Option) *Validator {
 	v := &Validator{
-		validators: make(map[string]TagValidationFunc),
+		validators:  make(map[string]TagValidationFunc),
+		tagName:     mainTag,
+		sets:        make(map[string]SetContainsFunc),
+		typeRules:   make(map[reflect.Type]string),
+		numberTypes: make(map[reflect.Type]NumberFunc),
+		customTypes: make(map[reflect.Type]CustomTypeFunc),
+		aliases:     make(map[string][]rule),
+		structRules: make(map[reflect.Type]map[string]string),
+		precedence:  defaultPrecedence,
+		maxDepth:    DefaultMaxDepth,
 	}
 	// register built-in validators
 	v.registerBuiltins()
+	v.applyOptions(opts)
 	return v
 }
 
-func (v *Validator) RegisterTag(tag string, fn TagValidationFunc) {
-	// don't check for existens - its totally fine to override some validator
+// RegisterTag registers the validator of a tag and reports whether it overrode an existing one,
+// e.g. a builtin or a tag registered by another layer of a framework. Overriding is fine.
+func (v *Validator) RegisterTag(tag string, fn TagValidationFunc) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.mustNotBeFrozen("RegisterTag", tag)
+	_, overridden := v.validators[tag]
 	v.validators[tag] = fn
+	v.invalidate()
+	return overridden
+}
+
+// UnregisterTag removes the validator of a tag, builtins included. Rules using the tag
+// are skipped afterwards like any unknown tag.
+func (v *Validator) UnregisterTag(tag string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.mustNotBeFrozen("UnregisterTag", tag)
+	delete(v.validators, tag)
+	v.invalidate()
+}
+
+// RegisterSet registers a named set of strings which can be referenced from tags as @name,
+// e.g. lakery:"notin=@common_passwords". Registering a set with the same name replaces it.
+func (v *Validator) RegisterSet(name string, values ...string) {
+	if v.Frozen() {
+		panic(frozenError("RegisterSet", name))
+	}
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+	v.RegisterSetValidator(name, func(s string) bool {
+		_, ok := set[s]
+		return ok
+	})
+}
+
+// RegisterSetValidator registers a named set backed by an arbitrary membership check,
+// e.g. a bloom filter or a remote denylist. Sets are shared between notin=@name and notforbidden=name.
+func (v *Validator) RegisterSetValidator(name string, contains SetContainsFunc) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.mustNotBeFrozen("RegisterSetValidator", name)
+	v.sets[name] = contains
+	v.invalidate()
+}
+
+// RegisterTypeRules attaches rules to a named type so domain types carry their constraints:
+//
+//	type Email string
+//	v.RegisterTypeRules(Email(""), "required,email")
+//
+// The rules apply to every field of that type (or of a pointer to it), even without a field tag.
+// By default they run before the field's own rules, see WithRulePrecedence.
+// Registering rules for the same type again replaces them.
+func (v *Validator) RegisterTypeRules(typ any, rules string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.mustNotBeFrozen("RegisterTypeRules", fmt.Sprint(reflect.TypeOf(typ)))
+	v.typeRules[reflect.TypeOf(typ)] = rules
+	v.invalidate()
+}
+
+// rulesForType returns the rules registered for t or for the type t points to.
+// The caller must hold v.mu.
+func (v *Validator) rulesForType(t reflect.Type) string {
+	if rules, ok := v.typeRules[t]; ok {
+		return rules
+	}
+	if t.Kind() == reflect.Pointer {
+		return v.typeRules[t.Elem()]
+	}
+	return ""
+}
+
+// validator returns the validator registered for tag, nil if there is none.
+func (v *Validator) validator(tag string) TagValidationFunc {
+	defer v.runlock(v.rlock())
+	return v.validators[tag]
 }
 
 func (v *Validator) ListValidators() []string {
 	// cache? not necessary since it is probably not very often to call
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 	vals := make([]string, 0, len(v.validators))
 	for k := range v.validators {
 		vals = append(vals, k)
@@ -41,6 +205,49 @@ func (v *Validator) ListValidators() []string {
 }
 
 func (v *Validator) Validate(s any) error {
+	return v.ValidateCtx(context.Background(), s)
+}
+
+// ValidateCtx validates s like Validate and makes ctx available to validators through Value.Context,
+// so validators doing I/O (e.g. uniqueness checks against a database) honor cancellation and deadlines.
+// Validation stops with the context error once ctx is done.
+func (v *Validator) ValidateCtx(ctx context.Context, s any) error {
+	return v.validate(ctx, s, nil)
+}
+
+// ValidatePartial validates only the given fields of s, e.g. the fields present in a PATCH request.
+// Nested fields reached through dive are named with dots (Address.City); naming a struct field
+// selects all of its nested fields.
+func (v *Validator) ValidatePartial(s any, fields ...string) error {
+	return v.validate(context.Background(), s, func(path string) bool {
+		for _, f := range fields {
+			if path == f || strings.HasPrefix(path, f+".") || strings.HasPrefix(f, path+".") {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ValidateExcept validates all fields of s except the given ones, named like for ValidatePartial.
+func (v *Validator) ValidateExcept(s any, fields ...string) error {
+	return v.validate(context.Background(), s, func(path string) bool {
+		for _, f := range fields {
+			if path == f || strings.HasPrefix(path, f+".") {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// validate validates the fields of s selected by include, all of them when include is nil.
+func (v *Validator) validate(ctx context.Context, s any, include func(path string) bool) error {
+	return v.run(&validation{v: v, ctx: ctx, include: include}, s)
+}
+
+// run validates the struct s within vs.
+func (v *Validator) run(vs *validation, s any) error {
 	// todo: parse internal structure here and search for data
 	if v == nil {
 		return errors.New("cannot validate nil")
@@ -53,116 +260,598 @@ func (v *Validator) Validate(s any) error {
 	if rv.Kind() != reflect.Struct {
 		return errors.New("can only validate structs")
 	}
-	return v.validateStruct(rv)
+	vs.hooks = v.hooks()
+	vs.validateStruct(rv, "")
+	return vs.err()
+}
+
+// varName is the field name reported for values validated with Var.
+const varName = "value"
+
+// Var validates a single value against a rule string, e.g. a query parameter or CLI flag:
+//
+//	err := v.Var(r.URL.Query().Get("q"), "required,min=3,max=64")
+//
+// Errors name the value "value"; rules referencing other fields fail since there is no parent struct.
+// Pass a pointer to let sanitizers like tonfc modify the value.
+func (v *Validator) Var(value any, rules string) error {
+	return v.VarCtx(context.Background(), value, rules)
+}
+
+// VarCtx validates a single value like Var and makes ctx available to validators like ValidateCtx.
+func (v *Validator) VarCtx(ctx context.Context, value any, rules string) error {
+	if v == nil {
+		return errors.New("cannot validate nil")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return v.validateRules(ctx, varName, value, rules)
+}
+
+// validateRules parses rules and runs them against a value without a parent struct like validateValue.
+// Malformed rules are reported as an InvalidRuleError, like malformed tags of struct fields.
+func (v *Validator) validateRules(ctx context.Context, name string, value any, rules string) error {
+	parsed, err := parseRules(rules, RuleSourceProgrammatic)
+	if err != nil {
+		rv := reflect.ValueOf(value)
+		if !rv.IsValid() {
+			rv = reflect.ValueOf(&value).Elem()
+		}
+		vs := &validation{v: v, ctx: ctx}
+		vs.fail(reflect.StructField{Name: name, Type: rv.Type()}, name, rv, "", "", ConfigError(err))
+		return vs.err()
+	}
+	return v.validateValue(ctx, name, value, parsed)
+}
+
+// validateValue runs rules against a value without a parent struct, reporting it under name.
+func (v *Validator) validateValue(ctx context.Context, name string, value any, rules []rule) error {
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() {
+		// untyped nil, validate it as an empty interface
+		rv = reflect.ValueOf(&value).Elem()
+	}
+	fieldType := reflect.StructField{Name: name, Type: rv.Type()}
+	vs := &validation{v: v, ctx: ctx}
+	rules, err := v.expandRules(rules)
+	if err != nil {
+		vs.fail(fieldType, name, rv, "", "", ConfigError(err))
+		return vs.err()
+	}
+	v.tracef("%s: rules %q", name, rules)
+	vs.runRules(reflect.Value{}, fieldType, name, rv, rules)
+	return vs.err()
+}
+
+// validation holds the state of a single Validate call.
+type validation struct {
+	v    *Validator
+	ctx  context.Context
+	errs ValidationErrors
+	// set when ctx was done before validation finished
+	ctxErr error
+	// selects the fields to validate by path (Address.City), nil selects all
+	include func(path string) bool
+	// set when a rule turned out to be malformed, which stops validation
+	invalid *InvalidRuleError
+	// data shared by validators through Value.Store and Value.Load, created on first use
+	state *callState
+	// collections sampled with each_sample, see ValidateWithCoverage
+	coverage []SampleCoverage
+	// rules compiled by Compile, used instead of the cache of the validator
+	types map[reflect.Type]*compiledStruct
+	// hooks of the validator when the call started
+	hooks fieldHooks
+	// number of structs validateStruct is in, see WithMaxDepth
+	depth int
+	// set when structs are nested deeper than the max depth, which stops validation
+	depthErr error
+	// addressable structs validateStruct is in, to stop at pointer cycles
+	entered []structRef
+	// set for the validations of WithParallelEach workers, which validate nested collections sequentially
+	worker bool
+}
+
+// compiled returns the compiled rules of a struct type.
+func (vs *validation) compiled(typ reflect.Type) *compiledStruct {
+	if c, ok := vs.types[typ]; ok {
+		return c
+	}
+	return vs.v.compiled(typ)
+}
+
+// fail records a failed rule and reports whether validation should go on.
+func (vs *validation) fail(fieldType reflect.StructField, namespace string, value reflect.Value, tag, param string, err error) bool {
+	fe := NewFieldError(fieldType, namespace, value, tag, param, err)
+	if fe.Category() == CategoryConfig {
+		vs.invalid = &InvalidRuleError{FieldError: fe}
+		if vs.v.panicOnInvalidRule {
+			panic(vs.invalid)
+		}
+		return false
+	}
+	vs.errs = append(vs.errs, fe)
+	return vs.v.collectAll
+}
+
+// err returns the first failure, or all of them in collect-all mode.
+func (vs *validation) err() error {
+	if vs.ctxErr != nil {
+		return vs.ctxErr
+	}
+	if vs.depthErr != nil {
+		return vs.depthErr
+	}
+	if vs.invalid != nil {
+		return vs.invalid
+	}
+	if len(vs.errs) == 0 {
+		return nil
+	}
+	if vs.v.collectAll {
+		return vs.errs
+	}
+	return vs.errs[0]
+}
+
+// structRef identifies an addressable struct, the type tells apart a struct and its first field.
+type structRef struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// validateStruct validates all fields of rv and reports whether validation should go on.
+// prefix is the namespace of rv itself when it is reached through dive, e.g. "Addresses[1]."
+// Structs reached again through a pointer cycle are not validated again, since their rules
+// already run; structs nested deeper than the max depth stop validation with ErrMaxDepth.
+func (vs *validation) validateStruct(rv reflect.Value, prefix string) bool {
+	if maxDepth := vs.v.maxDepth; vs.depth > maxDepth {
+		vs.depthErr = fmt.Errorf("%w: %s is nested in more than %d structs", ErrMaxDepth, strings.TrimSuffix(prefix, "."), maxDepth)
+		return false
+	}
+	if rv.CanAddr() {
+		ref := structRef{ptr: rv.Addr().Pointer(), typ: rv.Type()}
+		if slices.Contains(vs.entered, ref) {
+			vs.v.tracef("%s: pointer cycle, skipped", strings.TrimSuffix(prefix, "."))
+			return true
+		}
+		vs.entered = append(vs.entered, ref)
+		defer func() { vs.entered = vs.entered[:len(vs.entered)-1] }()
+	}
+	vs.depth++
+	defer func() { vs.depth-- }()
+	return vs.validateFields(rv, prefix)
 }
 
-func (v *Validator) validateStruct(rv reflect.Value) error {
+// validateFields validates the fields of rv, see validateStruct.
+func (vs *validation) validateFields(rv reflect.Value, prefix string) bool {
 	typ := rv.Type()
+	compiled := vs.compiled(typ)
 	for i := 0; i < rv.NumField(); i++ {
+		if err := vs.ctx.Err(); err != nil {
+			vs.ctxErr = err
+			return false
+		}
+		if compiled.skip[i] {
+			continue
+		}
 		field := rv.Field(i)
 		fieldType := typ.Field(i)
-		if err := v.proceedTags(field, fieldType); err != nil {
-			return err
+		embedded := isEmbeddedStruct(fieldType)
+		// embedded structs are entered even when excluded themselves, their promoted fields are filtered by name
+		included := vs.include == nil || vs.include(fieldPath(prefix+fieldType.Name))
+		if !included && !embedded {
+			continue
+		}
+		if vs.v.protobuf {
+			if isProtoInternalField(fieldType) {
+				continue
+			}
+			field = vs.v.unwrapProto(field)
+		}
+		if included && !vs.proceedTags(rv, field, fieldType, prefix+fieldType.Name, compiled.rules[i], compiled.errs[i]) {
+			return false
+		}
+		if compiled.dive[i] {
+			continue
+		}
+		switch {
+		case embedded:
+			// fields of embedded structs are promoted, so they keep the namespace of the parent
+			if !vs.validateNested(field, prefix) {
+				return false
+			}
+		case isInlineStruct(fieldType):
+			// fields declared as anonymous structs are validated like dived ones
+			if !vs.validateNested(field, prefix+fieldType.Name+".") {
+				return false
+			}
 		}
 	}
-	return nil
+	return true
 }
 
-func (v *Validator) proceedTags(fieldValue reflect.Value, fieldType reflect.StructField) error {
-	// "lakery:..." tag
-	rootTag := fieldType.Tag.Get(mainTag)
-	if rootTag == "" {
-		return nil
+// validateNested validates a struct or pointer to struct field, skipping nil pointers.
+func (vs *validation) validateNested(field reflect.Value, prefix string) bool {
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return true
+		}
+		field = field.Elem()
 	}
+	return vs.validateStruct(field, prefix)
+}
 
-	tags, err := splitTopLevelByComma(rootTag)
-	if err != nil {
-		return CurrentErrorFormatFunc(fieldType, fieldValue, err)
+// isEmbeddedStruct reports whether a field is an embedded struct or pointer to struct.
+func isEmbeddedStruct(sf reflect.StructField) bool {
+	if !sf.Anonymous {
+		return false
 	}
-	for _, tag := range tags {
-		tag = strings.TrimSpace(tag)
-		if tag == "" {
-			continue
-		}
-		var val *Value = &Value{val: fieldValue, name: fieldType.Name}
-		// if we have param - put it into Value field
-		splitted := strings.SplitN(tag, "=", 2)
-		tagKey := strings.TrimSpace(splitted[0])
+	typ := sf.Type
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct
+}
 
-		withVal := len(splitted) == 2
+// isInlineStruct reports whether an exported field is declared as an anonymous struct
+// (or a pointer to one), e.g. Opts struct{ N int }.
+func isInlineStruct(sf reflect.StructField) bool {
+	if !sf.IsExported() || sf.Anonymous {
+		return false
+	}
+	typ := sf.Type
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct && typ.Name() == ""
+}
 
-		if withVal {
-			val.param = strings.TrimSpace(splitted[1])
+func hasRule(rules []rule, key string) bool {
+	for _, r := range rules {
+		if r.key == key {
+			return true
 		}
+	}
+	return false
+}
 
-		// special handling for each={...}
-		if tagKey == eachTag {
-			// only applicable to slices/arrays
-			kind := fieldValue.Kind()
-			if kind != reflect.Slice && kind != reflect.Array {
-				return CurrentErrorFormatFunc(fieldType, fieldValue, fmt.Errorf("each can be used only with slice or array"))
-			}
-			inner := val.Param()
-			inner = strings.TrimSpace(inner)
-			if strings.HasPrefix(inner, "{") && strings.HasSuffix(inner, "}") {
-				inner = strings.TrimSpace(inner[1 : len(inner)-1])
+// fieldPath strips element indexes from a namespace, e.g. Addresses[1].City -> Addresses.City.
+func fieldPath(namespace string) string {
+	if !strings.Contains(namespace, "[") {
+		return namespace
+	}
+	var b strings.Builder
+	depth := 0
+	for _, r := range namespace {
+		switch {
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (vs *validation) proceedTags(parent, fieldValue reflect.Value, fieldType reflect.StructField, namespace string, rules []rule, err error) bool {
+	v := vs.v
+	if err != nil {
+		v.tracef("%s: cannot parse rules: %v", namespace, err)
+		return vs.fail(fieldType, namespace, fieldValue, "", "", ConfigError(err))
+	}
+	if len(rules) == 0 {
+		return true
+	}
+	v.tracef("%s: rules %q", namespace, rules)
+	if vs.hooks.empty() {
+		return vs.runRules(parent, fieldType, namespace, fieldValue, rules)
+	}
+	return vs.runRulesWithHooks(parent, fieldType, namespace, fieldValue, rules)
+}
+
+// runRules runs rules against value, which is either the field itself or an element of it
+// addressed by namespace (e.g. Tags[1]), and reports whether validation should go on.
+func (vs *validation) runRules(parent reflect.Value, fieldType reflect.StructField, namespace string, value reflect.Value, rules []rule) bool {
+	// interface fields are validated by their dynamic value
+	value = vs.v.customValue(concrete(value))
+	// limit of elements checked by the following collection rules, 0 checks all
+	sample := 0
+	for _, r := range rules {
+		var ok bool
+		switch {
+		// email|uuid passes when any of the alternatives passes
+		case r.alts != nil:
+			ok = vs.runAlternatives(parent, fieldType, namespace, value, r)
+		// omitempty short-circuits the rules following it for zero values
+		case r.key == omitEmptyTag:
+			if value.IsZero() {
+				vs.v.tracef("%s: empty, remaining rules skipped", namespace)
+				return true
 			}
-			innerTags, err := splitTopLevelByComma(inner)
+			continue
+		// each_sample=N limits the collection rules following it
+		case r.key == eachSampleTag:
+			n, err := parseSample(r)
 			if err != nil {
-				return CurrentErrorFormatFunc(fieldType, fieldValue, err)
-			}
-			for i := 0; i < fieldValue.Len(); i++ {
-				elem := fieldValue.Index(i)
-				for _, it := range innerTags {
-					it = strings.TrimSpace(it)
-					if it == "" {
-						continue
-					}
-					kv := strings.SplitN(it, "=", 2)
-					innerKey := strings.TrimSpace(kv[0])
-					eVal := &Value{val: elem, name: fieldType.Name}
-					if len(kv) == 2 {
-						eVal.param = strings.TrimSpace(kv[1])
-					}
-					if validator, ok := v.validators[innerKey]; ok {
-						if err := validator(eVal); err != nil {
-							// report error for the specific element value
-							return CurrentErrorFormatFunc(fieldType, elem, err)
-						}
-					}
-				}
+				return vs.fail(fieldType, namespace, value, r.key, r.param, err)
 			}
+			sample = n
 			continue
+		// special handling for each={...}, keys={...}, values={...} and dive
+		case r.key == eachTag:
+			ok = vs.runEach(parent, fieldType, namespace, value, r, sample)
+		case r.key == keysTag, r.key == valuesTag:
+			ok = vs.runMap(parent, fieldType, namespace, value, r, sample)
+		case r.key == diveTag:
+			ok = vs.runDive(fieldType, namespace, value, r)
+		default:
+			ok = vs.runRule(parent, fieldType, namespace, value, r)
+		}
+		if !ok {
+			return false
 		}
+	}
+	return true
+}
+
+func (vs *validation) runRule(parent reflect.Value, fieldType reflect.StructField, namespace string, value reflect.Value, r rule) bool {
+	v := vs.v
+	validator := v.ruleValidator(r)
+	if validator == nil {
+		return vs.unknownRule(fieldType, namespace, value, r.key, r.param)
+	}
+	val := &Value{val: value, name: fieldType.Name, param: r.param, parent: parent, validator: v, ctx: vs.ctx, call: vs}
+	err := callValidator(validator, val)
+	v.traceRule(namespace, r.key, r.param, validator, err)
+	if err != nil {
+		return vs.fail(fieldType, namespace, value, r.key, r.param, err)
+	}
+	return true
+}
+
+// unknownRule handles a rule without a registered validator, which is skipped unless WithStrictTags is set.
+func (vs *validation) unknownRule(fieldType reflect.StructField, namespace string, value reflect.Value, key, param string) bool {
+	if !vs.v.strictTags {
+		vs.v.traceRule(namespace, key, param, nil, nil)
+		return true
+	}
+	vs.v.tracef("%s: %s -> no validator registered", namespace, key)
+	return vs.fail(fieldType, namespace, value, key, param, configErrorf("unknown tag %q", key))
+}
 
-		if validator, ok := v.validators[tagKey]; ok {
-			if err := validator(val); err != nil {
-				return CurrentErrorFormatFunc(fieldType, fieldValue, err)
+// callValidator runs a validator, turning a panic into an internal error so a broken validator
+// does not take the caller down. The panic of Value.Param on a rule without param is a configuration error.
+func callValidator(fn TagValidationFunc, val *Value) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if missing, ok := r.(missingParamError); ok {
+				err = ConfigError(missing)
+				return
 			}
+			err = InternalError(fmt.Errorf("validator panicked: %v", r))
 		}
+	}()
+	return fn(val)
+}
+
+// runAlternatives runs the alternatives of a rule like email|uuid and fails only when all of them fail.
+// Alternatives without a registered validator are skipped, unless WithStrictTags is set, and a
+// configuration error of an alternative stops the rule with an InvalidRuleError.
+func (vs *validation) runAlternatives(parent reflect.Value, fieldType reflect.StructField, namespace string, value reflect.Value, r rule) bool {
+	v := vs.v
+	var msgs []string
+	for _, alt := range r.alts {
+		validator := v.ruleValidator(alt)
+		if validator == nil {
+			if !vs.unknownRule(fieldType, namespace, value, alt.key, alt.param) {
+				return false
+			}
+			continue
+		}
+		val := &Value{val: value, name: fieldType.Name, param: alt.param, parent: parent, validator: v, ctx: vs.ctx, call: vs}
+		err := callValidator(validator, val)
+		v.traceRule(namespace, alt.key, alt.param, validator, err)
+		if err == nil {
+			return true
+		}
+		if isConfigError(err) {
+			// a malformed alternative is reported as such, whatever the other alternatives say
+			return vs.fail(fieldType, namespace, value, alt.key, alt.param, err)
+		}
+		msgs = append(msgs, err.Error())
+	}
+	if len(msgs) == 0 {
+		return true
+	}
+	return vs.fail(fieldType, namespace, value, r.key, "", fmt.Errorf("should satisfy one of %s: %s", r.key, strings.Join(msgs, "; ")))
+}
+
+// runEach applies the inner rules of each={...} to every element of a slice or array,
+// or to at most sample elements when sample is not 0.
+func (vs *validation) runEach(parent reflect.Value, fieldType reflect.StructField, namespace string, value reflect.Value, r rule, sample int) bool {
+	if isNilInterface(value) {
+		// nothing to iterate, use required to reject nil values
+		return true
+	}
+	// only applicable to slices/arrays
+	kind := value.Kind()
+	if kind != reflect.Slice && kind != reflect.Array {
+		return vs.fail(fieldType, namespace, value, r.key, r.param, configErrorf("each can be used only with slice or array"))
+	}
+	inner, err := vs.v.collectionRules(r)
+	if err != nil {
+		return vs.fail(fieldType, namespace, value, r.key, r.param, ConfigError(err))
+	}
+	indexes := vs.sampleIndexes(namespace, r, value.Len(), sample)
+	if vs.v.parallelEach > 1 && !vs.worker && len(indexes) >= minParallelElements {
+		return vs.runEachParallel(parent, fieldType, namespace, value, inner, indexes)
+	}
+	for _, i := range indexes {
+		// report errors for the specific element value
+		if !vs.runRules(parent, fieldType, fmt.Sprintf("%s[%d]", namespace, i), value.Index(i), inner) {
+			return false
+		}
+	}
+	return true
+}
+
+// runMap applies the inner rules of keys={...} or values={...} to every key or value of a map.
+// Keys are visited in sorted order so errors are reported deterministically. When sample is not 0,
+// at most sample entries are checked.
+func (vs *validation) runMap(parent reflect.Value, fieldType reflect.StructField, namespace string, value reflect.Value, r rule, sample int) bool {
+	if isNilInterface(value) {
+		// nothing to iterate, use required to reject nil values
+		return true
+	}
+	if value.Kind() != reflect.Map {
+		return vs.fail(fieldType, namespace, value, r.key, r.param, configErrorf("%s can be used only with map", r.key))
+	}
+	inner, err := vs.v.collectionRules(r)
+	if err != nil {
+		return vs.fail(fieldType, namespace, value, r.key, r.param, ConfigError(err))
+	}
+	keys := value.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	for _, i := range vs.sampleIndexes(namespace, r, len(keys), sample) {
+		key := keys[i]
+		elem := key
+		if r.key == valuesTag {
+			elem = value.MapIndex(key)
+		}
+		if !vs.runRules(parent, fieldType, fmt.Sprintf("%s[%v]", namespace, key), elem, inner) {
+			return false
+		}
+	}
+	return true
+}
+
+// runDive validates the struct tags of a nested struct (or pointer to struct) value.
+// Fields of the nested struct are reported under namespace, e.g. Addresses[1].City.
+func (vs *validation) runDive(fieldType reflect.StructField, namespace string, value reflect.Value, r rule) bool {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			// nothing to dive into, use required to reject nil values
+			return true
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return vs.fail(fieldType, namespace, value, r.key, r.param, configErrorf("dive can be used only with struct"))
+	}
+	return vs.validateStruct(value, namespace+".")
+}
+
+// isNilInterface reports whether rv is an interface without a dynamic value, e.g. a missing key of ValidateMap.
+func isNilInterface(rv reflect.Value) bool {
+	return rv.Kind() == reflect.Interface && rv.IsNil()
+}
+
+// concrete unwraps interface values (any fields, elements of []any or map[string]any) so validators see their dynamic type.
+func concrete(rv reflect.Value) reflect.Value {
+	if rv.Kind() == reflect.Interface && !rv.IsNil() {
+		return rv.Elem()
+	}
+	return rv
+}
+
+// collectionRules returns the rules of a collection rule like each={min=1,max=5}, parsing them
+// unless the rule was compiled.
+func (v *Validator) collectionRules(r rule) ([]rule, error) {
+	if r.compiled {
+		return r.inner, r.innerErr
+	}
+	return v.innerRules(r)
+}
+
+// ruleValidator returns the validator of a rule, looking it up unless the rule was compiled.
+func (v *Validator) ruleValidator(r rule) TagValidationFunc {
+	if r.compiled {
+		return r.fn
+	}
+	return v.validator(r.key)
+}
+
+// innerRules parses the rules of a collection rule like each={min=1,max=5}.
+func (v *Validator) innerRules(r rule) ([]rule, error) {
+	inner := strings.TrimSpace(r.param)
+	if strings.HasPrefix(inner, "{") && strings.HasSuffix(inner, "}") {
+		inner = inner[1 : len(inner)-1]
+	}
+	rules, err := parseRules(inner, r.source)
+	if err != nil {
+		return nil, err
+	}
+	return v.expandRules(rules)
+}
+
+// TagRules returns the rules stored under key in a struct tag. Long rule lists may be
+// continued under numbered keys (key2, key3, ...), which are joined in order with commas:
+//
+//	Password string `lakery:"required,min=12,max=64" lakery2:"notin=@common_passwords" lakery3:"minentropy=3"`
+//
+// Numbering has to be contiguous; lookup stops at the first missing continuation key.
+func TagRules(tag reflect.StructTag, key string) string {
+	rules, ok := tag.Lookup(key)
+	if !ok {
+		return ""
+	}
+	for n := 2; ; n++ {
+		more, ok := tag.Lookup(key + strconv.Itoa(n))
+		if !ok {
+			return rules
+		}
+		rules += "," + more
 	}
-	return nil
 }
 
 // splitTopLevelByComma splits a string by commas, ignoring commas inside curly braces.
 func splitTopLevelByComma(s string) ([]string, error) {
+	return splitTopLevel(s, ',')
+}
+
+// splitTopLevel splits a string by sep, ignoring separators inside curly braces and quoted params.
+// A param is quoted when it starts with a single quote right after '=' (regexp='^a{1,3}$'); it ends at
+// the next single quote which is not doubled, see UnquoteParam.
+func splitTopLevel(s string, sep rune) ([]string, error) {
 	var parts []string
 	depth := 0
 	last := 0
+	// closed is set right after the quote ending a quoted param
+	quoted, closed := false, false
+	var prev rune
 	for i, r := range s {
-		switch r {
-		case '{':
+		wasClosed := closed
+		closed = false
+		switch {
+		case quoted:
+			if r == '\'' {
+				quoted, closed = false, true
+			}
+		case r == '\'' && (prev == '=' || wasClosed):
+			// opens a quoted param, or continues it after a doubled quote
+			quoted = true
+		case r == '{':
 			depth++
-		case '}':
+		case r == '}':
 			depth--
-		case ',':
+		case r == sep:
 			if depth == 0 {
 				parts = append(parts, s[last:i])
 				last = i + 1
 			}
 		}
+		prev = r
 	}
 	parts = append(parts, s[last:])
+	if quoted {
+		return nil, fmt.Errorf("unclosed quote in %q", s)
+	}
 	if depth < 0 {
 		return nil, fmt.Errorf("unopened braces in %q", s)
 	} else if depth > 0 {
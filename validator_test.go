@@ -1,8 +1,19 @@
 package lakery_test
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
 	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -111,14 +122,1991 @@ var _ = Describe("Validator", func() {
 			s := S{Creds: []string{"", "bb"}}
 			Expect(v.Validate(s)).To(HaveOccurred())
 		})
+		It("names the failed element", func() {
+			v := lakery.NewValidator()
+			err := v.Validate(S{Creds: []string{"a", "toolong"}})
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Namespace).To(Equal("Creds[1]"))
+		})
 		It("errors when used on non-slice", func() {
 			type T struct {
-				Name string `lakery:"each={min=1}"`
+				Name string `lakery:"each={min=1}"`
+			}
+			v := lakery.NewValidator()
+			s := T{Name: "aa"}
+			err := v.Validate(s)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("alternatives", func() {
+		type S struct {
+			ID string `lakery:"required,min=10|max=3"`
+		}
+		It("passes when any alternative passes", func() {
+			v := lakery.NewValidator()
+			Expect(v.Validate(S{ID: "abc"})).To(Succeed())
+			Expect(v.Validate(S{ID: "0123456789"})).To(Succeed())
+		})
+		It("fails when all alternatives fail", func() {
+			v := lakery.NewValidator()
+			err := v.Validate(S{ID: "abcde"})
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Tag).To(Equal("min=10|max=3"))
+			Expect(err).To(MatchError(ContainSubstring("should satisfy one of min=10|max=3: should have length at least 10; should have length at most 3")))
+		})
+		It("works inside collection rules", func() {
+			type T struct {
+				Codes []string `lakery:"each={min=4|max=1}"`
+			}
+			v := lakery.NewValidator()
+			Expect(v.Validate(T{Codes: []string{"a", "abcd"}})).To(Succeed())
+			Expect(v.Validate(T{Codes: []string{"a", "ab"}})).To(MatchError(ContainSubstring("Codes[1]")))
+		})
+		It("rejects malformed alternatives", func() {
+			type T struct {
+				Name string `lakery:"min=1|"`
+			}
+			v := lakery.NewValidator()
+			Expect(v.Validate(T{Name: "x"})).To(MatchError(ContainSubstring("empty alternative")))
+		})
+		It("reports malformed alternatives as invalid rules", func() {
+			v := lakery.NewValidator()
+			for _, value := range []string{"ab", "abcdef"} {
+				var invalid *lakery.InvalidRuleError
+				err := v.Var(value, "min=abc|max=3")
+				Expect(errors.As(err, &invalid)).To(BeTrue(), value)
+				Expect(invalid.Tag).To(Equal("min"))
+				Expect(invalid.Param).To(Equal("abc"))
+			}
+			var invalid *lakery.InvalidRuleError
+			Expect(errors.As(v.Var(42, "max=3|email"), &invalid)).To(BeTrue())
+		})
+		It("is exposed by ParseRules", func() {
+			rules, err := lakery.ParseRules("required,min=10|max=3,each={a|b}")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rules).To(Equal([]lakery.Rule{
+				{Key: "required"},
+				{Alternatives: []lakery.Rule{{Key: "min", Param: "10"}, {Key: "max", Param: "3"}}},
+				{Key: "each", Param: "{a|b}"},
+			}))
+			Expect(rules[1].String()).To(Equal("min=10|max=3"))
+			_, err = lakery.ParseRules("dive|required")
+			Expect(err).To(MatchError(ContainSubstring("dive cannot be used in alternatives")))
+		})
+	})
+
+	Context("profiles", func() {
+		type S struct {
+			Password string   `lakery:"required,prod:min=12;dev:min=4;default:min=8"`
+			Quota    int      `lakery:"prod:max=100;staging:max=1000|max=-1;default:max=10000"`
+			Tags     []string `lakery:"each={prod:max=3;default:max=10}"`
+		}
+		It("selects the rule of the profile", func() {
+			prod := lakery.NewValidator(lakery.WithProfile("prod"))
+			dev := lakery.NewValidator(lakery.WithProfile("dev"))
+			s := S{Password: "secret"}
+			Expect(dev.Validate(s)).To(Succeed())
+			err := prod.Validate(s)
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Tag).To(Equal("min"))
+			Expect(fe.Param).To(Equal("12"))
+		})
+		It("supports alternatives and collection rules", func() {
+			staging := lakery.NewValidator(lakery.WithProfile("staging"))
+			Expect(staging.Validate(S{Password: "long enough", Quota: -1})).To(Succeed())
+			Expect(staging.Validate(S{Password: "long enough", Quota: 5000})).To(MatchError(ContainSubstring("Quota")))
+			prod := lakery.NewValidator(lakery.WithProfile("prod"))
+			err := prod.Validate(S{Password: "long enough pass", Tags: []string{"go", "toolong"}})
+			Expect(err).To(MatchError(ContainSubstring("Tags[1]")))
+		})
+		It("falls back to the default variant", func() {
+			v := lakery.NewValidator()
+			Expect(v.Validate(S{Password: "long enough", Quota: 5000, Tags: []string{"toolong"}})).To(Succeed())
+			Expect(v.Validate(S{Password: "x"})).To(MatchError(ContainSubstring("Password")))
+			rules, err := lakery.NewValidator(lakery.WithProfile("test")).Explain(S{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rules).To(Equal([]lakery.EffectiveRule{
+				{Field: "Password", Rule: "required", Source: lakery.RuleSourceTag},
+				{Field: "Password", Rule: "min=8", Source: lakery.RuleSourceTag},
+				{Field: "Quota", Rule: "max=10000", Source: lakery.RuleSourceTag},
+				{Field: "Tags", Rule: "each={prod:max=3;default:max=10}", Source: lakery.RuleSourceTag},
+			}))
+		})
+		It("rejects profile rules without a variant for the profile or a default", func() {
+			type T struct {
+				Password string `lakery:"required,prod:min=12;dev:min=4"`
+			}
+			var invalid *lakery.InvalidRuleError
+			err := lakery.NewValidator().Validate(T{Password: "x"})
+			Expect(errors.As(err, &invalid)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("needs a default variant or a profile set with WithProfile")))
+			err = lakery.NewValidator(lakery.WithProfile("staging")).Validate(T{Password: "x"})
+			Expect(errors.As(err, &invalid)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("has no variant for profile staging and no default variant")))
+			Expect(errors.As(lakery.NewValidator().Var("x", "prod:min=12"), &invalid)).To(BeTrue())
+		})
+		It("works for single values", func() {
+			v := lakery.NewValidator(lakery.WithProfile("prod"))
+			Expect(v.Var("short", "prod:min=12;dev:min=4")).To(HaveOccurred())
+		})
+		It("is exposed by ParseRules", func() {
+			rules, err := lakery.ParseRules("inrange=1:5,dev:min=4; prod:min=12|email")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rules).To(Equal([]lakery.Rule{
+				{Key: "inrange", Param: "1:5"},
+				{Profiles: map[string]lakery.Rule{
+					"dev":  {Key: "min", Param: "4"},
+					"prod": {Alternatives: []lakery.Rule{{Key: "min", Param: "12"}, {Key: "email"}}},
+				}},
+			}))
+			Expect(rules[1].String()).To(Equal("dev:min=4;prod:min=12|email"))
+		})
+		It("rejects malformed profile rules", func() {
+			for _, rules := range []string{"prod:min=1;", "prod:min=1;prod:min=2", "prod:", "min=1|prod:max=2", "prod:min=1;x y:max=2"} {
+				_, err := lakery.ParseRules(rules)
+				Expect(err).To(HaveOccurred(), rules)
+			}
+		})
+	})
+
+	Context("quoted params", func() {
+		It("keeps separators inside quotes", func() {
+			rules, err := lakery.ParseRules("regexp='^a{1,3}(b|c);$',oneof='in progress',notin='it''s'|min=1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rules).To(Equal([]lakery.Rule{
+				{Key: "regexp", Param: "'^a{1,3}(b|c);$'"},
+				{Key: "oneof", Param: "'in progress'"},
+				{Alternatives: []lakery.Rule{{Key: "notin", Param: "'it''s'"}, {Key: "min", Param: "1"}}},
+			}))
+			Expect(rules[2].String()).To(Equal("notin='it''s'|min=1"))
+			Expect(lakery.UnquoteParam(rules[2].Alternatives[0].Param)).To(Equal("it's"))
+			Expect(lakery.UnquoteParam("a'b")).To(Equal("a'b"))
+		})
+		It("passes the unquoted param to validators", func() {
+			v := lakery.NewValidator()
+			Expect(v.Var("in progress", "oneof='in progress'")).To(Succeed())
+			Expect(v.Var("in", "oneof='in progress'")).To(HaveOccurred())
+		})
+		It("rejects unclosed quotes", func() {
+			for _, rules := range []string{"regexp='^a", "regexp='it''s,min=1", "each={regexp='}"} {
+				_, err := lakery.ParseRules(rules)
+				Expect(err).To(MatchError(ContainSubstring("unclosed")), rules)
+			}
+		})
+	})
+
+	Context("omitempty", func() {
+		type S struct {
+			Nick  string   `lakery:"omitempty,min=5"`
+			Age   *int     `lakery:"omitempty,min=18"`
+			Email string   `lakery:"required,omitempty,min=5"`
+			Tags  []string `lakery:"each={omitempty,min=2}"`
+		}
+		It("skips remaining rules for zero values", func() {
+			v := lakery.NewValidator()
+			Expect(v.Validate(S{Email: "a@b.io", Tags: []string{"", "go"}})).To(Succeed())
+		})
+		It("enforces rules when a value is present", func() {
+			v := lakery.NewValidator()
+			Expect(v.Validate(S{Nick: "bob", Email: "a@b.io"})).To(MatchError(ContainSubstring("Nick")))
+			age := 12
+			Expect(v.Validate(S{Age: &age, Email: "a@b.io"})).To(MatchError(ContainSubstring("Age")))
+			Expect(v.Validate(S{Email: "a@b.io", Tags: []string{"x"}})).To(MatchError(ContainSubstring("Tags[0]")))
+		})
+		It("still runs the rules before it", func() {
+			v := lakery.NewValidator()
+			err := v.Validate(S{})
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Field).To(Equal("Email"))
+			Expect(fe.Tag).To(Equal("required"))
+		})
+	})
+
+	Context("keys and values for maps", func() {
+		type S struct {
+			Labels map[string]string `lakery:"keys={min=3},values={required,max=10}"`
+		}
+		It("passes when all keys and values are valid", func() {
+			v := lakery.NewValidator()
+			Expect(v.Validate(S{Labels: map[string]string{"env": "prod", "team": "core"}})).To(Succeed())
+		})
+		It("reports the failed key", func() {
+			v := lakery.NewValidator()
+			err := v.Validate(S{Labels: map[string]string{"env": "prod", "id": "x"}})
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Field).To(Equal("Labels"))
+			Expect(fe.Namespace).To(Equal("Labels[id]"))
+			Expect(fe.Tag).To(Equal("min"))
+			Expect(err.Error()).To(HavePrefix("Labels[id]: "))
+		})
+		It("reports the failed value", func() {
+			v := lakery.NewValidator()
+			err := v.Validate(S{Labels: map[string]string{"env": "", "team": "core"}})
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Namespace).To(Equal("Labels[env]"))
+			Expect(fe.Tag).To(Equal("required"))
+		})
+		It("visits keys in sorted order", func() {
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			err := v.Validate(S{Labels: map[string]string{"zz": "a", "aa": "b", "mm": "c"}})
+			var errs lakery.ValidationErrors
+			Expect(errors.As(err, &errs)).To(BeTrue())
+			namespaces := make([]string, 0, len(errs))
+			for _, fe := range errs {
+				namespaces = append(namespaces, fe.Namespace)
+			}
+			Expect(namespaces).To(Equal([]string{"Labels[aa]", "Labels[mm]", "Labels[zz]"}))
+		})
+		It("supports nested collection rules", func() {
+			type T struct {
+				Groups map[string][]string `lakery:"values={each={min=2}}"`
+			}
+			v := lakery.NewValidator()
+			err := v.Validate(T{Groups: map[string][]string{"admins": {"root", "x"}}})
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Namespace).To(Equal("Groups[admins][1]"))
+		})
+		It("errors when used on non-map", func() {
+			type T struct {
+				Name string `lakery:"keys={min=1}"`
+			}
+			v := lakery.NewValidator()
+			Expect(v.Validate(T{Name: "aa"})).To(MatchError(ContainSubstring("keys can be used only with map")))
+		})
+	})
+
+	Context("dive", func() {
+		type Address struct {
+			City string `lakery:"required"`
+			Zip  string `lakery:"min=5,max=5"`
+		}
+		type S struct {
+			Home      *Address  `lakery:"dive"`
+			Addresses []Address `lakery:"min=1,each={dive}"`
+		}
+		It("passes when nested structs are valid", func() {
+			v := lakery.NewValidator()
+			s := S{Addresses: []Address{{City: "Paris", Zip: "75001"}}}
+			Expect(v.Validate(s)).To(Succeed())
+		})
+		It("includes the element index in the error path", func() {
+			v := lakery.NewValidator()
+			s := S{Addresses: []Address{{City: "Paris", Zip: "75001"}, {Zip: "10115"}}}
+			err := v.Validate(s)
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Field).To(Equal("City"))
+			Expect(fe.Namespace).To(Equal("Addresses[1].City"))
+			Expect(err.Error()).To(HavePrefix("Addresses[1].City: "))
+		})
+		It("dives into pointers to structs", func() {
+			v := lakery.NewValidator()
+			s := S{Home: &Address{City: "Berlin", Zip: "1"}, Addresses: []Address{{City: "Paris", Zip: "75001"}}}
+			err := v.Validate(s)
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Namespace).To(Equal("Home.Zip"))
+		})
+		It("errors when used on non-struct", func() {
+			type T struct {
+				Names []string `lakery:"each={dive}"`
+			}
+			v := lakery.NewValidator()
+			Expect(v.Validate(T{Names: []string{"a"}})).To(MatchError(ContainSubstring("dive can be used only with struct")))
+		})
+	})
+
+	Context("recursive structs", func() {
+		type Node struct {
+			Name     string  `lakery:"required"`
+			Next     *Node   `lakery:"dive"`
+			Children []*Node `lakery:"each={dive}"`
+		}
+		list := func(n int) *Node {
+			head := &Node{Name: "0"}
+			for node, i := head, 1; i < n; i++ {
+				node.Next = &Node{Name: strconv.Itoa(i)}
+				node = node.Next
+			}
+			return head
+		}
+		It("validates every node", func() {
+			v := lakery.NewValidator()
+			head := list(3)
+			Expect(v.Validate(head)).To(Succeed())
+			head.Next.Next.Name = ""
+			Expect(v.Validate(head)).To(MatchError(ContainSubstring("Next.Next.Name")))
+		})
+		It("stops at pointer cycles", func() {
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			head := list(3)
+			head.Next.Next.Next = head
+			head.Children = []*Node{head, {Name: "leaf", Next: head}}
+			Expect(v.Validate(head)).To(Succeed())
+			head.Next.Name = ""
+			var errs lakery.ValidationErrors
+			Expect(errors.As(v.Validate(head), &errs)).To(BeTrue())
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Namespace).To(Equal("Next.Name"))
+		})
+		It("validates shared structs at every path", func() {
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			shared := &Node{}
+			var errs lakery.ValidationErrors
+			Expect(errors.As(v.Validate(Node{Name: "root", Next: shared, Children: []*Node{shared}}), &errs)).To(BeTrue())
+			Expect(errs).To(HaveLen(2))
+		})
+		It("fails when structs are nested too deep", func() {
+			v := lakery.NewValidator(lakery.WithMaxDepth(3))
+			Expect(v.Validate(list(4))).To(Succeed())
+			err := v.Validate(list(5))
+			Expect(errors.Is(err, lakery.ErrMaxDepth)).To(BeTrue())
+			Expect(err).To(MatchError("maximum depth exceeded: Next.Next.Next.Next is nested in more than 3 structs"))
+			Expect(v.Clone().Validate(list(5))).To(MatchError(lakery.ErrMaxDepth))
+		})
+		It("limits the depth to DefaultMaxDepth by default", func() {
+			v := lakery.NewValidator()
+			Expect(v.Validate(list(lakery.DefaultMaxDepth + 1))).To(Succeed())
+			Expect(v.Validate(list(lakery.DefaultMaxDepth + 2))).To(MatchError(lakery.ErrMaxDepth))
+		})
+	})
+
+	Context("inline structs", func() {
+		type S struct {
+			Name string `lakery:"required"`
+			Opts struct {
+				Retries int `lakery:"min=1"`
+				Backoff *struct {
+					Max int `lakery:"max=60"`
+				}
+			}
+		}
+		It("validates fields of anonymous struct fields without a tag", func() {
+			v := lakery.NewValidator()
+			s := S{Name: "job"}
+			err := v.Validate(s)
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Namespace).To(Equal("Opts.Retries"))
+			s.Opts.Retries = 3
+			Expect(v.Validate(s)).To(Succeed())
+		})
+		It("follows pointers to anonymous structs", func() {
+			v := lakery.NewValidator()
+			s := S{Name: "job"}
+			s.Opts.Retries = 3
+			s.Opts.Backoff = &struct {
+				Max int `lakery:"max=60"`
+			}{Max: 120}
+			err := v.Validate(s)
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Namespace).To(Equal("Opts.Backoff.Max"))
+		})
+		It("validates them once when they are dived", func() {
+			type T struct {
+				Opts struct {
+					Retries int `lakery:"min=1"`
+				} `lakery:"dive"`
+			}
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			var errs lakery.ValidationErrors
+			Expect(errors.As(v.Validate(T{}), &errs)).To(BeTrue())
+			Expect(errs).To(HaveLen(1))
+		})
+		It("selects their fields in partial validation", func() {
+			v := lakery.NewValidator()
+			Expect(v.ValidatePartial(S{}, "Name")).To(MatchError(ContainSubstring("Name")))
+			Expect(v.ValidatePartial(S{Name: "job"}, "Opts.Retries")).To(MatchError(ContainSubstring("Opts.Retries")))
+			Expect(v.ValidateExcept(S{Name: "job"}, "Opts")).To(Succeed())
+		})
+	})
+
+	Context("interface fields", func() {
+		type Address struct {
+			City string `lakery:"required"`
+		}
+		type S struct {
+			Name    any   `lakery:"required,min=3,max=10"`
+			Amount  any   `lakery:"omitempty,money=2"`
+			Address any   `lakery:"dive"`
+			Values  []any `lakery:"each={min=2}"`
+		}
+		It("applies rules to the dynamic value", func() {
+			v := lakery.NewValidator()
+			Expect(v.Validate(S{Name: "john", Amount: 9.99, Address: Address{City: "Oslo"}, Values: []any{"ab", 5}})).To(Succeed())
+			Expect(v.Validate(S{Name: "jo"})).To(MatchError(ContainSubstring("should have length at least 3")))
+			Expect(v.Validate(S{Name: "john", Amount: "1.001"})).To(MatchError(ContainSubstring("at most 2 decimal places")))
+			Expect(v.Validate(S{Name: "john", Values: []any{1}})).To(MatchError(ContainSubstring("should be >= 2")))
+		})
+		It("follows pointers held by interfaces", func() {
+			v := lakery.NewValidator()
+			name := "jo"
+			Expect(v.Validate(S{Name: &name})).To(MatchError(ContainSubstring("should have length at least 3")))
+			Expect(v.Validate(S{Name: "john", Address: &Address{}})).To(MatchError(ContainSubstring("Address.City")))
+		})
+		It("treats nil interfaces like nil pointers", func() {
+			type T struct {
+				Name any `lakery:"min=3"`
+				Nick any `lakery:"max=3,notin=root"`
+			}
+			v := lakery.NewValidator()
+			Expect(v.Validate(S{})).To(MatchError(ContainSubstring("is required")))
+			Expect(v.Validate(T{})).To(MatchError(ContainSubstring("should have length at least 3")))
+			Expect(v.Validate(T{Name: "john"})).To(Succeed())
+		})
+	})
+
+	Context("embedded structs", func() {
+		type Audit struct {
+			CreatedBy string `lakery:"required"`
+		}
+		type base struct {
+			ID int `lakery:"min=1"`
+		}
+		type S struct {
+			base
+			*Audit
+			Name string `lakery:"required"`
+		}
+		It("validates promoted fields under the parent's namespace", func() {
+			v := lakery.NewValidator()
+			err := v.Validate(S{Name: "x"})
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Namespace).To(Equal("ID"))
+			Expect(err.Error()).NotTo(HavePrefix("base."))
+		})
+		It("skips nil embedded pointers and validates set ones", func() {
+			v := lakery.NewValidator()
+			s := S{base: base{ID: 1}, Name: "x"}
+			Expect(v.Validate(s)).To(Succeed())
+			s.Audit = &Audit{}
+			err := v.Validate(s)
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Namespace).To(Equal("CreatedBy"))
+			s.CreatedBy = "admin"
+			Expect(v.Validate(s)).To(Succeed())
+		})
+		It("lets custom validators read promoted fields of unexported embedded structs", func() {
+			v := lakery.NewValidator()
+			v.RegisterTag("positive", func(val *lakery.Value) error {
+				if val.Interface().(int) <= 0 {
+					return errors.New("should be positive")
+				}
+				return nil
+			})
+			type inner struct {
+				N int `lakery:"positive"`
+			}
+			type T struct{ inner }
+			Expect(v.Validate(T{})).To(MatchError(ContainSubstring("should be positive")))
+		})
+		It("selects promoted fields by name in partial validation", func() {
+			v := lakery.NewValidator()
+			Expect(v.ValidatePartial(S{Audit: &Audit{}}, "ID")).To(MatchError(ContainSubstring("ID")))
+			Expect(v.ValidatePartial(S{base: base{ID: 1}, Audit: &Audit{}}, "ID", "Name")).To(MatchError(ContainSubstring("Name")))
+			Expect(v.ValidateExcept(S{Name: "x", Audit: &Audit{}}, "ID", "CreatedBy")).To(Succeed())
+		})
+	})
+
+	Context("collect all", func() {
+		type S struct {
+			Name  string   `lakery:"required,min=2"`
+			Age   int      `lakery:"max=150"`
+			Creds []string `lakery:"each={min=2}"`
+		}
+
+		It("stops at the first failure by default", func() {
+			v := lakery.NewValidator()
+			err := v.Validate(S{Age: 200, Creds: []string{"a"}})
+			var fieldErr *lakery.FieldError
+			Expect(errors.As(err, &fieldErr)).To(BeTrue())
+			Expect(fieldErr.Field).To(Equal("Name"))
+			Expect(fieldErr.Tag).To(Equal("required"))
+		})
+
+		It("returns every failed rule of every field", func() {
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			err := v.Validate(S{Age: 200, Creds: []string{"a", "bb", "c"}})
+			var errs lakery.ValidationErrors
+			Expect(errors.As(err, &errs)).To(BeTrue())
+			var failed []string
+			for _, e := range errs {
+				failed = append(failed, e.Field+":"+e.Tag)
+			}
+			Expect(failed).To(Equal([]string{"Name:required", "Name:min", "Age:max", "Creds:min", "Creds:min"}))
+			Expect(strings.Count(err.Error(), "\n")).To(Equal(4))
+		})
+
+		It("returns nil when everything passes", func() {
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			Expect(v.Validate(S{Name: "john", Age: 30})).To(Succeed())
+		})
+	})
+
+	Context("errors by field", func() {
+		type S struct {
+			Name  string   `lakery:"required,min=2"`
+			Age   int      `lakery:"max=150"`
+			Creds []string `lakery:"each={min=2}"`
+		}
+
+		It("keys the first failure of every path by namespace", func() {
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			errs := lakery.FieldErrors(v.Validate(S{Age: 200, Creds: []string{"a", "bb", "c"}}))
+			Expect(errs).To(HaveLen(5))
+			byField := errs.ByField()
+			Expect(byField).To(HaveLen(4))
+			Expect(byField).To(HaveKey("Creds[2]"))
+			var fe *lakery.FieldError
+			Expect(errors.As(byField["Name"], &fe)).To(BeTrue())
+			Expect(fe.Tag).To(Equal("required"))
+			Expect(errs.Messages()).To(Equal(map[string]string{
+				"Name":     `field "Name" validation error: is required (received: '')`,
+				"Age":      `field "Age" validation error: should be <= 150 (received: '200')`,
+				"Creds[0]": `field "Creds" validation error: should have length at least 2 (received: 'a')`,
+				"Creds[2]": `field "Creds" validation error: should have length at least 2 (received: 'c')`,
+			}))
+		})
+
+		It("extracts the failures of any error returned by Validate", func() {
+			v := lakery.NewValidator()
+			errs := lakery.FieldErrors(v.Validate(S{Name: "john", Age: 200}))
+			Expect(errs.ByField()).To(HaveKey("Age"))
+			Expect(lakery.FieldErrors(v.Validate(S{Name: "john"}))).To(BeNil())
+			type Bad struct {
+				Age int `lakery:"min=abc"`
+			}
+			Expect(lakery.FieldErrors(v.Validate(Bad{}))).To(BeNil())
+			Expect(lakery.FieldErrors(context.Canceled)).To(BeNil())
+		})
+	})
+
+	Context("type rules", func() {
+		type Username string
+		type S struct {
+			Owner    Username
+			Reviewer *Username `lakery:"max=5"`
+		}
+
+		It("applies rules of named types without field tags", func() {
+			v := lakery.NewValidator()
+			v.RegisterTypeRules(Username(""), "required,min=3")
+			Expect(v.Validate(S{})).To(MatchError(ContainSubstring("is required")))
+			Expect(v.Validate(S{Owner: "jo"})).To(MatchError(ContainSubstring("at least 3")))
+			Expect(v.Validate(S{Owner: "john"})).To(MatchError(ContainSubstring(`"Reviewer"`)))
+		})
+
+		It("combines type rules with field tags", func() {
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			v.RegisterTypeRules(Username(""), "min=3")
+			reviewer := Username("alexander")
+			err := v.Validate(S{Owner: "john", Reviewer: &reviewer})
+			var errs lakery.ValidationErrors
+			Expect(errors.As(err, &errs)).To(BeTrue())
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Field).To(Equal("Reviewer"))
+			Expect(errs[0].Tag).To(Equal("max"))
+		})
+	})
+
+	Context("custom type funcs", func() {
+		nullString := func(rv reflect.Value) reflect.Value {
+			if ns := rv.Interface().(sql.NullString); ns.Valid {
+				return reflect.ValueOf(ns.String)
+			}
+			return reflect.Value{}
+		}
+		type S struct {
+			Name  sql.NullString  `lakery:"required,min=3"`
+			Nick  *sql.NullString `lakery:"omitempty,max=5"`
+			Codes []sql.NullInt64 `lakery:"each={omitempty,min=100}"`
+		}
+		newValidator := func() *lakery.Validator {
+			v := lakery.NewValidator()
+			v.RegisterCustomTypeFunc(nullString, sql.NullString{})
+			v.RegisterCustomTypeFunc(func(rv reflect.Value) reflect.Value {
+				if n := rv.Interface().(sql.NullInt64); n.Valid {
+					return reflect.ValueOf(n.Int64)
+				}
+				return reflect.Value{}
+			}, sql.NullInt64{})
+			return v
+		}
+		It("runs rules against the unwrapped value", func() {
+			v := newValidator()
+			nick := sql.NullString{String: "jo", Valid: true}
+			s := S{Name: sql.NullString{String: "john", Valid: true}, Nick: &nick, Codes: []sql.NullInt64{{}, {Int64: 200, Valid: true}}}
+			Expect(v.Validate(s)).To(Succeed())
+			s.Name.String = "jo"
+			Expect(v.Validate(s)).To(MatchError(ContainSubstring("should have length at least 3")))
+		})
+		It("treats null values as nil", func() {
+			v := newValidator()
+			Expect(v.Validate(S{})).To(MatchError(ContainSubstring("Name")))
+			Expect(v.Validate(S{Name: sql.NullString{String: "john", Valid: true}, Nick: &sql.NullString{}})).To(Succeed())
+		})
+		It("unwraps elements of collections", func() {
+			v := newValidator()
+			s := S{Name: sql.NullString{String: "john", Valid: true}, Codes: []sql.NullInt64{{Int64: 5, Valid: true}}}
+			err := v.Validate(s)
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Namespace).To(Equal("Codes[0]"))
+		})
+		It("works for single values", func() {
+			v := newValidator()
+			Expect(v.Var(sql.NullString{String: "ab", Valid: true}, "min=3")).To(HaveOccurred())
+		})
+	})
+
+	Context("custom tags", func() {
+		It("accepts dotted tag names", func() {
+			v := lakery.NewValidator(lakery.WithStrictTags())
+			v.RegisterTag("acme.slug", func(val *lakery.Value) error {
+				if strings.ContainsAny(val.String(), " /") {
+					return errors.New("should be a slug")
+				}
+				return nil
+			})
+			Expect(v.Var("my-post", "required,acme.slug")).To(Succeed())
+			Expect(v.Var("my post", "required,acme.slug|uuid")).To(MatchError(ContainSubstring("should be a slug")))
+		})
+	})
+
+	Context("value accessors", func() {
+		It("dereferences pointers for custom validators", func() {
+			v := lakery.NewValidator()
+			v.RegisterTag("even", func(val *lakery.Value) error {
+				if val.Deref().IsNil() {
+					return nil
+				}
+				if val.Int()%2 != 0 {
+					return errors.New("should be even")
+				}
+				return nil
+			})
+			v.RegisterTag("upper", func(val *lakery.Value) error {
+				if s := val.String(); s != strings.ToUpper(s) {
+					return errors.New("should be upper case")
+				}
+				return nil
+			})
+			type S struct {
+				N    *int     `lakery:"even"`
+				U    *uint8   `lakery:"even"`
+				Code **string `lakery:"upper"`
+			}
+			n, u, code := 3, uint8(4), "abc"
+			codePtr := &code
+			Expect(v.Validate(S{})).To(Succeed())
+			Expect(v.Validate(S{N: &n})).To(MatchError(ContainSubstring("should be even")))
+			Expect(v.Validate(S{U: &u, Code: &codePtr})).To(MatchError(ContainSubstring("should be upper case")))
+			code = "ABC"
+			Expect(v.Validate(S{U: &u, Code: &codePtr})).To(Succeed())
+		})
+		It("splits list and pair params", func() {
+			v := lakery.NewValidator()
+			v.RegisterTag("oneof", func(val *lakery.Value) error {
+				for _, allowed := range val.Params() {
+					if val.String() == allowed {
+						return nil
+					}
+				}
+				return errors.New("is not allowed")
+			})
+			v.RegisterTag("between", func(val *lakery.Value) error {
+				lo, hi, ok := val.ParamPair()
+				if !ok {
+					return lakery.ConfigError(errors.New("between expects lo:hi param"))
+				}
+				if n := strconv.FormatInt(val.Int(), 10); len(n) < len(lo) || len(n) > len(hi) {
+					return errors.New("has the wrong number of digits")
+				}
+				return nil
+			})
+			Expect(v.Var("green", "oneof=red green blue")).To(Succeed())
+			Expect(v.Var("green", "oneof={red, green}")).To(Succeed())
+			Expect(v.Var("in progress", "oneof={in progress,done}")).To(Succeed())
+			Expect(v.Var("progress", "oneof={in progress,done}")).To(MatchError(ContainSubstring("is not allowed")))
+			Expect(v.Var("in progress", "oneof='in progress' done")).To(Succeed())
+			Expect(v.Var("done", "oneof='in progress' done")).To(Succeed())
+			Expect(v.Var("in", "oneof='in progress' done")).To(MatchError(ContainSubstring("is not allowed")))
+			Expect(v.Var("it's", "oneof={'it''s','a,b'}")).To(Succeed())
+			Expect(v.Var("a,b", "oneof={'it''s','a,b'}")).To(Succeed())
+			Expect(v.Var("", "oneof='' none")).To(Succeed())
+			Expect(v.Var("pink", "oneof=red green")).To(MatchError(ContainSubstring("is not allowed")))
+			Expect(v.Var(123, "between=1:1000")).To(Succeed())
+			Expect(v.Var(1234, "between=1:100")).To(HaveOccurred())
+			var invalid *lakery.InvalidRuleError
+			Expect(errors.As(v.Var(1, "between=10"), &invalid)).To(BeTrue())
+		})
+		It("keeps the param of the dereferenced value", func() {
+			v := lakery.NewValidator()
+			v.RegisterTag("prefix", func(val *lakery.Value) error {
+				d := val.Deref()
+				if !strings.HasPrefix(d.String(), d.Param()) {
+					return errors.New("has no prefix")
+				}
+				return nil
+			})
+			s := "id-1"
+			Expect(v.Var(&s, "prefix=id-")).To(Succeed())
+			Expect(v.Var(&s, "prefix=pk-")).To(HaveOccurred())
+		})
+		It("exposes the parent struct and sibling fields", func() {
+			type Period struct {
+				Start int   `lakery:"before_end=End"`
+				End   *int  `lakery:"parent_kind"`
+				Slots []int `lakery:"each={before_end=End}"`
+			}
+			v := lakery.NewValidator()
+			v.RegisterTag("before_end", func(val *lakery.Value) error {
+				end, err := val.Sibling(val.Param())
+				if err != nil {
+					return err
+				}
+				if !end.Deref().IsNil() && val.Int() >= end.Int() {
+					return fmt.Errorf("should be before %s", val.Param())
+				}
+				return nil
+			})
+			v.RegisterTag("parent_kind", func(val *lakery.Value) error {
+				if val.Parent().Type() != reflect.TypeFor[Period]() {
+					return errors.New("has the wrong parent")
+				}
+				return nil
+			})
+			end := 10
+			Expect(v.Validate(Period{Start: 1, End: &end, Slots: []int{2, 3}})).To(Succeed())
+			Expect(v.Validate(Period{Start: 1})).To(Succeed())
+			Expect(v.Validate(Period{Start: 10, End: &end})).To(MatchError(ContainSubstring("should be before End")))
+			Expect(v.Validate(Period{Start: 1, End: &end, Slots: []int{2, 12}})).To(MatchError(ContainSubstring("Slots[1]")))
+
+			var invalid *lakery.InvalidRuleError
+			type Typo struct {
+				Start int `lakery:"before_end=Ende"`
+			}
+			Expect(errors.As(v.Validate(Typo{}), &invalid)).To(BeTrue())
+			Expect(invalid).To(MatchError(ContainSubstring(`unknown field "Ende"`)))
+			Expect(errors.As(v.Var(1, "before_end=End"), &invalid)).To(BeTrue())
+			v.RegisterTag("has_parent", func(val *lakery.Value) error {
+				if !val.Parent().IsValid() {
+					return errors.New("has no parent")
+				}
+				return nil
+			})
+			Expect(v.Var(1, "has_parent")).To(MatchError(ContainSubstring("has no parent")))
+		})
+	})
+
+	Context("skipped and unexported fields", func() {
+		type Meta struct {
+			Owner string `lakery:"required"`
+		}
+		type S struct {
+			Name   string `lakery:"required"`
+			Legacy string `lakery:"-"`
+			Meta   `lakery:"-"`
+			Extra  *Meta  `lakery:"-"`
+			secret string `lakery:"required"`
+		}
+		It("excludes fields tagged with -", func() {
+			v := lakery.NewValidator()
+			v.RegisterTypeRules("", "max=3")
+			Expect(v.Validate(S{Name: "abc", Legacy: "too long", Extra: &Meta{}})).To(Succeed())
+			rules, err := v.Explain(S{})
+			Expect(err).NotTo(HaveOccurred())
+			for _, r := range rules {
+				Expect(r.Field).To(Equal("Name"))
+			}
+		})
+		It("skips rules of unexported fields by default", func() {
+			v := lakery.NewValidator()
+			Expect(v.Validate(S{Name: "abc"})).To(Succeed())
+		})
+		It("reports rules of unexported fields as invalid rules when configured", func() {
+			v := lakery.NewValidator(lakery.WithUnexportedFields(lakery.UnexportedError))
+			err := v.Validate(S{Name: "abc", secret: "x"})
+			var invalid *lakery.InvalidRuleError
+			Expect(errors.As(err, &invalid)).To(BeTrue())
+			Expect(invalid.Field).To(Equal("secret"))
+			Expect(err).To(MatchError(ContainSubstring("unexported field secret")))
+			_, err = v.Explain(S{})
+			Expect(err).To(HaveOccurred())
+		})
+		It("still validates promoted fields of unexported embedded structs", func() {
+			type inner struct {
+				N int `lakery:"min=1"`
+			}
+			type T struct{ inner }
+			v := lakery.NewValidator(lakery.WithUnexportedFields(lakery.UnexportedError))
+			Expect(v.Validate(T{})).To(MatchError(ContainSubstring("should be >= 1")))
+		})
+	})
+
+	Context("shared state", func() {
+		type sumKey struct{}
+		newValidator := func() *lakery.Validator {
+			v := lakery.NewValidator()
+			v.RegisterTag("summed", func(val *lakery.Value) error {
+				sum := 0
+				for _, r := range val.String() {
+					sum += int(r - '0')
+				}
+				val.Store(sumKey{}, sum%10)
+				return nil
+			})
+			v.RegisterTag("checksum", func(val *lakery.Value) error {
+				sum, ok := val.Load(sumKey{})
+				if !ok {
+					return errors.New("has nothing to check")
+				}
+				if int64(sum.(int)) != val.Int() {
+					return errors.New("does not match")
+				}
+				return nil
+			})
+			return v
+		}
+		type S struct {
+			Digits   string `lakery:"summed"`
+			Checksum int    `lakery:"checksum"`
+		}
+		It("shares data between validators of one call", func() {
+			v := newValidator()
+			Expect(v.Validate(S{Digits: "1234", Checksum: 0})).To(Succeed())
+			Expect(v.Validate(S{Digits: "1235", Checksum: 0})).To(MatchError(ContainSubstring("does not match")))
+		})
+		It("does not keep data between calls", func() {
+			v := newValidator()
+			Expect(v.Validate(S{Digits: "1234"})).To(Succeed())
+			Expect(v.Var(0, "checksum")).To(MatchError(ContainSubstring("has nothing to check")))
+		})
+		It("updates data atomically for parallel workers", func() {
+			type countKey struct{}
+			v := lakery.NewValidator(lakery.WithParallelEach(8))
+			v.RegisterTag("counted", func(val *lakery.Value) error {
+				val.Update(countKey{}, func(old any) any {
+					n, _ := old.(int)
+					return n + 1
+				})
+				return nil
+			})
+			v.RegisterTag("count", func(val *lakery.Value) error {
+				if n, _ := val.Load(countKey{}); n != int(val.Int()) {
+					return fmt.Errorf("counted %v", n)
+				}
+				return nil
+			})
+			type Batch struct {
+				Items []int `lakery:"each={counted}"`
+				Count int   `lakery:"count"`
+			}
+			Expect(v.Validate(Batch{Items: make([]int, 20000), Count: 20000})).To(Succeed())
+		})
+	})
+
+	Context("aliases", func() {
+		type S struct {
+			Password string   `lakery:"password"`
+			Tags     []string `lakery:"each={tag}"`
+		}
+		newValidator := func() *lakery.Validator {
+			v := lakery.NewValidator()
+			v.RegisterAlias("tag", "required,max=5")
+			v.RegisterAlias("password", "required,min=8,max=64")
+			return v
+		}
+		It("expands an alias into its rules", func() {
+			v := newValidator()
+			Expect(v.Validate(S{Password: "secret-password"})).To(Succeed())
+			err := v.Validate(S{Password: "short"})
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Tag).To(Equal("min"))
+			Expect(v.Validate(S{})).To(MatchError(ContainSubstring("is required")))
+		})
+		It("expands aliases inside each", func() {
+			v := newValidator()
+			err := v.Validate(S{Password: "secret-password", Tags: []string{"go", "toolong"}})
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Namespace).To(Equal("Tags[1]"))
+			Expect(fe.Tag).To(Equal("max"))
+		})
+		It("expands aliases of single values and aliases used by aliases", func() {
+			v := newValidator()
+			v.RegisterAlias("strong_password", "password,minentropy=3")
+			Expect(v.Var("short", "password")).To(HaveOccurred())
+			Expect(v.Var("aaaaaaaaaa", "strong_password")).To(MatchError(ContainSubstring("entropy")))
+		})
+		It("explains the expanded rules", func() {
+			v := newValidator()
+			rules, err := v.Explain(S{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rules[:3]).To(Equal([]lakery.EffectiveRule{
+				{Field: "Password", Rule: "required", Source: lakery.RuleSourceTag},
+				{Field: "Password", Rule: "min=8", Source: lakery.RuleSourceTag},
+				{Field: "Password", Rule: "max=64", Source: lakery.RuleSourceTag},
+			}))
+		})
+		It("invalidates cached rules", func() {
+			v := lakery.NewValidator()
+			v.RegisterAlias("password", "min=3")
+			Expect(v.Validate(S{Password: "abc"})).To(Succeed())
+			v.RegisterAlias("password", "min=8")
+			Expect(v.Validate(S{Password: "abc"})).To(HaveOccurred())
+		})
+		It("panics on malformed aliases", func() {
+			v := lakery.NewValidator()
+			Expect(func() { v.RegisterAlias("", "required") }).To(Panic())
+			Expect(func() { v.RegisterAlias("each", "required") }).To(Panic())
+			Expect(func() { v.RegisterAlias("broken", "min=1|") }).To(Panic())
+		})
+	})
+
+	Context("compiled rules", func() {
+		type S struct {
+			Tags  []string       `lakery:"each={sku}"`
+			Code  string         `lakery:"sku|max=1"`
+			Items map[string]int `lakery:"values={each_sample=1,min=1}"`
+		}
+		It("pick up validators registered after the first validation", func() {
+			v := lakery.NewValidator()
+			s := S{Tags: []string{"x"}, Code: "x"}
+			Expect(v.Validate(s)).To(Succeed())
+			v.RegisterTag("sku", func(*lakery.Value) error { return errors.New("bad sku") })
+			Expect(v.Validate(s)).To(MatchError(ContainSubstring("Tags[0]")))
+			Expect(v.Validate(S{Code: "xy"})).To(MatchError(ContainSubstring("bad sku")))
+			v.UnregisterTag("sku")
+			Expect(v.Validate(s)).To(Succeed())
+		})
+		It("are reused by concurrent validations", func() {
+			v := lakery.NewValidator()
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					for j := 0; j < 100; j++ {
+						Expect(v.Validate(S{Items: map[string]int{"a": 0}})).To(MatchError(ContainSubstring("Items[a]")))
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	})
+
+	Context("rule precedence", func() {
+		type Username string
+		type S struct {
+			Name Username `lakery:"min=4,max=10"`
+		}
+
+		newValidator := func(opts ...lakery.Option) *lakery.Validator {
+			v := lakery.NewValidator(opts...)
+			v.RegisterTypeRules(Username(""), "required,min=2")
+			v.RegisterStructRules(S{}, map[string]string{"Name": "max=6"})
+			return v
+		}
+
+		explain := func(v *lakery.Validator) []string {
+			rules, err := v.Explain(S{})
+			Expect(err).NotTo(HaveOccurred())
+			var out []string
+			for _, r := range rules {
+				out = append(out, r.Field+":"+r.Rule+"@"+r.Source.String())
+			}
+			return out
+		}
+
+		It("appends sources and lets the higher precedence win duplicated rules", func() {
+			v := newValidator()
+			Expect(explain(v)).To(Equal([]string{"Name:required@type", "Name:min=4@tag", "Name:max=6@programmatic"}))
+			Expect(v.Validate(S{Name: "johnny"})).To(Succeed())
+			Expect(v.Validate(S{Name: "alexander"})).To(MatchError(ContainSubstring("at most 6")))
+		})
+
+		It("honors a custom precedence", func() {
+			v := newValidator(lakery.WithRulePrecedence(lakery.RuleSourceType, lakery.RuleSourceTag, lakery.RuleSourceProgrammatic))
+			Expect(explain(v)).To(Equal([]string{"Name:max=10@tag", "Name:required@type", "Name:min=2@type"}))
+		})
+
+		It("ignores sources left out of the precedence", func() {
+			v := newValidator(lakery.WithRulePrecedence(lakery.RuleSourceTag))
+			Expect(explain(v)).To(Equal([]string{"Name:min=4@tag", "Name:max=10@tag"}))
+		})
+
+		It("runs required before the omitempty of another source", func() {
+			type Email string
+			type T struct {
+				Contact Email `lakery:"required"`
+				Backup  Email
+			}
+			v := lakery.NewValidator()
+			v.RegisterTypeRules(Email(""), "omitempty,email")
+			Expect(v.Validate(T{})).To(MatchError(ContainSubstring("Contact")))
+			Expect(v.Validate(T{Contact: "ops@example.com"})).To(Succeed())
+			Expect(v.Validate(T{Contact: "ops"})).To(MatchError(ContainSubstring("email")))
+			rules, err := v.Explain(T{})
+			Expect(err).NotTo(HaveOccurred())
+			var out []string
+			for _, r := range rules {
+				out = append(out, r.Field+":"+r.Rule+"@"+r.Source.String())
+			}
+			Expect(out).To(Equal([]string{"Contact:required@tag", "Contact:omitempty@type", "Contact:email@type", "Backup:omitempty@type", "Backup:email@type"}))
+		})
+
+		It("replaces rules with the highest precedence source", func() {
+			v := newValidator(lakery.WithRuleMerge(lakery.MergeReplace))
+			Expect(explain(v)).To(Equal([]string{"Name:max=6@programmatic"}))
+			Expect(v.Validate(S{})).To(Succeed())
+		})
+
+		It("panics for unknown fields", func() {
+			v := lakery.NewValidator()
+			Expect(func() { v.RegisterStructRules(S{}, map[string]string{"Missing": "required"}) }).To(Panic())
+		})
+	})
+
+	Context("late registration", func() {
+		type Code string
+		type S struct {
+			Code Code   `lakery:"upper"`
+			Name string `lakery:"min=1"`
+		}
+		It("uses validators registered after the struct was validated", func() {
+			v := lakery.NewValidator()
+			Expect(v.Validate(S{Code: "abc", Name: "x"})).To(Succeed())
+			v.RegisterTag("upper", func(val *lakery.Value) error {
+				if val.String() != strings.ToUpper(val.String()) {
+					return errors.New("should be upper case")
+				}
+				return nil
+			})
+			Expect(v.Validate(S{Code: "abc", Name: "x"})).To(MatchError(ContainSubstring("upper case")))
+		})
+		It("uses type and struct rules registered after the struct was validated", func() {
+			v := lakery.NewValidator()
+			Expect(v.Validate(S{Code: "ab", Name: "x"})).To(Succeed())
+			v.RegisterTypeRules(Code(""), "min=3")
+			Expect(v.Validate(S{Code: "ab", Name: "x"})).To(MatchError(ContainSubstring("Code")))
+			v.RegisterTypeRules(Code(""), "")
+			v.RegisterStructRules(S{}, map[string]string{"Name": "min=2"})
+			Expect(v.Validate(S{Code: "ab", Name: "x"})).To(MatchError(ContainSubstring("Name")))
+		})
+	})
+
+	Context("concurrent use", func() {
+		type S struct {
+			Name     string `lakery:"min=1,custom,notin=@denylist"`
+			Password string `lakery:"notforbidden=denylist"`
+		}
+		It("allows registration while other goroutines validate", func() {
+			v := lakery.NewValidator()
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 50; j++ {
+						_ = v.Validate(S{Name: "john"})
+						_ = v.ListValidators()
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					for j := 0; j < 50; j++ {
+						v.RegisterTag("custom", func(*lakery.Value) error { return nil })
+						v.RegisterSet("denylist", "root")
+						v.RegisterTypeRules("", "max=64")
+						v.RegisterStructRules(S{}, map[string]string{"Password": "max=64"})
+					}
+				}()
+			}
+			wg.Wait()
+			Expect(v.Validate(S{Name: "root"})).To(MatchError(ContainSubstring("not allowed")))
+		})
+	})
+
+	Context("freeze", func() {
+		type S struct {
+			Name string `lakery:"required,min=2"`
+		}
+		It("validates as before", func() {
+			v := lakery.NewValidator()
+			v.Freeze()
+			Expect(v.Frozen()).To(BeTrue())
+			Expect(v.Validate(S{Name: "ok"})).To(Succeed())
+			Expect(v.Validate(S{Name: "x"})).To(HaveOccurred())
+		})
+		It("keeps registrations made before freezing", func() {
+			type Count struct{ n int }
+			type T struct {
+				Name  string `lakery:"notin=@reserved,shout"`
+				Count Count  `lakery:"min=1"`
+			}
+			v := lakery.NewValidator()
+			v.RegisterSet("reserved", "root")
+			v.RegisterTag("shout", func(val *lakery.Value) error {
+				if val.String() != strings.ToUpper(val.String()) {
+					return errors.New("should be upper case")
+				}
+				return nil
+			})
+			v.RegisterNumberType(Count{}, func(x any) (*big.Rat, bool) {
+				return new(big.Rat).SetInt64(int64(x.(Count).n)), true
+			})
+			v.Freeze()
+			var wg sync.WaitGroup
+			for range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					Expect(v.Validate(T{Name: "ADMIN", Count: Count{n: 1}})).To(Succeed())
+					Expect(v.Validate(T{Name: "root", Count: Count{n: 1}})).To(HaveOccurred())
+					Expect(v.Validate(T{Name: "admin", Count: Count{n: 1}})).To(MatchError(ContainSubstring("should be upper case")))
+					Expect(v.Validate(T{Name: "ADMIN"})).To(MatchError(ContainSubstring("should be >= 1")))
+				}()
+			}
+			wg.Wait()
+		})
+		It("panics on registration", func() {
+			v := lakery.NewValidator()
+			Expect(v.Frozen()).To(BeFalse())
+			v.Freeze()
+			expectFrozen := func(register func()) {
+				defer func() {
+					err, ok := recover().(error)
+					Expect(ok).To(BeTrue())
+					Expect(errors.Is(err, lakery.ErrFrozen)).To(BeTrue())
+				}()
+				register()
+			}
+			expectFrozen(func() { v.RegisterTag("custom", func(*lakery.Value) error { return nil }) })
+			expectFrozen(func() { v.RegisterSet("denylist", "root") })
+			expectFrozen(func() { v.RegisterSetValidator("denylist", func(string) bool { return false }) })
+			expectFrozen(func() { v.RegisterTypeRules("", "min=1") })
+			expectFrozen(func() { v.RegisterStructRules(S{}, map[string]string{"Name": "max=3"}) })
+			expectFrozen(func() { v.RegisterCustomTypeFunc(func(rv reflect.Value) reflect.Value { return rv }, S{}) })
+			expectFrozen(func() { v.RegisterAlias("password", "required") })
+			expectFrozen(func() { v.UnregisterTag("min") })
+			expectFrozen(func() { v.OnFieldStart(func(context.Context, lakery.FieldInfo) {}) })
+			expectFrozen(func() { v.OnFieldResult(func(context.Context, lakery.FieldResult) {}) })
+		})
+	})
+
+	Context("default validator", func() {
+		It("is shared by the package-level functions", func() {
+			type S struct {
+				Name string `lakery:"required,defaulttest"`
+			}
+			Expect(lakery.Default()).To(BeIdenticalTo(lakery.Default()))
+			Expect(lakery.Validate(S{})).To(MatchError(ContainSubstring("is required")))
+			Expect(lakery.RegisterTag("defaulttest", func(val *lakery.Value) error {
+				return errors.New("rejected")
+			})).To(BeFalse())
+			Expect(lakery.Validate(S{Name: "x"})).To(MatchError(ContainSubstring("rejected")))
+			Expect(lakery.Var("x", "defaulttest")).To(MatchError(ContainSubstring("rejected")))
+			Expect(lakery.Var("", "max=3")).To(Succeed())
+		})
+	})
+
+	Context("sampling", func() {
+		type Row struct {
+			Name string `lakery:"required"`
+		}
+		type Dataset struct {
+			Rows   []Row          `lakery:"each_sample=10,each={dive}"`
+			Codes  []string       `lakery:"each={max=3}"`
+			Labels map[string]int `lakery:"each_sample=2,values={max=5}"`
+		}
+		rows := func(n int) []Row {
+			out := make([]Row, n)
+			for i := range out {
+				out[i].Name = "row"
+			}
+			return out
+		}
+
+		It("validates a bounded sample of elements and reports the coverage", func() {
+			v := lakery.NewValidator()
+			checked := 0
+			v.RegisterTag("counted", func(*lakery.Value) error {
+				checked++
+				return nil
+			})
+			type S struct {
+				Values []int `lakery:"each_sample=10,each={counted}"`
+			}
+			coverage, err := v.ValidateWithCoverage(context.Background(), S{Values: make([]int, 1000)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(checked).To(Equal(10))
+			Expect(coverage).To(Equal([]lakery.SampleCoverage{
+				{Namespace: "Values", Rule: "each={counted}", Checked: 10, Total: 1000},
+			}))
+			Expect(coverage[0].Ratio()).To(Equal(0.01))
+		})
+
+		It("checks every element of small collections", func() {
+			v := lakery.NewValidator()
+			d := Dataset{Rows: rows(5), Labels: map[string]int{"a": 1, "b": 9}}
+			d.Rows[3].Name = ""
+			coverage, err := v.ValidateWithCoverage(context.Background(), d)
+			Expect(err).To(MatchError(ContainSubstring("Rows[3].Name")))
+			Expect(coverage).To(Equal([]lakery.SampleCoverage{
+				{Namespace: "Rows", Rule: "each={dive}", Checked: 5, Total: 5},
+			}))
+			Expect(v.Validate(Dataset{Rows: rows(5), Labels: map[string]int{"a": 1, "b": 9}})).To(MatchError(ContainSubstring("Labels[b]")))
+		})
+
+		It("samples every stratum of large collections", func() {
+			v := lakery.NewValidator()
+			d := Dataset{Rows: rows(100)}
+			for i := 30; i < 40; i++ {
+				d.Rows[i].Name = ""
+			}
+			Expect(v.Validate(d)).To(MatchError(ContainSubstring("Rows[3")))
+			Expect(v.Validate(Dataset{Rows: rows(100), Codes: []string{"toolong"}})).To(HaveOccurred())
+		})
+
+		It("rejects invalid limits", func() {
+			type S struct {
+				Values []int `lakery:"each_sample=0,each={min=1}"`
+			}
+			var invalid *lakery.InvalidRuleError
+			Expect(errors.As(lakery.NewValidator().Validate(S{}), &invalid)).To(BeTrue())
+		})
+	})
+
+	Context("parallel each", func() {
+		type Row struct {
+			SKU  string   `lakery:"required,sqlident"`
+			Tags []string `lakery:"each={min=2}"`
+		}
+		type Import struct {
+			Rows []Row `lakery:"each={dive}"`
+		}
+		rows := func(n int) []Row {
+			out := make([]Row, n)
+			for i := range out {
+				out[i] = Row{SKU: "sku_" + strconv.Itoa(i), Tags: []string{"ok"}}
+			}
+			return out
+		}
+
+		It("returns the failure of the first invalid element", func() {
+			v := lakery.NewValidator(lakery.WithParallelEach(4))
+			in := Import{Rows: rows(5000)}
+			Expect(v.Validate(in)).To(Succeed())
+			in.Rows[4321].SKU = ""
+			in.Rows[1234].Tags = []string{"x"}
+			in.Rows[3000].SKU = "not valid"
+			for range 10 {
+				var fe *lakery.FieldError
+				Expect(errors.As(v.Validate(in), &fe)).To(BeTrue())
+				Expect(fe.Namespace).To(Equal("Rows[1234].Tags[0]"))
+			}
+		})
+
+		It("merges all failures in element order in collect-all mode", func() {
+			sequential := lakery.NewValidator(lakery.WithCollectAll())
+			parallel := lakery.NewValidator(lakery.WithCollectAll(), lakery.WithParallelEach(8))
+			in := Import{Rows: rows(3000)}
+			for i := 0; i < len(in.Rows); i += 7 {
+				in.Rows[i].SKU = ""
+			}
+			in.Rows[2999].Tags = []string{"a", "b"}
+			want := sequential.Validate(in)
+			Expect(want).To(HaveOccurred())
+			Expect(parallel.Validate(in)).To(Equal(want))
+		})
+
+		It("stops at malformed rules", func() {
+			type S struct {
+				Values []int `lakery:"each={min=abc}"`
+			}
+			v := lakery.NewValidator(lakery.WithParallelEach(4))
+			var invalid *lakery.InvalidRuleError
+			Expect(errors.As(v.Validate(S{Values: make([]int, 1000)}), &invalid)).To(BeTrue())
+			Expect(invalid.Namespace).To(Equal("Values[0]"))
+			v = lakery.NewValidator(lakery.WithParallelEach(4), lakery.WithPanicOnInvalidRule())
+			Expect(func() { _ = v.Validate(S{Values: make([]int, 1000)}) }).To(PanicWith(BeAssignableToTypeOf(&lakery.InvalidRuleError{})))
+		})
+
+		It("shares the state of the call between workers", func() {
+			type counter struct{}
+			v := lakery.NewValidator(lakery.WithParallelEach(4))
+			var mu sync.Mutex
+			v.RegisterTag("counted", func(val *lakery.Value) error {
+				mu.Lock()
+				defer mu.Unlock()
+				n, _ := val.Load(counter{})
+				count, _ := n.(int)
+				val.Store(counter{}, count+1)
+				return nil
+			})
+			v.RegisterTag("total", func(val *lakery.Value) error {
+				n, _ := val.Load(counter{})
+				if n != 2000 {
+					return fmt.Errorf("counted %v elements", n)
+				}
+				return nil
+			})
+			type S struct {
+				Values []int `lakery:"each={counted},total"`
+			}
+			Expect(v.Validate(S{Values: make([]int, 2000)})).To(Succeed())
+		})
+
+		It("validates small collections sequentially", func() {
+			v := lakery.NewValidator(lakery.WithParallelEach(4))
+			var calls []int
+			v.RegisterTag("ordered", func(val *lakery.Value) error {
+				calls = append(calls, int(val.Int()))
+				return nil
+			})
+			type S struct {
+				Values []int `lakery:"each={ordered}"`
+			}
+			values := make([]int, 100)
+			for i := range values {
+				values[i] = i
+			}
+			Expect(v.Validate(S{Values: values})).To(Succeed())
+			Expect(calls).To(Equal(values))
+		})
+	})
+
+	Context("registration", func() {
+		It("reports overridden validators", func() {
+			v := lakery.NewValidator()
+			noop := func(*lakery.Value) error { return nil }
+			Expect(v.RegisterTag("credential", noop)).To(BeFalse())
+			Expect(v.RegisterTag("credential", noop)).To(BeTrue())
+			Expect(v.RegisterTag("min", noop)).To(BeTrue())
+			Expect(v.Var("", "min=3")).To(Succeed())
+		})
+		It("unregisters validators", func() {
+			type S struct {
+				Name string `lakery:"min=3"`
+			}
+			v := lakery.NewValidator()
+			Expect(v.Validate(S{Name: "x"})).To(HaveOccurred())
+			v.UnregisterTag("min")
+			Expect(v.ListValidators()).NotTo(ContainElement("min"))
+			Expect(v.Validate(S{Name: "x"})).To(Succeed())
+			v.UnregisterTag("missing")
+		})
+	})
+
+	Context("clone", func() {
+		type S struct {
+			Name string `lakery:"sku,short"`
+		}
+		sku := func(prefix string) lakery.TagValidationFunc {
+			return func(val *lakery.Value) error {
+				if !strings.HasPrefix(val.String(), prefix) {
+					return fmt.Errorf("should start with %s", prefix)
+				}
+				return nil
+			}
+		}
+
+		It("copies registrations and options", func() {
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			v.RegisterTag("sku", sku("A-"))
+			v.RegisterAlias("short", "max=4")
+			c := v.Clone()
+			err := c.Validate(S{Name: "B-12345"})
+			var errs lakery.ValidationErrors
+			Expect(errors.As(err, &errs)).To(BeTrue())
+			Expect(errs).To(HaveLen(2))
+		})
+
+		It("registers without affecting the original", func() {
+			v := lakery.NewValidator()
+			v.RegisterTag("sku", sku("A-"))
+			v.RegisterAlias("short", "max=4")
+			Expect(v.Validate(S{Name: "A-1"})).To(Succeed())
+			v.Freeze()
+
+			c := v.Clone()
+			Expect(c.Frozen()).To(BeFalse())
+			c.RegisterTag("sku", sku("B-"))
+			c.RegisterAlias("short", "max=8")
+			Expect(c.Validate(S{Name: "B-12345"})).To(Succeed())
+			Expect(c.Validate(S{Name: "A-1"})).To(HaveOccurred())
+			Expect(v.Validate(S{Name: "A-1"})).To(Succeed())
+			Expect(v.Validate(S{Name: "B-12345"})).To(HaveOccurred())
+		})
+	})
+
+	Context("context", func() {
+		type ctxKey struct{}
+		type S struct {
+			Name string `lakery:"unique"`
+			Nick string `lakery:"unique"`
+		}
+
+		It("passes the context to validators", func() {
+			v := lakery.NewValidator()
+			var seen any
+			v.RegisterTag("unique", func(val *lakery.Value) error {
+				seen = val.Context().Value(ctxKey{})
+				return nil
+			})
+			ctx := context.WithValue(context.Background(), ctxKey{}, "db")
+			Expect(v.ValidateCtx(ctx, S{})).To(Succeed())
+			Expect(seen).To(Equal("db"))
+		})
+
+		It("uses a background context for Validate", func() {
+			v := lakery.NewValidator()
+			v.RegisterTag("unique", func(val *lakery.Value) error {
+				return val.Context().Err()
+			})
+			Expect(v.Validate(S{})).To(Succeed())
+		})
+
+		It("stops once the context is cancelled", func() {
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			ctx, cancel := context.WithCancel(context.Background())
+			calls := 0
+			v.RegisterTag("unique", func(val *lakery.Value) error {
+				calls++
+				cancel()
+				return nil
+			})
+			Expect(v.ValidateCtx(ctx, S{})).To(MatchError(context.Canceled))
+			Expect(calls).To(Equal(1))
+		})
+	})
+
+	Context("var", func() {
+		It("validates single values against a rule string", func() {
+			v := lakery.NewValidator()
+			Expect(v.Var("john", "required,min=3,max=64")).To(Succeed())
+			Expect(v.Var(42, "min=1,max=100")).To(Succeed())
+			Expect(v.Var([]string{"a", "bb"}, "each={min=1}")).To(Succeed())
+			Expect(v.Var("jo", "required,min=3")).To(MatchError(ContainSubstring("at least 3")))
+		})
+		It("reports the value as a field error", func() {
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			err := v.Var("", "required,min=3")
+			var errs lakery.ValidationErrors
+			Expect(errors.As(err, &errs)).To(BeTrue())
+			Expect(errs).To(HaveLen(2))
+			Expect(errs[0].Field).To(Equal("value"))
+			Expect(errs[0].Tag).To(Equal("required"))
+		})
+		It("handles nil values", func() {
+			v := lakery.NewValidator()
+			Expect(v.Var(nil, "required")).To(MatchError(ContainSubstring("is required")))
+			Expect(v.Var(nil, "omitempty,min=3")).To(Succeed())
+		})
+		It("fails rules referencing other fields", func() {
+			v := lakery.NewValidator()
+			Expect(v.Var("x", "eqfield=Other")).To(MatchError(ContainSubstring("without a parent struct")))
+		})
+		It("rejects malformed rules and done contexts", func() {
+			v := lakery.NewValidator()
+			Expect(v.Var("x", "each={")).To(MatchError(ContainSubstring("unclosed braces")))
+			for _, rule := range []string{"each={", "min={", "email|"} {
+				var invalid *lakery.InvalidRuleError
+				Expect(errors.As(v.Var("x", rule), &invalid)).To(BeTrue(), rule)
+				var fe *lakery.FieldError
+				Expect(errors.As(invalid, &fe)).To(BeTrue())
+				Expect(fe.Category()).To(Equal(lakery.CategoryConfig))
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			Expect(v.VarCtx(ctx, "x", "required")).To(MatchError(context.Canceled))
+		})
+	})
+
+	Context("validate map", func() {
+		rules := map[string]string{
+			"name":         "required,min=3",
+			"age":          "omitempty,min=18",
+			"address.city": "required,max=10",
+			"tags":         "each={min=2}",
+		}
+		It("validates dynamic data", func() {
+			v := lakery.NewValidator()
+			data := map[string]any{
+				"name":    "john",
+				"age":     float64(30),
+				"address": map[string]any{"city": "Paris"},
+				"tags":    []any{"go", "hcl"},
+			}
+			errs, err := v.ValidateMap(data, rules)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errs).To(BeEmpty())
+		})
+		It("reports every failed key", func() {
+			v := lakery.NewValidator()
+			data := map[string]any{
+				"name":    "jo",
+				"address": map[string]any{"city": "Llanfairpwllgwyngyll"},
+				"tags":    []string{"go", "x"},
+			}
+			errs, err := v.ValidateMap(data, rules)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errs).To(HaveLen(3))
+			Expect(errs["name"]).To(MatchError(ContainSubstring("at least 3")))
+			Expect(errs["address.city"]).To(MatchError(ContainSubstring("at most 10")))
+			var fe *lakery.FieldError
+			Expect(errors.As(errs["tags"], &fe)).To(BeTrue())
+			Expect(fe.Namespace).To(Equal("tags[1]"))
+		})
+		It("validates missing keys as nil", func() {
+			v := lakery.NewValidator()
+			errs, err := v.ValidateMap(map[string]any{"address": "oops"}, rules)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errs).To(HaveKey("name"))
+			Expect(errs).To(HaveKey("address.city"))
+			Expect(errs).NotTo(HaveKey("age"))
+			Expect(errs).NotTo(HaveKey("tags"))
+		})
+		It("rejects malformed rules", func() {
+			v := lakery.NewValidator()
+			for _, rule := range []string{"each={", "min={", "email|"} {
+				errs, err := v.ValidateMap(nil, map[string]string{"tags": rule})
+				Expect(errs).To(BeNil())
+				var invalid *lakery.InvalidRuleError
+				Expect(errors.As(err, &invalid)).To(BeTrue(), rule)
+				Expect(invalid.Namespace).To(Equal("tags"))
+			}
+			_, err := v.ValidateMap(nil, map[string]string{"tags": "each={"})
+			Expect(err).To(MatchError(ContainSubstring("tags: unclosed braces")))
+		})
+		It("returns invalid rules separately from failed keys", func() {
+			v := lakery.NewValidator()
+			errs, err := v.ValidateMap(map[string]any{"name": "john"}, map[string]string{"name": "min=abc", "city": "required"})
+			Expect(errs).To(BeNil())
+			var invalid *lakery.InvalidRuleError
+			Expect(errors.As(err, &invalid)).To(BeTrue())
+			Expect(invalid.Error()).To(ContainSubstring("min=abc"))
+		})
+	})
+
+	Context("validate as", func() {
+		type Address struct {
+			City string `json:"city" lakery:"required,max=10"`
+		}
+		type Audit struct {
+			Note string `json:"note,omitempty" lakery:"max=5"`
+		}
+		type User struct {
+			Audit
+			Name    string   `json:"name" lakery:"required,min=3"`
+			Age     int      `json:"age,omitempty" lakery:"omitempty,min=18"`
+			Address Address  `json:"address" lakery:"dive"`
+			Tags    []string `json:"tags,omitempty" lakery:"each={min=2}"`
+			Secret  string   `json:"-"`
+		}
+		codes := func(err error) []string {
+			var errs lakery.ValidationErrors
+			Expect(errors.As(err, &errs)).To(BeTrue())
+			var out []string
+			for _, fe := range errs {
+				out = append(out, fe.Namespace+" "+fe.Code())
+			}
+			return out
+		}
+
+		It("converts and validates the values of a map", func() {
+			v := lakery.NewValidator()
+			u, err := lakery.ValidateAs[User](v, map[string]any{
+				"NAME":    "john",
+				"age":     float64(30),
+				"address": map[string]any{"city": "Paris"},
+				"tags":    []any{"go", "hcl"},
+				"note":    "hi",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(u.Name).To(Equal("john"))
+			Expect(u.Age).To(Equal(30))
+			Expect(u.Address.City).To(Equal("Paris"))
+			Expect(u.Note).To(Equal("hi"))
+		})
+
+		It("reports unknown, missing and mistyped keys with the rule failures", func() {
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			_, err := lakery.ValidateAs[User](v, map[string]any{
+				"name":   "jo",
+				"age":    "thirty",
+				"tags":   []any{"go", "x"},
+				"secret": "s",
+				"note":   "too long",
+			})
+			Expect(codes(err)).To(Equal([]string{
+				"age lakery.type",
+				"secret lakery.unknown_key",
+				"address lakery.missing_key",
+				"Note lakery.max",
+				"Name lakery.min",
+				"Tags[1] lakery.min",
+			}))
+			Expect(err).To(MatchError(ContainSubstring(`field "age" validation error: should be int`)))
+		})
+
+		It("skips the rules of missing fields", func() {
+			v := lakery.NewValidator()
+			_, err := lakery.ValidateAs[User](v, map[string]any{"name": "john"})
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Code()).To(Equal("lakery.missing_key"))
+			Expect(fe.Field).To(Equal("address"))
+			_, err = lakery.ValidateAs[int](v, nil)
+			Expect(err).To(MatchError(ContainSubstring("needs a struct type")))
+		})
+	})
+
+	Context("mutation", func() {
+		type S struct {
+			Name string `lakery:"required,min=3"`
+		}
+		typeName := reflect.TypeOf(S{}).String()
+		It("drops a rule", func() {
+			m := lakery.Mutation{Type: typeName, Field: "Name", Rule: "min=3"}
+			Expect(lakery.NewValidator(lakery.WithMutationForTesting(m)).Validate(S{Name: "x"})).To(Succeed())
+		})
+		It("replaces a rule", func() {
+			m := lakery.Mutation{Type: typeName, Field: "Name", Rule: "min=3", Replace: "min=1"}
+			v := lakery.NewValidator(lakery.WithMutationForTesting(m))
+			Expect(v.Validate(S{Name: "x"})).To(Succeed())
+			Expect(v.Validate(S{})).To(HaveOccurred())
+		})
+		It("mutates aliases as written in the tag", func() {
+			type T struct {
+				Password string `lakery:"password"`
 			}
+			m := lakery.Mutation{Type: reflect.TypeOf(T{}).String(), Field: "Password", Rule: "password"}
+			v := lakery.NewValidator(lakery.WithMutationForTesting(m))
+			v.RegisterAlias("password", "required,min=8")
+			Expect(v.Validate(T{})).To(Succeed())
+		})
+		It("leaves other fields alone", func() {
+			m := lakery.Mutation{Type: typeName, Field: "Other", Rule: "min=3"}
+			Expect(lakery.NewValidator(lakery.WithMutationForTesting(m)).Validate(S{Name: "x"})).To(HaveOccurred())
+		})
+		It("is not read from the environment", func() {
+			GinkgoT().Setenv("LAKERY_MUTATE", `{"type":"`+typeName+`","field":"Name","rule":"min=3"}`)
+			Expect(lakery.NewValidator().Validate(S{Name: "x"})).To(HaveOccurred())
+		})
+		It("reports malformed replacements", func() {
+			m := lakery.Mutation{Type: typeName, Field: "Name", Rule: "min=3", Replace: "min={"}
+			var invalid *lakery.InvalidRuleError
+			Expect(errors.As(lakery.NewValidator(lakery.WithMutationForTesting(m)).Validate(S{Name: "x"}), &invalid)).To(BeTrue())
+		})
+	})
+
+	Context("partial validation", func() {
+		type Address struct {
+			City string `lakery:"required"`
+			Zip  string `lakery:"min=5"`
+		}
+		type S struct {
+			Name      string    `lakery:"required"`
+			Email     string    `lakery:"required"`
+			Address   Address   `lakery:"dive"`
+			Addresses []Address `lakery:"each={dive}"`
+		}
+		It("validates only the given fields", func() {
 			v := lakery.NewValidator()
-			s := T{Name: "aa"}
-			err := v.Validate(s)
-			Expect(err).To(HaveOccurred())
+			Expect(v.ValidatePartial(S{Name: "john"}, "Name")).To(Succeed())
+			Expect(v.ValidatePartial(S{Name: "john"}, "Name", "Email")).To(MatchError(ContainSubstring("Email")))
+		})
+		It("selects nested fields with dots", func() {
+			v := lakery.NewValidator()
+			s := S{Address: Address{City: "Paris"}, Addresses: []Address{{Zip: "75001"}}}
+			Expect(v.ValidatePartial(s, "Address.City")).To(Succeed())
+			Expect(v.ValidatePartial(s, "Address")).To(MatchError(ContainSubstring("Address.Zip")))
+			Expect(v.ValidatePartial(s, "Addresses.Zip")).To(Succeed())
+			Expect(v.ValidatePartial(s, "Addresses.City")).To(MatchError(ContainSubstring("Addresses[0].City")))
+		})
+		It("validates all but the given fields", func() {
+			v := lakery.NewValidator()
+			s := S{Name: "john", Address: Address{City: "Paris"}}
+			Expect(v.ValidateExcept(s, "Email", "Address.Zip")).To(Succeed())
+			Expect(v.ValidateExcept(s, "Email")).To(MatchError(ContainSubstring("Address.Zip")))
+			Expect(v.ValidateExcept(S{}, "Name", "Email", "Address")).To(Succeed())
+		})
+	})
+
+	Context("tag name", func() {
+		type S struct {
+			Name  string `validate:"required,min=3" validate2:"max=5" lakery:"max=1"`
+			Email string `validate:"required"`
+		}
+		It("reads rules from the configured tag key", func() {
+			v := lakery.NewValidator(lakery.WithTagName("validate"))
+			Expect(v.Validate(S{Name: "john", Email: "j@x.io"})).To(Succeed())
+			Expect(v.Validate(S{Name: "jo", Email: "j@x.io"})).To(MatchError(ContainSubstring("Name")))
+			Expect(v.Validate(S{Name: "johnny", Email: "j@x.io"})).To(MatchError(ContainSubstring("at most 5")))
+			Expect(v.Validate(S{Name: "john"})).To(MatchError(ContainSubstring("Email")))
+		})
+		It("uses the tag key for manifests", func() {
+			v := lakery.NewValidator(lakery.WithTagName("validate"))
+			m, err := v.Manifest(S{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Types[0].Fields[0].Rules).To(Equal([]string{"required", "min=3", "max=5"}))
+		})
+	})
+
+	Context("tag continuation", func() {
+		type S struct {
+			Password string `lakery:"required,min=8" lakery2:"max=12" lakery3:"notin=password123"`
+			Skipped  string `lakery:"required" lakery3:"max=1"`
+		}
+
+		It("concatenates numbered continuation keys", func() {
+			v := lakery.NewValidator()
+			Expect(v.Validate(S{Password: "s3cret-pass", Skipped: "abc"})).To(Succeed())
+			Expect(v.Validate(S{Password: "s3cret-passphrase", Skipped: "abc"})).To(MatchError(ContainSubstring("at most 12")))
+			Expect(v.Validate(S{Password: "password123", Skipped: "abc"})).To(MatchError(ContainSubstring("not allowed")))
+		})
+
+		It("stops at the first missing continuation key", func() {
+			Expect(lakery.TagRules(reflect.StructTag(`lakery:"required" lakery3:"max=1"`), "lakery")).To(Equal("required"))
+		})
+	})
+
+	Context("tracing", func() {
+		type S struct {
+			Name  string   `lakery:"required,min=3,unknown"`
+			Creds []string `lakery:"each={max=2}"`
+		}
+
+		It("logs rules and outcomes", func() {
+			var buf bytes.Buffer
+			v := lakery.NewValidator(lakery.WithTrace(&buf))
+			Expect(v.Validate(S{Name: "john", Creds: []string{"abc"}})).To(HaveOccurred())
+			out := buf.String()
+			Expect(out).To(ContainSubstring(`Name: rules ["required" "min=3" "unknown"]`))
+			Expect(out).To(ContainSubstring("Name: unknown -> no validator registered, skipped"))
+			Expect(out).To(MatchRegexp(`Creds\[0\]: max=2 \(\S+\) -> fail: should have length at most 2`))
+		})
+
+		It("is silent by default", func() {
+			var buf bytes.Buffer
+			v := lakery.NewValidator(lakery.WithTrace(nil))
+			Expect(v.Validate(S{Name: "john"})).To(Succeed())
+			Expect(buf.Len()).To(BeZero())
+		})
+	})
+
+	Context("error categories", func() {
+		category := func(err error) lakery.ErrorCategory {
+			var fe *lakery.FieldError
+			ExpectWithOffset(1, errors.As(err, &fe)).To(BeTrue())
+			return fe.Category()
+		}
+		It("blames the value for failed rules", func() {
+			type S struct {
+				Name string `lakery:"min=3"`
+			}
+			Expect(category(lakery.NewValidator().Validate(S{Name: "a"}))).To(Equal(lakery.CategoryClient))
+		})
+		It("blames the declaration for malformed rules and params", func() {
+			type Param struct {
+				Name string `lakery:"min=abc"`
+			}
+			type Kind struct {
+				Active bool `lakery:"min=1"`
+			}
+			type Syntax struct {
+				Tags []string `lakery:"each={min=1"`
+			}
+			type Inner struct {
+				Tags []string `lakery:"each={|min=1}"`
+			}
+			v := lakery.NewValidator()
+			Expect(category(v.Validate(Param{}))).To(Equal(lakery.CategoryConfig))
+			Expect(category(v.Validate(Kind{}))).To(Equal(lakery.CategoryConfig))
+			Expect(category(v.Validate(Syntax{}))).To(Equal(lakery.CategoryConfig))
+			Expect(category(v.Validate(Inner{Tags: []string{"a"}}))).To(Equal(lakery.CategoryConfig))
+		})
+		It("reports panicking validators as internal errors", func() {
+			type S struct {
+				Name string `lakery:"broken"`
+			}
+			v := lakery.NewValidator()
+			v.RegisterTag("broken", func(*lakery.Value) error { panic("boom") })
+			err := v.Validate(S{})
+			Expect(err).To(MatchError(ContainSubstring("validator panicked: boom")))
+			Expect(category(err)).To(Equal(lakery.CategoryInternal))
+		})
+		It("reports rules missing their param as invalid rules", func() {
+			type S struct {
+				Name string `lakery:"prefix"`
+			}
+			v := lakery.NewValidator()
+			v.RegisterTag("prefix", func(val *lakery.Value) error {
+				if !strings.HasPrefix(val.String(), val.Param()) {
+					return fmt.Errorf("should start with %s", val.Param())
+				}
+				return nil
+			})
+			var invalid *lakery.InvalidRuleError
+			Expect(errors.As(v.Validate(S{}), &invalid)).To(BeTrue())
+			Expect(category(v.Validate(S{}))).To(Equal(lakery.CategoryConfig))
+			err := v.Var("abc", "min")
+			Expect(errors.As(err, &invalid)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("is not set")))
+			Expect(err).NotTo(MatchError(ContainSubstring("panicked")))
+		})
+		It("lets custom validators categorize their errors", func() {
+			type S struct {
+				Name string `lakery:"lookup"`
+			}
+			v := lakery.NewValidator()
+			dbErr := errors.New("connection refused")
+			v.RegisterTag("lookup", func(*lakery.Value) error { return lakery.InternalError(dbErr) })
+			err := v.Validate(S{})
+			Expect(category(err)).To(Equal(lakery.CategoryInternal))
+			Expect(errors.Is(err, dbErr)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("connection refused")))
+		})
+		It("reports the most severe category of all failures", func() {
+			type S struct {
+				Name string `lakery:"min=3"`
+				Age  int    `lakery:"lookup"`
+			}
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			v.RegisterTag("lookup", func(*lakery.Value) error { return lakery.InternalError(errors.New("timeout")) })
+			var errs lakery.ValidationErrors
+			Expect(errors.As(v.Validate(S{}), &errs)).To(BeTrue())
+			Expect(errs.Category()).To(Equal(lakery.CategoryInternal))
+			Expect(errs.Category().String()).To(Equal("internal-error"))
+		})
+	})
+
+	Context("error codes", func() {
+		codes := func(err error) []string {
+			var errs lakery.ValidationErrors
+			Expect(errors.As(err, &errs)).To(BeTrue())
+			var out []string
+			for _, fe := range errs {
+				out = append(out, fe.Code())
+			}
+			return out
+		}
+		It("are stable for builtins, alternatives and custom tags", func() {
+			type S struct {
+				Name  string   `lakery:"required"`
+				Tags  []string `lakery:"each={min=2}"`
+				ID    string   `lakery:"min=10|max=3"`
+				Token string   `lakery:"credential"`
+			}
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			v.RegisterTag("credential", func(*lakery.Value) error { return errors.New("leaked") })
+			err := v.Validate(S{Tags: []string{"a"}, ID: "abcde"})
+			Expect(codes(err)).To(Equal([]string{"lakery.required", "lakery.min", lakery.CodeOneOf, "credential"}))
+		})
+		It("are kept by overridden builtins and empty for unparsable rules", func() {
+			v := lakery.NewValidator()
+			v.RegisterTag("required", func(*lakery.Value) error { return errors.New("missing") })
+			var fe *lakery.FieldError
+			Expect(errors.As(v.Var("x", "required"), &fe)).To(BeTrue())
+			Expect(fe.Code()).To(Equal("lakery.required"))
+			type S struct {
+				Name string `lakery:"each={"`
+			}
+			Expect(errors.As(v.Validate(S{}), &fe)).To(BeTrue())
+			Expect(fe.Code()).To(BeEmpty())
+		})
+	})
+
+	Context("invalid rules", func() {
+		type S struct {
+			Name string `lakery:"required"`
+			Age  int    `lakery:"min=abc"`
+		}
+		It("returns InvalidRuleError instead of blaming the value", func() {
+			v := lakery.NewValidator()
+			err := v.Validate(S{Name: "john"})
+			var ire *lakery.InvalidRuleError
+			Expect(errors.As(err, &ire)).To(BeTrue())
+			Expect(ire.Tag).To(Equal("min"))
+			Expect(err).To(MatchError(`invalid rule min=abc of Age: min expects integer param: strconv.Atoi: parsing "abc": invalid syntax`))
+			var fe *lakery.FieldError
+			Expect(errors.As(err, &fe)).To(BeTrue())
+			Expect(fe.Category()).To(Equal(lakery.CategoryConfig))
+		})
+		It("reports rule syntax errors", func() {
+			type T struct {
+				Tags []string `lakery:"each={min=1"`
+			}
+			err := lakery.NewValidator().Validate(T{})
+			Expect(err).To(MatchError(`invalid rules of Tags: unclosed braces in "each={min=1"`))
+		})
+		It("stops validation in collect-all mode", func() {
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			err := v.Validate(S{})
+			var ire *lakery.InvalidRuleError
+			Expect(errors.As(err, &ire)).To(BeTrue())
+			var errs lakery.ValidationErrors
+			Expect(errors.As(err, &errs)).To(BeFalse())
+		})
+		It("panics in development mode", func() {
+			v := lakery.NewValidator(lakery.WithPanicOnInvalidRule())
+			Expect(func() { _ = v.Validate(S{Name: "john"}) }).To(PanicWith(BeAssignableToTypeOf(&lakery.InvalidRuleError{})))
+		})
+	})
+
+	Context("strict tags", func() {
+		type S struct {
+			Name string `lakery:"requird,min=3"`
+		}
+		It("skips unknown tags by default", func() {
+			Expect(lakery.NewValidator().Validate(S{Name: "john"})).To(Succeed())
+		})
+		It("returns InvalidRuleError for unknown tags", func() {
+			v := lakery.NewValidator(lakery.WithStrictTags())
+			err := v.Validate(S{Name: "john"})
+			var ire *lakery.InvalidRuleError
+			Expect(errors.As(err, &ire)).To(BeTrue())
+			Expect(ire.Tag).To(Equal("requird"))
+			Expect(err).To(MatchError(`invalid rule requird of Name: unknown tag "requird"`))
+		})
+		It("checks alternatives and nested rules", func() {
+			v := lakery.NewValidator(lakery.WithStrictTags())
+			type T struct {
+				ID   string   `lakery:"sqlident|goidnet"`
+				Tags []string `lakery:"each={min=1,maxx=5}"`
+			}
+			Expect(v.Validate(T{ID: "user-id"})).To(MatchError(ContainSubstring(`unknown tag "goidnet"`)))
+			Expect(v.Validate(T{ID: "user_id", Tags: []string{"go"}})).To(MatchError(ContainSubstring(`unknown tag "maxx"`)))
+			Expect(v.Var("john", "required,nosuchtag")).To(MatchError(ContainSubstring(`unknown tag "nosuchtag"`)))
+		})
+		It("treats unregistered tags as unknown", func() {
+			v := lakery.NewValidator(lakery.WithStrictTags())
+			v.UnregisterTag("min")
+			type T struct {
+				Name string `lakery:"min=3"`
+			}
+			Expect(v.Validate(T{Name: "john"})).To(MatchError(ContainSubstring(`unknown tag "min"`)))
+		})
+		It("is copied by Clone", func() {
+			v := lakery.NewValidator(lakery.WithStrictTags())
+			Expect(v.Clone().Validate(S{Name: "john"})).To(MatchError(ContainSubstring(`unknown tag "requird"`)))
 		})
 	})
 
@@ -138,5 +2126,496 @@ var _ = Describe("Validator", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("wrapped"))
 		})
+		It("formats errors created outside of Validate", func() {
+			type S struct {
+				Name string `lakery:"min=3"`
+			}
+			sf, _ := reflect.TypeOf(S{}).FieldByName("Name")
+			fe := lakery.NewFieldError(sf, "Name", reflect.ValueOf("aa"), "min", "3", errors.New("should have length at least 3"))
+			Expect(fe.Error()).To(Equal(lakery.NewValidator().Validate(S{Name: "aa"}).Error()))
+		})
+	})
+
+	Context("rules for", func() {
+		type Item struct {
+			SKU string `lakery:"required"`
+			Qty int    `lakery:"min=1"`
+		}
+		type Audit struct {
+			By string `lakery:"required"`
+		}
+		type Order struct {
+			Audit
+			ID    string            `lakery:"required,sqlident|goident"`
+			Items []Item            `lakery:"required,each={dive}"`
+			Meta  map[string]string `lakery:"keys={min=1},values={max=10}"`
+			Ship  *Item             `lakery:"dive"`
+			Note  string
+			Opts  struct {
+				Retries int `lakery:"max=5"`
+			}
+		}
+
+		It("returns the rule tree of a struct", func() {
+			v := lakery.NewValidator()
+			fields, err := v.RulesFor(&Order{})
+			Expect(err).NotTo(HaveOccurred())
+			var paths []string
+			var walk func(fields []lakery.FieldRules)
+			var walkRules func(rules []lakery.RuleNode)
+			walk = func(fields []lakery.FieldRules) {
+				for _, f := range fields {
+					paths = append(paths, f.Path)
+					walkRules(f.Rules)
+					walk(f.Fields)
+				}
+			}
+			walkRules = func(rules []lakery.RuleNode) {
+				for _, r := range rules {
+					walkRules(r.Inner)
+					walk(r.Fields)
+				}
+			}
+			walk(fields)
+			Expect(paths).To(Equal([]string{"Audit", "By", "ID", "Items", "Items[].SKU", "Items[].Qty", "Meta", "Ship", "Ship.SKU", "Ship.Qty", "Opts", "Opts.Retries"}))
+
+			id := fields[1]
+			Expect(id.Type).To(Equal("string"))
+			Expect(id.Rules).To(HaveLen(2))
+			Expect(id.Rules[0].Key).To(Equal("required"))
+			Expect(id.Rules[0].Source).To(Equal(lakery.RuleSourceTag))
+			Expect(id.Rules[1].Alternatives).To(HaveLen(2))
+
+			items := fields[2]
+			Expect(items.Rules[1].Key).To(Equal("each"))
+			Expect(items.Rules[1].Param).To(Equal("{dive}"))
+			dive := items.Rules[1].Inner[0]
+			Expect(dive.Key).To(Equal("dive"))
+			Expect(dive.Fields[1].Rules[0].String()).To(Equal("min=1"))
+
+			meta := fields[3]
+			Expect(meta.Rules[0].Inner[0].String()).To(Equal("min=1"))
+			Expect(meta.Rules[1].Inner[0].String()).To(Equal("max=10"))
+		})
+
+		It("lists the effective rules", func() {
+			type Account struct {
+				Name string `lakery:"handle,prod:min=12;dev:min=4"`
+			}
+			v := lakery.NewValidator(lakery.WithProfile("dev"))
+			v.RegisterAlias("handle", "required,sqlident")
+			v.RegisterTypeRules("", "max=64")
+			fields, err := v.RulesFor(Account{})
+			Expect(err).NotTo(HaveOccurred())
+			var rules []string
+			for _, r := range fields[0].Rules {
+				rules = append(rules, fmt.Sprintf("%s:%s", r.Source, r))
+			}
+			Expect(rules).To(Equal([]string{"type:max=64", "tag:required", "tag:sqlident", "tag:min=4"}))
+		})
+
+		It("expands recursive types once", func() {
+			type Node struct {
+				Name     string  `lakery:"required"`
+				Children []*Node `lakery:"each={dive}"`
+			}
+			fields, err := lakery.NewValidator().RulesFor(Node{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fields).To(HaveLen(2))
+			Expect(fields[1].Rules[0].Inner[0].Fields).To(BeNil())
+		})
+
+		It("reports malformed rules and non-structs", func() {
+			type Bad struct {
+				Tags []string `lakery:"each={min=1"`
+			}
+			_, err := lakery.NewValidator().RulesFor(Bad{})
+			Expect(err).To(MatchError(ContainSubstring("Bad.Tags: unclosed braces")))
+			_, err = lakery.NewValidator().RulesFor("x")
+			Expect(err).To(MatchError("lakery: RulesFor needs a struct, got string"))
+		})
+	})
+
+	Context("compile", func() {
+		type Item struct {
+			SKU string `lakery:"min=2"`
+		}
+		type Order struct {
+			ID    string   `lakery:"required"`
+			Items []Item   `lakery:"each={dive}"`
+			Tags  []string `lakery:"each={max=3}"`
+		}
+		It("validates like Validate", func() {
+			v := lakery.NewValidator()
+			orders, err := lakery.Compile[Order](v)
+			Expect(err).NotTo(HaveOccurred())
+			valid := Order{ID: "1", Items: []Item{{SKU: "ab"}}, Tags: []string{"new"}}
+			Expect(orders.Validate(valid)).To(Succeed())
+			invalid := Order{Items: []Item{{SKU: "a"}}, Tags: []string{"urgent"}}
+			Expect(orders.Validate(invalid)).To(MatchError(v.Validate(invalid).Error()))
+		})
+		It("reports all configuration errors at once", func() {
+			type Bad struct {
+				Name  string   `lakery:"nosuchtag"`
+				Tags  string   `lakery:"each={min=1}"`
+				Count int      `lakery:"dive"`
+				Attrs []string `lakery:"keys={min=1}"`
+				Codes []string `lakery:"each={min=1"`
+				Items []Item   `lakery:"each={bogus}"`
+			}
+			_, err := lakery.Compile[Bad](lakery.NewValidator())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`Name: unknown tag "nosuchtag"`))
+			Expect(err.Error()).To(ContainSubstring("Tags: each can be used only with slice or array"))
+			Expect(err.Error()).To(ContainSubstring("Count: dive can be used only with struct"))
+			Expect(err.Error()).To(ContainSubstring("Attrs: keys can be used only with map"))
+			Expect(err.Error()).To(ContainSubstring("Codes: "))
+			Expect(err.Error()).To(ContainSubstring(`Items[]: unknown tag "bogus"`))
+		})
+		It("checks the struct types reachable from T", func() {
+			type Inner struct {
+				Name string `lakery:"nosuchtag"`
+			}
+			type Outer struct {
+				Inner *Inner `lakery:"dive"`
+			}
+			_, err := lakery.Compile[Outer](lakery.NewValidator())
+			Expect(err).To(MatchError(ContainSubstring(`Inner.Name: unknown tag "nosuchtag"`)))
+		})
+		It("handles recursive types", func() {
+			type Node struct {
+				Name     string  `lakery:"required"`
+				Children []*Node `lakery:"each={dive}"`
+			}
+			nodes, err := lakery.Compile[Node](lakery.NewValidator())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(nodes.Validate(Node{Name: "root", Children: []*Node{{Name: "leaf"}}})).To(Succeed())
+			Expect(nodes.Validate(Node{Name: "root", Children: []*Node{{}}})).To(HaveOccurred())
+		})
+		It("keeps the validators registered at compile time", func() {
+			type S struct {
+				Code string `lakery:"code"`
+			}
+			v := lakery.NewValidator()
+			v.RegisterTag("code", func(val *lakery.Value) error { return nil })
+			codes, err := lakery.Compile[S](v)
+			Expect(err).NotTo(HaveOccurred())
+			v.RegisterTag("code", func(val *lakery.Value) error { return errors.New("rejected") })
+			Expect(codes.Validate(S{Code: "x"})).To(Succeed())
+			Expect(v.Validate(S{Code: "x"})).To(HaveOccurred())
+		})
+		It("needs a struct type", func() {
+			_, err := lakery.Compile[string](lakery.NewValidator())
+			Expect(err).To(MatchError(ContainSubstring("needs a struct type")))
+		})
+	})
+
+	Context("typed validate", func() {
+		type S struct {
+			Name string `lakery:"required"`
+		}
+		It("validates structs and pointers to structs", func() {
+			v := lakery.NewValidator()
+			Expect(lakery.ValidateT(v, S{Name: "john"})).To(Succeed())
+			Expect(lakery.ValidateT(v, &S{})).To(MatchError(ContainSubstring("is required")))
+		})
+		It("rejects other types by their static type", func() {
+			v := lakery.NewValidator()
+			Expect(lakery.ValidateT(v, "john")).To(MatchError("lakery: ValidateT needs a struct type, got string"))
+			var names []string
+			Expect(lakery.ValidateT(v, names)).To(MatchError("lakery: ValidateT needs a struct type, got []string"))
+			var anyS any = S{Name: "john"}
+			Expect(lakery.ValidateT(v, anyS)).To(MatchError("lakery: ValidateT needs a struct type, got interface {}"))
+		})
+	})
+
+	Context("validated json", func() {
+		type CreateUser struct {
+			Name  string `json:"name" lakery:"trim,required"`
+			Age   int    `json:"age" lakery:"max=150"`
+			Email string `json:"email" lakery:"default=unknown"`
+		}
+		It("decodes and validates", func() {
+			var req lakery.Validated[CreateUser]
+			Expect(json.Unmarshal([]byte(`{"name":" john ","age":30}`), &req)).To(Succeed())
+			Expect(req.Value).To(Equal(CreateUser{Name: "john", Age: 30, Email: "unknown"}))
+			err := json.Unmarshal([]byte(`{"name":"  ","age":30}`), &req)
+			Expect(lakery.FieldErrors(err).ByField()).To(HaveKey("Name"))
+		})
+		It("reports decoding and validation errors together", func() {
+			var req lakery.Validated[CreateUser]
+			err := json.Unmarshal([]byte(`{"age":"old"}`), &req)
+			var typeErr *json.UnmarshalTypeError
+			Expect(errors.As(err, &typeErr)).To(BeTrue())
+			Expect(lakery.FieldErrors(err).ByField()).To(HaveKey("Name"))
+		})
+		It("returns malformed json as is", func() {
+			var req lakery.Validated[CreateUser]
+			err := json.Unmarshal([]byte(`{"name":`), &req)
+			var syntaxErr *json.SyntaxError
+			Expect(errors.As(err, &syntaxErr)).To(BeTrue())
+			Expect(lakery.FieldErrors(err)).To(BeNil())
+		})
+		It("works as a field and encodes like the value", func() {
+			var body struct {
+				User lakery.Validated[CreateUser] `json:"user"`
+			}
+			Expect(json.Unmarshal([]byte(`{"user":{"age":200}}`), &body)).To(MatchError(ContainSubstring("is required")))
+			data, err := json.Marshal(lakery.Validated[CreateUser]{Value: CreateUser{Name: "john"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal(`{"name":"john","age":0,"email":""}`))
+		})
+	})
+
+	Context("allowed validators", func() {
+		type Credentials struct {
+			_        struct{} `lakery:"allow=required min max"`
+			User     string   `lakery:"required,max=64"`
+			Password string   `lakery:"required,min=12,notin=root"`
+			Scopes   []string `lakery:"each={min=1|sqlident}"`
+			Limits   struct {
+				Rate int `lakery:"min=1,inrange=1:10"`
+			}
+		}
+		It("reports validators not listed by allow", func() {
+			err := lakery.NewValidator().VerifyStruct(&Credentials{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Password: notin is not allowed by allow=required min max"))
+			Expect(err.Error()).To(ContainSubstring("Scopes[]: sqlident is not allowed"))
+			Expect(err.Error()).To(ContainSubstring("Limits.Rate: inrange is not allowed"))
+			Expect(err.Error()).NotTo(ContainSubstring("User"))
+			_, err = lakery.Compile[Credentials](lakery.NewValidator())
+			Expect(err).To(MatchError(ContainSubstring("Password: notin is not allowed")))
+		})
+		It("checks dive targets against their own allow rules", func() {
+			type Account struct {
+				Share float64     `lakery:"required,percent"`
+				Owner Credentials `lakery:"dive"`
+			}
+			err := lakery.NewValidator().VerifyStruct(Account{})
+			Expect(err).To(MatchError(ContainSubstring("Owner.Password: notin is not allowed")))
+			Expect(err.Error()).NotTo(ContainSubstring("Share"))
+		})
+		It("doesn't change validation", func() {
+			v := lakery.NewValidator()
+			Expect(v.Validate(Credentials{User: "john", Password: "correct-horse", Limits: struct {
+				Rate int `lakery:"min=1,inrange=1:10"`
+			}{Rate: 2}})).To(Succeed())
+			Expect(v.Validate(Credentials{User: "john", Password: "root"})).To(HaveOccurred())
+		})
+		It("reports unknown struct rules", func() {
+			type S struct {
+				_ struct{} `lakery:"min=1"`
+			}
+			Expect(lakery.NewValidator().VerifyStruct(S{})).To(MatchError(ContainSubstring("_: min=1 is not a struct rule")))
+		})
+		It("verifies structs without allow rules", func() {
+			type S struct {
+				Share float64 `lakery:"required,percent"`
+			}
+			Expect(lakery.NewValidator().VerifyStruct(S{})).To(Succeed())
+			Expect(lakery.NewValidator().VerifyStruct("s")).To(MatchError(ContainSubstring("needs a struct")))
+		})
+	})
+
+	Context("tag parser", func() {
+		// jsonParser reads rules encoded as a JSON object of validators and params
+		jsonParser := lakery.TagParserFunc(func(tag string) ([]lakery.Rule, error) {
+			var m map[string]string
+			if err := json.Unmarshal([]byte(tag), &m); err != nil {
+				return nil, err
+			}
+			keys := make([]string, 0, len(m))
+			for key := range m {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			rules := make([]lakery.Rule, len(keys))
+			for i, key := range keys {
+				rules[i] = lakery.Rule{Key: key, Param: m[key]}
+			}
+			return rules, nil
+		})
+		type S struct {
+			Name string   `rules:"{\"min\": \"3\", \"required\": \"\"}"`
+			Tags []string `rules:"{\"each\": \"{max=2}\"}"`
+		}
+
+		It("validates tags in another grammar", func() {
+			v := lakery.NewValidator(lakery.WithTagName("rules"), lakery.WithTagParser(jsonParser))
+			Expect(v.Validate(S{Name: "john", Tags: []string{"a"}})).To(Succeed())
+			Expect(v.Validate(S{Name: "jo"})).To(MatchError(ContainSubstring("should have length at least 3")))
+			Expect(v.Validate(S{Name: "john", Tags: []string{"abc"}})).To(MatchError(ContainSubstring("Tags[0]")))
+		})
+		It("reports parse errors as invalid rules", func() {
+			type Bad struct {
+				Name string `rules:"min=3"`
+			}
+			v := lakery.NewValidator(lakery.WithTagName("rules"), lakery.WithTagParser(jsonParser))
+			var ire *lakery.InvalidRuleError
+			Expect(errors.As(v.Validate(Bad{}), &ire)).To(BeTrue())
+		})
+		It("checks the parsed rules", func() {
+			parser := lakery.TagParserFunc(func(string) ([]lakery.Rule, error) {
+				return []lakery.Rule{{Alternatives: []lakery.Rule{{Key: "min", Param: "1"}, {Key: "omitempty"}}}}, nil
+			})
+			type S struct {
+				Name string `lakery:"anything"`
+			}
+			err := lakery.NewValidator(lakery.WithTagParser(parser)).Validate(S{})
+			Expect(err).To(MatchError(ContainSubstring("omitempty cannot be used in alternatives")))
+		})
+		It("keeps the lakery grammar for rules declared in code", func() {
+			v := lakery.NewValidator(lakery.WithTagName("rules"), lakery.WithTagParser(jsonParser))
+			Expect(v.Var("jo", "min=3")).To(HaveOccurred())
+		})
+		It("lists parsed rules in manifests", func() {
+			v := lakery.NewValidator(lakery.WithTagName("rules"), lakery.WithTagParser(jsonParser))
+			m, err := v.Manifest(S{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Types[0].Fields[0].Rules).To(Equal([]string{"min=3", "required"}))
+		})
+		It("exposes the default parser", func() {
+			rules, err := lakery.DefaultTagParser().ParseRules("required,min=3|max=1")
+			Expect(err).NotTo(HaveOccurred())
+			expected, _ := lakery.ParseRules("required,min=3|max=1")
+			Expect(rules).To(Equal(expected))
+		})
+	})
+
+	Context("plugins", func() {
+		It("registers plugins and lists their specs", func() {
+			v := lakery.NewValidator()
+			v.Install(companyIDs{})
+			type S struct {
+				Customer string `lakery:"companyid"`
+			}
+			Expect(v.Validate(S{Customer: "ACME-0042"})).To(Succeed())
+			Expect(v.Validate(S{Customer: "acme"})).To(MatchError(ContainSubstring("should be a company ID")))
+
+			specs := v.Specs()
+			Expect(specs).To(HaveLen(len(lakery.BuiltinSpecs()) + 1))
+			i := slices.IndexFunc(specs, func(s lakery.BuiltinSpec) bool { return s.Name == "companyid" })
+			Expect(i).NotTo(Equal(-1))
+			Expect(specs[i].Plugin).To(Equal("example.com/companyids"))
+			Expect(specs[i].Code).To(Equal("companyid"))
+			Expect(lakery.NewValidator().Specs()).To(Equal(lakery.BuiltinSpecs()))
+		})
+		It("keeps plugins in clones", func() {
+			v := lakery.NewValidator()
+			v.Install(companyIDs{})
+			Expect(v.Clone().Specs()).To(Equal(v.Specs()))
+		})
+		It("panics on plugins installed twice", func() {
+			v := lakery.NewValidator()
+			v.Install(companyIDs{})
+			Expect(func() { v.Install(companyIDs{}) }).To(PanicWith(ContainSubstring("already installed")))
+		})
+		It("writes and reads specs", func() {
+			var buf bytes.Buffer
+			Expect(lakery.WriteSpecs(&buf, companyIDs{}.Specs())).To(Succeed())
+			specs, err := lakery.ReadSpecs(&buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(specs).To(Equal(companyIDs{}.Specs()))
+			_, err = lakery.ReadSpecs(strings.NewReader("{"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("field hooks", func() {
+		type Address struct {
+			City string `lakery:"required"`
+		}
+		type S struct {
+			Name    string `lakery:"required,min=3"`
+			Note    string
+			Address Address  `lakery:"dive"`
+			Tags    []string `lakery:"each={min=2}"`
+		}
+
+		It("reports every field with rules", func() {
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			var events []string
+			v.OnFieldStart(func(_ context.Context, f lakery.FieldInfo) {
+				events = append(events, fmt.Sprintf("start %s %v", f.Namespace, f.Rules))
+			})
+			v.OnFieldResult(func(_ context.Context, r lakery.FieldResult) {
+				events = append(events, fmt.Sprintf("result %s %v", r.Namespace, r.Err != nil))
+				Expect(r.Duration).To(BeNumerically(">=", 0))
+			})
+			Expect(v.Validate(S{Name: "jo", Tags: []string{"a", "b"}})).NotTo(Succeed())
+			Expect(events).To(Equal([]string{
+				"start Name [required min=3]",
+				"result Name true",
+				"start Address [dive]",
+				"start Address.City [required]",
+				"result Address.City true",
+				"result Address true",
+				"start Tags [each={min=2}]",
+				"result Tags true",
+			}))
+		})
+
+		It("passes the failures of the field", func() {
+			v := lakery.NewValidator(lakery.WithCollectAll())
+			results := make(map[string]error)
+			v.OnFieldResult(func(_ context.Context, r lakery.FieldResult) {
+				results[r.Namespace] = r.Err
+			})
+			_ = v.Validate(S{Name: "john", Address: Address{City: "Oslo"}, Tags: []string{"a", "b"}})
+			Expect(results["Name"]).To(BeNil())
+			var errs lakery.ValidationErrors
+			Expect(errors.As(results["Tags"], &errs)).To(BeTrue())
+			Expect(errs).To(HaveLen(2))
+
+			type Bad struct {
+				Name string `lakery:"notin=@missing"`
+			}
+			_ = v.Validate(Bad{})
+			var ire *lakery.InvalidRuleError
+			Expect(errors.As(results["Name"], &ire)).To(BeTrue())
+		})
+
+		It("passes the context of the call", func() {
+			type key struct{}
+			v := lakery.NewValidator()
+			var got any
+			v.OnFieldStart(func(ctx context.Context, _ lakery.FieldInfo) { got = ctx.Value(key{}) })
+			Expect(v.ValidateCtx(context.WithValue(context.Background(), key{}, "req-1"), S{Name: "john", Address: Address{City: "Oslo"}})).To(Succeed())
+			Expect(got).To(Equal("req-1"))
+		})
+
+		It("are copied by Clone", func() {
+			v := lakery.NewValidator()
+			calls := 0
+			v.OnFieldStart(func(context.Context, lakery.FieldInfo) { calls++ })
+			c := v.Clone()
+			c.OnFieldStart(func(context.Context, lakery.FieldInfo) { calls += 10 })
+			Expect(v.Validate(Address{City: "Oslo"})).To(Succeed())
+			Expect(calls).To(Equal(1))
+			Expect(c.Validate(Address{City: "Oslo"})).To(Succeed())
+			Expect(calls).To(Equal(12))
+		})
 	})
+
 })
+
+// companyIDs is a plugin validating company IDs like ACME-0042.
+type companyIDs struct{}
+
+func (companyIDs) Name() string { return "example.com/companyids" }
+
+func (companyIDs) Register(v *lakery.Validator) {
+	v.RegisterTag("companyid", func(val *lakery.Value) error {
+		prefix, number, ok := strings.Cut(val.String(), "-")
+		if !ok || strings.ToUpper(prefix) != prefix || len(number) != 4 {
+			return errors.New("should be a company ID")
+		}
+		return nil
+	})
+}
+
+func (companyIDs) Specs() []lakery.BuiltinSpec {
+	return []lakery.BuiltinSpec{{Name: "companyid", Since: "1.0.0", Kinds: []lakery.KindBehavior{
+		{Kinds: []string{"string"}, Behavior: "a company ID like ACME-0042"},
+	}}}
+}
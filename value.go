@@ -1,19 +1,72 @@
 package lakery
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 )
 
 type Value struct {
 	val   reflect.Value
 	name  string
 	param string
+	// struct containing the field, used by rules referencing other fields
+	parent    reflect.Value
+	validator *Validator
+	ctx       context.Context
+	// the Validate call the value belongs to, holding the shared state
+	call *validation
 }
 
-// todo: this is very interesting question - how we can obtain the underlaying value
+// Deref returns the value behind pointers and interfaces, keeping the param and context,
+// so validators see the same value whether a field is declared as T or *T.
+// A nil pointer or interface yields a value for which IsNil reports true.
+func (v *Value) Deref() *Value {
+	d := *v
+	rv, ok := derefValue(v.val)
+	if !ok {
+		rv = reflect.Value{}
+	}
+	d.val = rv
+	return &d
+}
+
+// IsNil reports whether the value is a nil pointer, interface, slice, map, channel or func.
+func (v *Value) IsNil() bool {
+	switch v.val.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Chan, reflect.Func:
+		return v.val.IsNil()
+	default:
+		return false
+	}
+}
+
+// String returns the underlying string, dereferencing pointers. Nil values give an empty string,
+// other types are formatted like reflect.Value.String.
 func (v *Value) String() string {
-	return v.val.String()
+	d := v.Deref()
+	if !d.val.IsValid() {
+		return ""
+	}
+	return d.val.String()
+}
+
+// Int returns the underlying integer, dereferencing pointers. Nil values give 0.
+// It panics if the value is not an integer, like reflect.Value.Int.
+func (v *Value) Int() int64 {
+	d := v.Deref()
+	switch d.val.Kind() {
+	case reflect.Invalid:
+		return 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(d.val.Uint())
+	default:
+		return d.val.Int()
+	}
 }
 
 func (v *Value) Interface() any {
@@ -23,9 +76,236 @@ func (v *Value) Interface() any {
 	panic(v.val.Type().String() + " is not an interface type")
 }
 
+// Param returns the param of the rule, unquoted when it was written in single quotes (see UnquoteParam).
+// It panics when the rule has no param; the validator then fails with an InvalidRuleError since
+// the rule is declared without its param, e.g. min instead of min=3.
 func (v *Value) Param() string {
 	if v.param != "" {
-		return v.param
+		return UnquoteParam(v.param)
+	}
+	panic(missingParamError{name: v.name})
+}
+
+// missingParamError is the panic of Param, which callValidator reports as a configuration error.
+type missingParamError struct {
+	name string
+}
+
+func (e missingParamError) Error() string {
+	return fmt.Sprintf("requested param value for %q is not set", e.name)
+}
+
+// Params splits a list param into its values. Values are separated by spaces (oneof=red green blue)
+// or, when wrapped into curly braces, by commas only (oneof={red,in progress,done}). Each value may be
+// quoted to keep spaces or commas in it (oneof='in progress' done), quotes are removed like Param does.
+// Like Param, it panics when the rule has no param.
+func (v *Value) Params() []string {
+	if v.param == "" {
+		v.Param()
+	}
+	param := strings.TrimSpace(v.param)
+	if strings.HasPrefix(param, "{") && strings.HasSuffix(param, "}") {
+		return splitParams(param[1:len(param)-1], func(r rune) bool { return r == ',' })
+	}
+	return splitParams(param, func(r rune) bool { return r == ' ' || r == '\t' })
+}
+
+// splitParams splits a list param at separators outside of single quotes, trimming and unquoting
+// each value. Empty values are dropped unless written as a quoted empty string.
+func splitParams(param string, isSep func(rune) bool) []string {
+	var values []string
+	var value strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(value.String()); s != "" {
+			values = append(values, UnquoteParam(s))
+		}
+		value.Reset()
+	}
+	quoted := false
+	for _, r := range param {
+		if r == '\'' {
+			quoted = !quoted
+		}
+		if !quoted && isSep(r) {
+			flush()
+			continue
+		}
+		value.WriteRune(r)
+	}
+	flush()
+	return values
+}
+
+// UnquoteParam returns a param as validators see it. Params containing commas, pipes, semicolons or
+// unbalanced braces, which would end the rule, are written in single quotes with quotes inside doubled:
+//
+//	Code string `lakery:"regexp='^[A-Z]{2,3}(-[0-9]+)?$'"`
+//	Note string `lakery:"notin='it''s'"`
+//
+// Rules keep the quotes, e.g. in Rule.Param and FieldError.Param, so they print as written;
+// Value.Param removes them. Params which are not quoted are returned as is.
+func UnquoteParam(param string) string {
+	if !isQuoted(param) {
+		return param
+	}
+	return strings.ReplaceAll(param[1:len(param)-1], "''", "'")
+}
+
+// isQuoted reports whether a param is written in single quotes.
+func isQuoted(param string) bool {
+	return len(param) >= 2 && param[0] == '\'' && param[len(param)-1] == '\''
+}
+
+// ParamPair splits a param of two colon-separated parts like between=1:10 or money=2:signed.
+// ok is false when the param has no colon, second is then empty. Like Param, it panics when
+// the rule has no param.
+func (v *Value) ParamPair() (first, second string, ok bool) {
+	return strings.Cut(v.Param(), ":")
+}
+
+// Context returns the context passed to ValidateCtx, or context.Background for Validate.
+func (v *Value) Context() context.Context {
+	if v.ctx == nil {
+		return context.Background()
+	}
+	return v.ctx
+}
+
+// Store saves data under key for the other validators of the same Validate call, e.g. a checksum
+// computed by one validator and verified by another. Like with context values, key should be of an
+// unexported type to avoid collisions. The data is dropped when the call returns. Store and Load are
+// safe for concurrent use by the workers of WithParallelEach; use Update to change data based on its
+// previous value.
+func (v *Value) Store(key, data any) {
+	if v.call == nil {
+		return
+	}
+	if v.call.state == nil {
+		v.call.state = &callState{}
+	}
+	v.call.state.store(key, data)
+}
+
+// Load returns the data saved under key by Store during the same Validate call.
+func (v *Value) Load(key any) (any, bool) {
+	if v.call == nil {
+		return nil, false
+	}
+	if v.call.state == nil {
+		return nil, false
+	}
+	return v.call.state.load(key)
+}
+
+// Update replaces the data under key with the result of fn, called with the data stored so far (nil
+// if none), and returns the new data. Unlike Load followed by Store, the workers of WithParallelEach
+// cannot interleave, so running totals like a size budget per request stay exact.
+func (v *Value) Update(key any, fn func(old any) any) any {
+	if v.call == nil {
+		return fn(nil)
+	}
+	if v.call.state == nil {
+		v.call.state = &callState{}
+	}
+	return v.call.state.update(key, fn)
+}
+
+// callState holds the data of Value.Store, guarded for the workers of WithParallelEach.
+type callState struct {
+	mu   sync.Mutex
+	data map[any]any
+}
+
+func (s *callState) store(key, data any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data == nil {
+		s.data = make(map[any]any)
+	}
+	s.data[key] = data
+}
+
+func (s *callState) update(key any, fn func(old any) any) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data == nil {
+		s.data = make(map[any]any)
+	}
+	data := fn(s.data[key])
+	s.data[key] = data
+	return data
+}
+
+func (s *callState) load(key any) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.data[key]
+	return data, ok
+}
+
+// Parent returns the struct containing the field, so custom validators can implement cross-field rules.
+// For elements of collections it is the struct containing the collection. It is the zero Value, for
+// which IsValid reports false, for values validated without a struct, e.g. with Var.
+func (v *Value) Parent() reflect.Value {
+	return v.parent
+}
+
+// Sibling returns the field name of the parent struct, promoted fields included, with the context of v:
+//
+//	v.RegisterTag("before_end", func(val *lakery.Value) error {
+//		end, err := val.Sibling(val.Param())
+//		if err != nil {
+//			return err
+//		}
+//		if val.Int() >= end.Int() {
+//			return fmt.Errorf("should be before %s", val.Param())
+//		}
+//		return nil
+//	})
+//
+// The error for unknown fields and values without a parent is a configuration error, see ConfigError,
+// so validators can return it as is. The returned Value has no param.
+func (v *Value) Sibling(name string) (*Value, error) {
+	f, err := v.field(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Value{val: f, name: name, parent: v.parent, validator: v.validator, ctx: v.ctx, call: v.call}, nil
+}
+
+// field returns the value of a sibling field by name.
+func (v *Value) field(name string) (reflect.Value, error) {
+	if !v.parent.IsValid() {
+		return reflect.Value{}, configErrorf("field %q cannot be resolved without a parent struct", name)
+	}
+	f := v.parent.FieldByName(name)
+	if !f.IsValid() {
+		return reflect.Value{}, configErrorf("unknown field %q", name)
+	}
+	return f, nil
+}
+
+// setString replaces the underlying string value (dereferencing pointers) for sanitizing tags.
+// It fails when the value is not settable, which happens when the struct was not passed by pointer.
+func (v *Value) setString(tag, s string) error {
+	rv := v.Deref().val
+	if !rv.IsValid() {
+		return nil
+	}
+	if !rv.CanSet() {
+		return configErrorf("%s cannot modify the value: pass a pointer to the struct to Validate", tag)
+	}
+	rv.SetString(s)
+	return nil
+}
+
+// derefValue follows pointers and interfaces and reports false when a nil one is met.
+func derefValue(rv reflect.Value) (reflect.Value, bool) {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return rv, false
+		}
+		rv = rv.Elem()
 	}
-	panic(fmt.Sprintf("requested param value for %q is not set", v.name))
+	return rv, true
 }